@@ -758,7 +758,12 @@ func (s *ReturnStmt) Pos() token.Pos { return s.Return }
 func (s *BranchStmt) Pos() token.Pos { return s.TokPos }
 
 // Pos returns position of first character belonging to the node.
-func (s *BlockStmt) Pos() token.Pos { return s.Lbrace }
+func (s *BlockStmt) Pos() token.Pos {
+	if !s.Lbrace.IsValid() && len(s.List) > 0 {
+		return s.List[0].Pos() // body of the func main of a script, without braces
+	}
+	return s.Lbrace
+}
 
 // Pos returns position of first character belonging to the node.
 func (s *IfStmt) Pos() token.Pos { return s.If }
@@ -1047,7 +1052,12 @@ func (d *BadDecl) Pos() token.Pos { return d.From }
 func (d *GenDecl) Pos() token.Pos { return d.TokPos }
 
 // Pos returns position of first character belonging to the node.
-func (d *FuncDecl) Pos() token.Pos { return d.Type.Pos() }
+func (d *FuncDecl) Pos() token.Pos {
+	if pos := d.Type.Pos(); pos.IsValid() || d.Body == nil {
+		return pos
+	}
+	return d.Body.Pos() // func main of a script, without the func keyword
+}
 
 // End returns position of first character immediately after the node.
 func (d *BadDecl) End() token.Pos { return d.To }
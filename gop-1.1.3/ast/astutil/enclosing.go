@@ -60,6 +60,13 @@ import (
 // file, but unfortunately ast.File records only the token.Pos of
 // the 'package' keyword, but not of the start of the file itself.
 func PathEnclosingInterval(root *ast.File, start, end token.Pos) (path []ast.Node, exact bool) {
+	return pathEnclosingInterval(root, start, end, childrenOf)
+}
+
+// pathEnclosingInterval implements PathEnclosingInterval and
+// GopPathEnclosingInterval; childrenOf returns the children of a node
+// of the root's syntax tree.
+func pathEnclosingInterval(root ast.Node, start, end token.Pos, childrenOf func(ast.Node) []ast.Node) (path []ast.Node, exact bool) {
 	// fmt.Printf("EnclosingInterval %d %d\n", start, end) // debugging
 
 	// Precondition: node.[Pos..End) and adjoining whitespace contain [start, end).
@@ -481,7 +488,7 @@ func (sl byPos) Swap(i, j int) {
 }
 
 // NodeDescription returns a description of the concrete type of n suitable
-// for a user interface.
+// for a user interface. n may be a go/ast or a gop/ast node.
 //
 // TODO(adonovan): in some cases (e.g. Field, FieldList, Ident,
 // StarExpr) we could be much more specific given the path to the AST
@@ -632,5 +639,8 @@ func NodeDescription(n ast.Node) string {
 		return "value specification"
 
 	}
+	if desc := gopNodeDescription(n); desc != "" {
+		return desc
+	}
 	panic(fmt.Sprintf("unexpected node type: %T", n))
 }
@@ -0,0 +1,508 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package astutil
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	goptoken "github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+)
+
+// GopPathEnclosingInterval is PathEnclosingInterval for a Go+ file. The
+// path is made of gop/ast nodes; the bare tokens of Go+ syntax, such as
+// the => of a lambda, the <- of a for phrase, the ? and ! of error wrapping,
+// the colons of a range expression and the brackets of a comprehension,
+// are treated as nodes as the tokens of Go are.
+func GopPathEnclosingInterval(root *gopast.File, start, end token.Pos) (path []ast.Node, exact bool) {
+	return pathEnclosingInterval(root, start, end, gopChildrenOf)
+}
+
+// gopChildrenOf is childrenOf for a node of a Go+ syntax tree. Nodes and
+// tokens the parser synthesizes without a position, such as the package
+// name of a file without a package clause, are left out.
+func gopChildrenOf(n ast.Node) []ast.Node {
+	var children []ast.Node
+
+	// First add nodes for all true subtrees.
+	gopast.Inspect(n, func(node ast.Node) bool {
+		if node == n { // push n
+			return true // recur
+		}
+		if node != nil { // push child
+			children = append(children, node)
+		}
+		return false // no recursion
+	})
+
+	// Then add fake Nodes for bare tokens.
+	switch n := n.(type) {
+	case *gopast.ArrayType:
+		children = append(children,
+			tok(n.Lbrack, len("[")),
+			tok(n.Elt.End(), len("]")))
+
+	case *gopast.AssignStmt:
+		children = append(children,
+			tok(n.TokPos, len(n.Tok.String())))
+
+	case *gopast.BasicLit:
+		children = append(children,
+			tok(n.ValuePos, len(n.Value)))
+
+	case *gopast.BinaryExpr:
+		children = append(children, tok(n.OpPos, len(n.Op.String())))
+
+	case *gopast.BlockStmt:
+		children = append(children,
+			tok(n.Lbrace, len("{")),
+			tok(n.Rbrace, len("}")))
+
+	case *gopast.BranchStmt:
+		children = append(children,
+			tok(n.TokPos, len(n.Tok.String())))
+
+	case *gopast.CallExpr:
+		// A command-style call such as `println x` has no parentheses.
+		children = append(children,
+			tok(n.Lparen, len("(")),
+			tok(n.Rparen, len(")")))
+		if n.Ellipsis != 0 {
+			children = append(children, tok(n.Ellipsis, len("...")))
+		}
+
+	case *gopast.CaseClause:
+		if n.List == nil {
+			children = append(children,
+				tok(n.Case, len("default")))
+		} else {
+			children = append(children,
+				tok(n.Case, len("case")))
+		}
+		children = append(children, tok(n.Colon, len(":")))
+
+	case *gopast.ChanType:
+		switch n.Dir {
+		case gopast.RECV:
+			children = append(children, tok(n.Begin, len("<-chan")))
+		case gopast.SEND:
+			children = append(children, tok(n.Begin, len("chan<-")))
+		case gopast.RECV | gopast.SEND:
+			children = append(children, tok(n.Begin, len("chan")))
+		}
+
+	case *gopast.CommClause:
+		if n.Comm == nil {
+			children = append(children,
+				tok(n.Case, len("default")))
+		} else {
+			children = append(children,
+				tok(n.Case, len("case")))
+		}
+		children = append(children, tok(n.Colon, len(":")))
+
+	case *gopast.CompositeLit:
+		children = append(children,
+			tok(n.Lbrace, len("{")),
+			tok(n.Rbrace, len("{")))
+
+	case *gopast.DeferStmt:
+		children = append(children,
+			tok(n.Defer, len("defer")))
+
+	case *gopast.Ellipsis:
+		children = append(children,
+			tok(n.Ellipsis, len("...")))
+
+	case *gopast.FieldList:
+		children = append(children,
+			tok(n.Opening, len("(")), // or len("[")
+			tok(n.Closing, len(")"))) // or len("]")
+
+	case *gopast.File:
+		children = append(children,
+			tok(n.Package, len("package")))
+
+	case *gopast.ForStmt:
+		children = append(children,
+			tok(n.For, len("for")))
+
+	case *gopast.FuncDecl:
+		// As in childrenOf, the FuncType is inlined so that the
+		// receiver precedes the parameters. The func main of a
+		// script has only a body, without braces.
+		children = nil // discard ast.Walk(FuncDecl) info subtrees
+		children = append(children, tok(n.Type.Func, len("func")))
+		if n.Recv != nil {
+			children = append(children, n.Recv)
+		}
+		if n.Type.Func.IsValid() {
+			children = append(children, n.Name)
+		}
+		if n.Type.Params != nil {
+			children = append(children, n.Type.Params)
+		}
+		if n.Type.Results != nil {
+			children = append(children, n.Type.Results)
+		}
+		if n.Body != nil {
+			children = append(children, n.Body)
+		}
+
+	case *gopast.FuncType:
+		children = append(children,
+			tok(n.Func, len("func")))
+
+	case *gopast.GenDecl:
+		children = append(children,
+			tok(n.TokPos, len(n.Tok.String())))
+		if n.Lparen != 0 {
+			children = append(children,
+				tok(n.Lparen, len("(")),
+				tok(n.Rparen, len(")")))
+		}
+
+	case *gopast.GoStmt:
+		children = append(children,
+			tok(n.Go, len("go")))
+
+	case *gopast.Ident:
+		children = append(children,
+			tok(n.NamePos, len(n.Name)))
+
+	case *gopast.IfStmt:
+		children = append(children,
+			tok(n.If, len("if")))
+
+	case *gopast.IncDecStmt:
+		children = append(children,
+			tok(n.TokPos, len(n.Tok.String())))
+
+	case *gopast.IndexExpr:
+		children = append(children,
+			tok(n.Lbrack, len("[")),
+			tok(n.Rbrack, len("]")))
+
+	case *gopast.InterfaceType:
+		children = append(children,
+			tok(n.Interface, len("interface")))
+
+	case *gopast.KeyValueExpr:
+		children = append(children,
+			tok(n.Colon, len(":")))
+
+	case *gopast.LabeledStmt:
+		children = append(children,
+			tok(n.Colon, len(":")))
+
+	case *gopast.MapType:
+		children = append(children,
+			tok(n.Map, len("map")))
+
+	case *gopast.ParenExpr:
+		children = append(children,
+			tok(n.Lparen, len("(")),
+			tok(n.Rparen, len(")")))
+
+	case *gopast.RangeStmt:
+		children = append(children,
+			tok(n.For, len("for")),
+			tok(n.TokPos, len(n.Tok.String())))
+
+	case *gopast.ReturnStmt:
+		children = append(children,
+			tok(n.Return, len("return")))
+
+	case *gopast.SelectStmt:
+		children = append(children,
+			tok(n.Select, len("select")))
+
+	case *gopast.SendStmt:
+		children = append(children,
+			tok(n.Arrow, len("<-")))
+
+	case *gopast.SliceExpr:
+		children = append(children,
+			tok(n.Lbrack, len("[")),
+			tok(n.Rbrack, len("]")))
+
+	case *gopast.StarExpr:
+		children = append(children, tok(n.Star, len("*")))
+
+	case *gopast.StructType:
+		children = append(children, tok(n.Struct, len("struct")))
+
+	case *gopast.SwitchStmt:
+		children = append(children, tok(n.Switch, len("switch")))
+
+	case *gopast.TypeAssertExpr:
+		children = append(children,
+			tok(n.Lparen-1, len(".")),
+			tok(n.Lparen, len("(")),
+			tok(n.Rparen, len(")")))
+
+	case *gopast.TypeSwitchStmt:
+		children = append(children, tok(n.Switch, len("switch")))
+
+	case *gopast.UnaryExpr:
+		children = append(children, tok(n.OpPos, len(n.Op.String())))
+
+	// Go+ nodes
+	case *gopast.SliceLit:
+		children = append(children,
+			tok(n.Lbrack, len("[")),
+			tok(n.Rbrack, len("]")))
+
+	case *gopast.ErrWrapExpr:
+		children = append(children,
+			tok(n.TokPos, len(n.Tok.String())))
+		if n.Default != nil {
+			// the : of expr?:defval
+			children = append(children, tok(n.TokPos+1, len(":")))
+		}
+
+	case *gopast.LambdaExpr:
+		if n.LhsHasParen {
+			children = append(children, tok(n.First, len("(")))
+		}
+		children = append(children, tok(n.Rarrow, len("=>")))
+
+	case *gopast.LambdaExpr2:
+		if n.LhsHasParen {
+			children = append(children, tok(n.First, len("(")))
+		}
+		children = append(children, tok(n.Rarrow, len("=>")))
+
+	case *gopast.ForPhrase:
+		children = append(children,
+			tok(n.For, len("for")),
+			tok(n.TokPos, len("<-")))
+		if n.IfPos.IsValid() {
+			// IfPos is that of either "if" or ","; the distance
+			// to the condition tells them apart.
+			next := ast.Node(n.Cond)
+			if n.Init != nil {
+				next = n.Init
+			}
+			l := len("if")
+			if next != nil && int(next.Pos()-n.IfPos) < len("if ") {
+				l = len(",")
+			}
+			children = append(children, tok(n.IfPos, l))
+		}
+
+	case *gopast.ComprehensionExpr:
+		if n.Tok == goptoken.LBRACK {
+			children = append(children,
+				tok(n.Lpos, len("[")),
+				tok(n.Rpos, len("]")))
+		} else {
+			children = append(children,
+				tok(n.Lpos, len("{")),
+				tok(n.Rpos, len("}")))
+		}
+
+	case *gopast.RangeExpr:
+		children = append(children, tok(n.To, len(":")))
+		if n.Colon2.IsValid() {
+			children = append(children, tok(n.Colon2, len(":")))
+		}
+	}
+
+	valid := children[:0]
+	for _, child := range children {
+		if child.Pos().IsValid() {
+			valid = append(valid, child)
+		}
+	}
+	children = valid
+	sort.Sort(byPos(children))
+
+	return children
+}
+
+// gopNodeDescription is NodeDescription for a node of a Go+ syntax tree.
+// It returns "" if n is not a gop/ast node.
+func gopNodeDescription(n ast.Node) string {
+	switch n := n.(type) {
+	case *gopast.ArrayType:
+		return "array type"
+	case *gopast.AssignStmt:
+		return "assignment"
+	case *gopast.BadDecl:
+		return "bad declaration"
+	case *gopast.BadExpr:
+		return "bad expression"
+	case *gopast.BadStmt:
+		return "bad statement"
+	case *gopast.BasicLit:
+		return "basic literal"
+	case *gopast.BinaryExpr:
+		return fmt.Sprintf("binary %s operation", n.Op)
+	case *gopast.BlockStmt:
+		return "block"
+	case *gopast.BranchStmt:
+		switch n.Tok {
+		case goptoken.BREAK:
+			return "break statement"
+		case goptoken.CONTINUE:
+			return "continue statement"
+		case goptoken.GOTO:
+			return "goto statement"
+		case goptoken.FALLTHROUGH:
+			return "fall-through statement"
+		}
+	case *gopast.CallExpr:
+		if n.IsCommand() {
+			return "command-style function call"
+		}
+		if len(n.Args) == 1 && !n.Ellipsis.IsValid() {
+			return "function call (or conversion)"
+		}
+		return "function call"
+	case *gopast.CaseClause:
+		return "case clause"
+	case *gopast.ChanType:
+		return "channel type"
+	case *gopast.CommClause:
+		return "communication clause"
+	case *gopast.CompositeLit:
+		return "composite literal"
+	case *gopast.DeclStmt:
+		return NodeDescription(n.Decl) + " statement"
+	case *gopast.DeferStmt:
+		return "defer statement"
+	case *gopast.Ellipsis:
+		return "ellipsis"
+	case *gopast.EmptyStmt:
+		return "empty statement"
+	case *gopast.ExprStmt:
+		return "expression statement"
+	case *gopast.Field:
+		return "field/method/parameter"
+	case *gopast.FieldList:
+		return "field/method/parameter list"
+	case *gopast.File:
+		return "source file"
+	case *gopast.ForStmt:
+		return "for loop"
+	case *gopast.FuncDecl:
+		return "function declaration"
+	case *gopast.FuncLit:
+		return "function literal"
+	case *gopast.FuncType:
+		return "function type"
+	case *gopast.GenDecl:
+		switch n.Tok {
+		case goptoken.IMPORT:
+			return "import declaration"
+		case goptoken.CONST:
+			return "constant declaration"
+		case goptoken.TYPE:
+			return "type declaration"
+		case goptoken.VAR:
+			return "variable declaration"
+		}
+	case *gopast.GoStmt:
+		return "go statement"
+	case *gopast.Ident:
+		return "identifier"
+	case *gopast.IfStmt:
+		return "if statement"
+	case *gopast.ImportSpec:
+		return "import specification"
+	case *gopast.IncDecStmt:
+		if n.Tok == goptoken.INC {
+			return "increment statement"
+		}
+		return "decrement statement"
+	case *gopast.IndexExpr:
+		return "index expression"
+	case *gopast.InterfaceType:
+		return "interface type"
+	case *gopast.KeyValueExpr:
+		return "key/value association"
+	case *gopast.LabeledStmt:
+		return "statement label"
+	case *gopast.MapType:
+		return "map type"
+	case *gopast.Package:
+		return "package"
+	case *gopast.ParenExpr:
+		return "parenthesized " + NodeDescription(n.X)
+	case *gopast.RangeStmt:
+		return "range loop"
+	case *gopast.ReturnStmt:
+		return "return statement"
+	case *gopast.SelectStmt:
+		return "select statement"
+	case *gopast.SelectorExpr:
+		return "selector"
+	case *gopast.SendStmt:
+		return "channel send"
+	case *gopast.SliceExpr:
+		return "slice expression"
+	case *gopast.StarExpr:
+		return "*-operation" // load/store expr or pointer type
+	case *gopast.StructType:
+		return "struct type"
+	case *gopast.SwitchStmt:
+		return "switch statement"
+	case *gopast.TypeAssertExpr:
+		return "type assertion"
+	case *gopast.TypeSpec:
+		return "type specification"
+	case *gopast.TypeSwitchStmt:
+		return "type switch"
+	case *gopast.UnaryExpr:
+		return fmt.Sprintf("unary %s operation", n.Op)
+	case *gopast.ValueSpec:
+		return "value specification"
+
+	// Go+ nodes
+	case *gopast.SliceLit:
+		return "slice literal"
+	case *gopast.ErrWrapExpr:
+		if n.Default != nil {
+			return "error wrapping ?: operation"
+		}
+		return fmt.Sprintf("error wrapping %s operation", n.Tok)
+	case *gopast.LambdaExpr, *gopast.LambdaExpr2:
+		return "lambda expression"
+	case *gopast.ForPhrase:
+		return "for phrase"
+	case *gopast.ComprehensionExpr:
+		switch {
+		case n.Tok == goptoken.LBRACK:
+			return "list comprehension"
+		case n.Elt == nil:
+			return "existence check"
+		}
+		if _, ok := n.Elt.(*gopast.KeyValueExpr); ok {
+			return "map comprehension"
+		}
+		return "select comprehension"
+	case *gopast.ForPhraseStmt:
+		return "for phrase loop"
+	case *gopast.RangeExpr:
+		return "range expression"
+	}
+	return ""
+}
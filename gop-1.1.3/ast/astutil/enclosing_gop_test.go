@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package astutil_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast/astutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+)
+
+const gopInput = `import "strings"
+
+for x <- [1, 2, 3], x > 1 {
+	println x
+}
+f := (a, b) => a + b
+g := s => {
+	return strings.ToUpper(s)
+}
+evens := [v * 2 for v <- 1:10:2 if v%2 == 0]
+n := atoi("12")?:0
+m := atoi("12")!
+`
+
+func TestGopPathEnclosingInterval(t *testing.T) {
+	tests := []struct {
+		substr string // the interval starts at the first occurrence of substr
+		n      int    // the length of the interval, if not len(substr)
+		path   string // types of the nodes of the path
+		exact  bool
+	}{
+		// the <- of a for phrase statement, and its range
+		{"<-", 0, "[ForPhrase ForPhraseStmt BlockStmt FuncDecl File]", true},
+		{"[1, 2, 3]", 0, "[SliceLit ForPhrase ForPhraseStmt BlockStmt FuncDecl File]", true},
+		{", x > 1", 1, "[ForPhrase ForPhraseStmt BlockStmt FuncDecl File]", true},
+		{"x > 1", 0, "[BinaryExpr ForPhrase ForPhraseStmt BlockStmt FuncDecl File]", true},
+		{"println", 0, "[Ident CallExpr ExprStmt BlockStmt ForPhraseStmt BlockStmt FuncDecl File]", true},
+
+		// lambdas
+		{"=> a", 2, "[LambdaExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"(a, b)", 0, "[LambdaExpr AssignStmt BlockStmt FuncDecl File]", false},
+		{"b) =>", 1, "[Ident LambdaExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"a + b", 0, "[BinaryExpr LambdaExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"=> {", 2, "[LambdaExpr2 AssignStmt BlockStmt FuncDecl File]", true},
+		{"ToUpper", 0, "[Ident SelectorExpr CallExpr ReturnStmt BlockStmt LambdaExpr2 AssignStmt BlockStmt FuncDecl File]", true},
+
+		// a list comprehension over a range expression
+		{"[v", 1, "[ComprehensionExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"0]", 1, "[BasicLit BinaryExpr ForPhrase ComprehensionExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"v * 2", 0, "[BinaryExpr ComprehensionExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"for v", 3, "[ForPhrase ComprehensionExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"1:10:2", 0, "[RangeExpr ForPhrase ComprehensionExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{":10", 1, "[RangeExpr ForPhrase ComprehensionExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"10", 0, "[BasicLit RangeExpr ForPhrase ComprehensionExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"if v", 2, "[ForPhrase ComprehensionExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"v%2", 0, "[BinaryExpr BinaryExpr ForPhrase ComprehensionExpr AssignStmt BlockStmt FuncDecl File]", true},
+
+		// error wrapping
+		{"?", 0, "[ErrWrapExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{":0", 1, "[ErrWrapExpr AssignStmt BlockStmt FuncDecl File]", true},
+		{"!", 0, "[ErrWrapExpr AssignStmt BlockStmt FuncDecl File]", true},
+
+		// the imports, before the statements of the script
+		{`"strings"`, 0, "[BasicLit ImportSpec GenDecl File]", true},
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "<input>", gopInput, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokFile := fset.File(f.Decls[0].Pos())
+	for _, test := range tests {
+		i := strings.Index(gopInput, test.substr)
+		if i < 0 {
+			t.Errorf("%q is not a substring of input", test.substr)
+			continue
+		}
+		start := tokFile.Pos(i)
+		n := test.n
+		if n == 0 {
+			n = len(test.substr)
+		}
+		end := start + token.Pos(n)
+		path, exact := astutil.GopPathEnclosingInterval(f, start, end)
+		if got := pathToString(path); got != test.path {
+			t.Errorf("GopPathEnclosingInterval(%q): got path %s, want %s", test.substr, got, test.path)
+			continue
+		}
+		if exact != test.exact {
+			t.Errorf("GopPathEnclosingInterval(%q): got exact %t, want %t", test.substr, exact, test.exact)
+		}
+	}
+}
+
+// TestGopPathEnclosingIntervalTestdata checks that every position of the
+// parser/_testdata files is enclosed by the node found for it, and that the
+// nodes of the path can be described.
+func TestGopPathEnclosingIntervalTestdata(t *testing.T) {
+	const dir = "../../parser/_testdata"
+	fis, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		fset := token.NewFileSet()
+		pkgs, err := parser.ParseDir(fset, path.Join(dir, fi.Name()), nil, parser.ParseComments|parser.ParseGoAsGoPlus)
+		if err != nil {
+			t.Fatalf("%s: %v", fi.Name(), err)
+		}
+		for _, pkg := range pkgs {
+			for name, f := range pkg.Files {
+				checkGopPaths(t, fset, name, f)
+			}
+		}
+	}
+}
+
+func checkGopPaths(t *testing.T, fset *token.FileSet, name string, f *gopast.File) {
+	var tokFile *token.File
+	fset.Iterate(func(file *token.File) bool {
+		if file.Name() == name {
+			tokFile = file
+			return false
+		}
+		return true
+	})
+	for off := 0; off < tokFile.Size(); off++ {
+		pos := tokFile.Pos(off)
+		path, exact := astutil.GopPathEnclosingInterval(f, pos, pos)
+		if len(path) == 0 || path[len(path)-1] != f {
+			t.Errorf("%s:%d: path does not end with the file", name, off)
+			return
+		}
+		for _, n := range path {
+			astutil.NodeDescription(n) // must not panic
+		}
+		if exact && (pos < path[0].Pos() || pos >= path[0].End()) {
+			t.Errorf("%s: %s is not in the exact %T [%s, %s)", name, fset.Position(pos),
+				path[0], fset.Position(path[0].Pos()), fset.Position(path[0].End()))
+			return
+		}
+	}
+}
+
+func TestGopNodeDescription(t *testing.T) {
+	const src = `a := [x for x <- s]
+b := {x: 1 for x <- s}
+c := {x for x <- s}
+d := {for x <- s if x > 1}
+e := f()!
+g := f()?:0
+for x <- s {
+}
+println x => x
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "<input>", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	gopast.Inspect(f, func(n gopast.Node) bool {
+		switch n.(type) {
+		case *gopast.ComprehensionExpr, *gopast.ErrWrapExpr, *gopast.ForPhrase,
+			*gopast.ForPhraseStmt, *gopast.LambdaExpr, *gopast.CallExpr:
+			got = append(got, astutil.NodeDescription(n))
+		}
+		return true
+	})
+	want := []string{
+		"list comprehension", "for phrase",
+		"map comprehension", "for phrase",
+		"select comprehension", "for phrase",
+		"existence check", "for phrase",
+		"error wrapping ! operation", "function call",
+		"error wrapping ?: operation", "function call",
+		"for phrase loop", "for phrase",
+		"command-style function call", "lambda expression",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got descriptions %q, want %q", got, want)
+	}
+}
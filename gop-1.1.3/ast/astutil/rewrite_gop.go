@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package astutil
+
+import (
+	"go/ast"
+	"sort"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+)
+
+// applyGop walks the children of n, a node of a Go+ syntax tree. It reports
+// whether n is a gop/ast node. Comments are shared with go/ast and handled
+// by apply.
+func (a *application) applyGop(n ast.Node) bool {
+	// (the order of the cases matches the order of the corresponding node
+	// types in gop/ast, followed by those of ast_gop.go)
+	switch n := n.(type) {
+	// Fields
+	case *gopast.Field:
+		a.apply(n, "Doc", nil, n.Doc)
+		a.applyList(n, "Names")
+		a.apply(n, "Type", nil, n.Type)
+		a.apply(n, "Tag", nil, n.Tag)
+		a.apply(n, "Comment", nil, n.Comment)
+
+	case *gopast.FieldList:
+		a.applyList(n, "List")
+
+	// Expressions
+	case *gopast.BadExpr, *gopast.Ident, *gopast.BasicLit:
+		// nothing to do
+
+	case *gopast.Ellipsis:
+		a.apply(n, "Elt", nil, n.Elt)
+
+	case *gopast.FuncLit:
+		a.apply(n, "Type", nil, n.Type)
+		a.apply(n, "Body", nil, n.Body)
+
+	case *gopast.CompositeLit:
+		a.apply(n, "Type", nil, n.Type)
+		a.applyList(n, "Elts")
+
+	case *gopast.ParenExpr:
+		a.apply(n, "X", nil, n.X)
+
+	case *gopast.SelectorExpr:
+		a.apply(n, "X", nil, n.X)
+		a.apply(n, "Sel", nil, n.Sel)
+
+	case *gopast.IndexExpr:
+		a.apply(n, "X", nil, n.X)
+		a.apply(n, "Index", nil, n.Index)
+
+	case *gopast.SliceExpr:
+		a.apply(n, "X", nil, n.X)
+		a.apply(n, "Low", nil, n.Low)
+		a.apply(n, "High", nil, n.High)
+		a.apply(n, "Max", nil, n.Max)
+
+	case *gopast.TypeAssertExpr:
+		a.apply(n, "X", nil, n.X)
+		a.apply(n, "Type", nil, n.Type)
+
+	case *gopast.CallExpr:
+		a.apply(n, "Fun", nil, n.Fun)
+		a.applyList(n, "Args")
+
+	case *gopast.StarExpr:
+		a.apply(n, "X", nil, n.X)
+
+	case *gopast.UnaryExpr:
+		a.apply(n, "X", nil, n.X)
+
+	case *gopast.BinaryExpr:
+		a.apply(n, "X", nil, n.X)
+		a.apply(n, "Y", nil, n.Y)
+
+	case *gopast.KeyValueExpr:
+		a.apply(n, "Key", nil, n.Key)
+		a.apply(n, "Value", nil, n.Value)
+
+	// Types
+	case *gopast.ArrayType:
+		a.apply(n, "Len", nil, n.Len)
+		a.apply(n, "Elt", nil, n.Elt)
+
+	case *gopast.StructType:
+		a.apply(n, "Fields", nil, n.Fields)
+
+	case *gopast.FuncType:
+		a.apply(n, "Params", nil, n.Params)
+		a.apply(n, "Results", nil, n.Results)
+
+	case *gopast.InterfaceType:
+		a.apply(n, "Methods", nil, n.Methods)
+
+	case *gopast.MapType:
+		a.apply(n, "Key", nil, n.Key)
+		a.apply(n, "Value", nil, n.Value)
+
+	case *gopast.ChanType:
+		a.apply(n, "Value", nil, n.Value)
+
+	// Statements
+	case *gopast.BadStmt:
+		// nothing to do
+
+	case *gopast.DeclStmt:
+		a.apply(n, "Decl", nil, n.Decl)
+
+	case *gopast.EmptyStmt:
+		// nothing to do
+
+	case *gopast.LabeledStmt:
+		a.apply(n, "Label", nil, n.Label)
+		a.apply(n, "Stmt", nil, n.Stmt)
+
+	case *gopast.ExprStmt:
+		a.apply(n, "X", nil, n.X)
+
+	case *gopast.SendStmt:
+		a.apply(n, "Chan", nil, n.Chan)
+		a.apply(n, "Value", nil, n.Value)
+
+	case *gopast.IncDecStmt:
+		a.apply(n, "X", nil, n.X)
+
+	case *gopast.AssignStmt:
+		a.applyList(n, "Lhs")
+		a.applyList(n, "Rhs")
+
+	case *gopast.GoStmt:
+		a.apply(n, "Call", nil, n.Call)
+
+	case *gopast.DeferStmt:
+		a.apply(n, "Call", nil, n.Call)
+
+	case *gopast.ReturnStmt:
+		a.applyList(n, "Results")
+
+	case *gopast.BranchStmt:
+		a.apply(n, "Label", nil, n.Label)
+
+	case *gopast.BlockStmt:
+		a.applyList(n, "List")
+
+	case *gopast.IfStmt:
+		a.apply(n, "Init", nil, n.Init)
+		a.apply(n, "Cond", nil, n.Cond)
+		a.apply(n, "Body", nil, n.Body)
+		a.apply(n, "Else", nil, n.Else)
+
+	case *gopast.CaseClause:
+		a.applyList(n, "List")
+		a.applyList(n, "Body")
+
+	case *gopast.SwitchStmt:
+		a.apply(n, "Init", nil, n.Init)
+		a.apply(n, "Tag", nil, n.Tag)
+		a.apply(n, "Body", nil, n.Body)
+
+	case *gopast.TypeSwitchStmt:
+		a.apply(n, "Init", nil, n.Init)
+		a.apply(n, "Assign", nil, n.Assign)
+		a.apply(n, "Body", nil, n.Body)
+
+	case *gopast.CommClause:
+		a.apply(n, "Comm", nil, n.Comm)
+		a.applyList(n, "Body")
+
+	case *gopast.SelectStmt:
+		a.apply(n, "Body", nil, n.Body)
+
+	case *gopast.ForStmt:
+		a.apply(n, "Init", nil, n.Init)
+		a.apply(n, "Cond", nil, n.Cond)
+		a.apply(n, "Post", nil, n.Post)
+		a.apply(n, "Body", nil, n.Body)
+
+	case *gopast.RangeStmt:
+		a.apply(n, "Key", nil, n.Key)
+		a.apply(n, "Value", nil, n.Value)
+		a.apply(n, "X", nil, n.X)
+		a.apply(n, "Body", nil, n.Body)
+
+	// Declarations
+	case *gopast.ImportSpec:
+		a.apply(n, "Doc", nil, n.Doc)
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Path", nil, n.Path)
+		a.apply(n, "Comment", nil, n.Comment)
+
+	case *gopast.ValueSpec:
+		a.apply(n, "Doc", nil, n.Doc)
+		a.applyList(n, "Names")
+		a.apply(n, "Type", nil, n.Type)
+		a.applyList(n, "Values")
+		a.apply(n, "Comment", nil, n.Comment)
+
+	case *gopast.TypeSpec:
+		a.apply(n, "Doc", nil, n.Doc)
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Type", nil, n.Type)
+		a.apply(n, "Comment", nil, n.Comment)
+
+	case *gopast.BadDecl:
+		// nothing to do
+
+	case *gopast.GenDecl:
+		a.apply(n, "Doc", nil, n.Doc)
+		a.applyList(n, "Specs")
+
+	case *gopast.FuncDecl:
+		a.apply(n, "Doc", nil, n.Doc)
+		a.apply(n, "Recv", nil, n.Recv)
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Type", nil, n.Type)
+		a.apply(n, "Body", nil, n.Body)
+
+	// Files and packages
+	case *gopast.File:
+		a.apply(n, "Doc", nil, n.Doc)
+		a.apply(n, "Name", nil, n.Name)
+		a.applyList(n, "Decls")
+		// Don't walk n.Comments; they have either been walked already if
+		// they are Doc comments, or they can be easily walked explicitly.
+
+	case *gopast.Package:
+		// collect and sort names for reproducible behavior
+		var names []string
+		for name := range n.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			a.apply(n, name, nil, n.Files[name])
+		}
+
+	// Go+ expressions and statements
+	case *gopast.SliceLit:
+		a.applyList(n, "Elts")
+
+	case *gopast.ErrWrapExpr:
+		a.apply(n, "X", nil, n.X)
+		a.apply(n, "Default", nil, n.Default)
+
+	case *gopast.LambdaExpr:
+		a.applyList(n, "Lhs")
+		a.applyList(n, "Rhs")
+
+	case *gopast.LambdaExpr2:
+		a.applyList(n, "Lhs")
+		a.apply(n, "Body", nil, n.Body)
+
+	case *gopast.ForPhrase:
+		a.apply(n, "Key", nil, n.Key)
+		a.apply(n, "Value", nil, n.Value)
+		a.apply(n, "X", nil, n.X)
+		a.apply(n, "Init", nil, n.Init)
+		a.apply(n, "Cond", nil, n.Cond)
+
+	case *gopast.ComprehensionExpr:
+		a.apply(n, "Elt", nil, n.Elt)
+		a.applyList(n, "Fors")
+
+	case *gopast.ForPhraseStmt:
+		a.apply(n, "ForPhrase", nil, n.ForPhrase)
+		a.apply(n, "Body", nil, n.Body)
+
+	case *gopast.RangeExpr:
+		a.apply(n, "First", nil, n.First)
+		a.apply(n, "Last", nil, n.Last)
+		a.apply(n, "Expr3", nil, n.Expr3)
+
+	default:
+		return false
+	}
+	return true
+}
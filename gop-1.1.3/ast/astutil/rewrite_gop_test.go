@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package astutil_test
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast/astutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/format"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+)
+
+// TestApplyGopTestdata checks that Apply visits the nodes of each
+// parser/_testdata package in the same order as gop/ast.Inspect.
+func TestApplyGopTestdata(t *testing.T) {
+	const dir = "../../parser/_testdata"
+	fis, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		fset := token.NewFileSet()
+		pkgs, err := parser.ParseDir(fset, path.Join(dir, fi.Name()), nil, parser.ParseComments|parser.ParseGoAsGoPlus)
+		if err != nil {
+			t.Fatalf("%s: %v", fi.Name(), err)
+		}
+		for _, pkg := range pkgs {
+			var want []gopast.Node
+			gopast.Inspect(pkg, func(n gopast.Node) bool {
+				if n != nil {
+					want = append(want, n)
+				}
+				return true
+			})
+			var got []gopast.Node
+			astutil.Apply(pkg, func(c *astutil.Cursor) bool {
+				if c.Node() != nil {
+					got = append(got, c.Node())
+				}
+				return true
+			}, nil)
+			if len(got) != len(want) {
+				t.Errorf("%s: Apply visited %d nodes, Inspect %d", fi.Name(), len(got), len(want))
+				continue
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s: node %d: Apply visited %T, Inspect %T", fi.Name(), i, got[i], want[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestApplyGopRewrite(t *testing.T) {
+	const src = `for x <- [1, 2] if x > 1 {
+	f(y => y * 2, (a, b) => {
+		return a + b
+	})
+	v := g()?:0
+	_ = {k: v for k, v <- m if k != ""}
+	for i <- 1:10:2 {
+	}
+}
+`
+	const want = `for X <- [1, 2] if X > 1 {
+	f(Y => Y * 2, (A, B) => {
+		return A + B
+	})
+	V := g()?:0
+	_ = {K: V for K, V <- m if K != ""}
+	for I <- 1:10:2 {
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.gop", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rename := map[string]string{"x": "X", "y": "Y", "a": "A", "b": "B", "v": "V", "k": "K", "i": "I"}
+	astutil.Apply(f, func(c *astutil.Cursor) bool {
+		if id, ok := c.Node().(*gopast.Ident); ok {
+			if name, ok := rename[id.Name]; ok {
+				c.Replace(&gopast.Ident{NamePos: id.NamePos, Name: name})
+			}
+		}
+		return true
+	}, nil)
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, f); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -28,162 +28,306 @@ import (
 
 // ----------------------------------------------------------------------------
 
-func gopExpr(val ast.Expr) gopast.Expr {
+func (p *converter) gopExpr(val ast.Expr) gopast.Expr {
 	if val == nil {
 		return nil
 	}
 	switch v := val.(type) {
 	case *ast.Ident:
-		return gopIdent(v)
+		return p.gopIdent(v)
 	case *ast.SelectorExpr:
 		return &gopast.SelectorExpr{
-			X:   gopExpr(v.X),
-			Sel: gopIdent(v.Sel),
+			X:   p.gopExpr(v.X),
+			Sel: p.gopIdent(v.Sel),
 		}
 	case *ast.SliceExpr:
 		return &gopast.SliceExpr{
-			X:      gopExpr(v.X),
+			X:      p.gopExpr(v.X),
 			Lbrack: v.Lbrack,
-			Low:    gopExpr(v.Low),
-			High:   gopExpr(v.High),
-			Max:    gopExpr(v.Max),
+			Low:    p.gopExpr(v.Low),
+			High:   p.gopExpr(v.High),
+			Max:    p.gopExpr(v.Max),
 			Slice3: v.Slice3,
 			Rbrack: v.Rbrack,
 		}
 	case *ast.StarExpr:
 		return &gopast.StarExpr{
 			Star: v.Star,
-			X:    gopExpr(v.X),
+			X:    p.gopExpr(v.X),
 		}
 	case *ast.MapType:
 		return &gopast.MapType{
 			Map:   v.Map,
-			Key:   gopType(v.Key),
-			Value: gopType(v.Value),
+			Key:   p.gopType(v.Key),
+			Value: p.gopType(v.Value),
 		}
 	case *ast.StructType:
 		return &gopast.StructType{
 			Struct: v.Struct,
-			Fields: gopFieldList(v.Fields),
+			Fields: p.gopFieldList(v.Fields),
 		}
 	case *ast.FuncType:
-		return gopFuncType(v)
+		return p.gopFuncType(v)
 	case *ast.InterfaceType:
 		return &gopast.InterfaceType{
 			Interface: v.Interface,
-			Methods:   gopFieldList(v.Methods),
+			Methods:   p.gopFieldList(v.Methods),
 		}
 	case *ast.ArrayType:
 		return &gopast.ArrayType{
 			Lbrack: v.Lbrack,
-			Len:    gopExpr(v.Len),
-			Elt:    gopType(v.Elt),
+			Len:    p.gopExpr(v.Len),
+			Elt:    p.gopType(v.Elt),
 		}
 	case *ast.ChanType:
 		return &gopast.ChanType{
 			Begin: v.Begin,
 			Arrow: v.Arrow,
 			Dir:   gopast.ChanDir(v.Dir),
-			Value: gopType(v.Value),
+			Value: p.gopType(v.Value),
 		}
 	case *ast.BasicLit:
-		return gopBasicLit(v)
+		return p.gopBasicLit(v)
 	case *ast.BinaryExpr:
 		return &gopast.BinaryExpr{
-			X:     gopExpr(v.X),
+			X:     p.gopExpr(v.X),
 			OpPos: v.OpPos,
 			Op:    goptoken.Token(v.Op),
-			Y:     gopExpr(v.Y),
+			Y:     p.gopExpr(v.Y),
 		}
 	case *ast.UnaryExpr:
 		return &gopast.UnaryExpr{
 			OpPos: v.OpPos,
 			Op:    goptoken.Token(v.Op),
-			X:     gopExpr(v.X),
+			X:     p.gopExpr(v.X),
 		}
 	case *ast.CallExpr:
 		return &gopast.CallExpr{
-			Fun:      gopExpr(v.Fun),
+			Fun:      p.gopExpr(v.Fun),
 			Lparen:   v.Lparen,
-			Args:     gopExprs(v.Args),
+			Args:     p.gopExprs(v.Args),
 			Ellipsis: v.Ellipsis,
 			Rparen:   v.Rparen,
 		}
 	case *ast.IndexExpr:
 		return &gopast.IndexExpr{
-			X:      gopExpr(v.X),
+			X:      p.gopExpr(v.X),
 			Lbrack: v.Lbrack,
-			Index:  gopExpr(v.Index),
+			Index:  p.gopExpr(v.Index),
 			Rbrack: v.Rbrack,
 		}
 	case *ast.ParenExpr:
 		return &gopast.ParenExpr{
 			Lparen: v.Lparen,
-			X:      gopExpr(v.X),
+			X:      p.gopExpr(v.X),
 			Rparen: v.Rparen,
 		}
 	case *ast.CompositeLit:
 		return &gopast.CompositeLit{
-			Type:   gopType(v.Type),
+			Type:   p.gopType(v.Type),
 			Lbrace: v.Lbrace,
-			Elts:   gopExprs(v.Elts),
+			Elts:   p.gopExprs(v.Elts),
 			Rbrace: v.Rbrace,
 		}
 	case *ast.FuncLit:
 		return &gopast.FuncLit{
-			Type: gopFuncType(v.Type),
-			Body: &gopast.BlockStmt{}, // skip closure body
+			Type: p.gopFuncType(v.Type),
+			Body: p.gopFuncBody(v.Body),
 		}
 	case *ast.TypeAssertExpr:
 		return &gopast.TypeAssertExpr{
-			X:      gopExpr(v.X),
+			X:      p.gopExpr(v.X),
 			Lparen: v.Lparen,
-			Type:   gopType(v.Type),
+			Type:   p.gopType(v.Type),
 			Rparen: v.Rparen,
 		}
 	case *ast.KeyValueExpr:
 		return &gopast.KeyValueExpr{
-			Key:   gopExpr(v.Key),
+			Key:   p.gopExpr(v.Key),
 			Colon: v.Colon,
-			Value: gopExpr(v.Value),
+			Value: p.gopExpr(v.Value),
 		}
 	case *ast.Ellipsis:
 		return &gopast.Ellipsis{
 			Ellipsis: v.Ellipsis,
-			Elt:      gopExpr(v.Elt),
+			Elt:      p.gopExpr(v.Elt),
 		}
 	}
 	log.Panicln("gopExpr: unknown expr -", reflect.TypeOf(val))
 	return nil
 }
 
-func gopExprs(vals []ast.Expr) []gopast.Expr {
+func (p *converter) gopExprs(vals []ast.Expr) []gopast.Expr {
 	n := len(vals)
 	if n == 0 {
 		return nil
 	}
 	ret := make([]gopast.Expr, n)
 	for i, v := range vals {
-		ret[i] = gopExpr(v)
+		ret[i] = p.gopExpr(v)
 	}
 	return ret
 }
 
 // ----------------------------------------------------------------------------
 
-func gopFuncType(v *ast.FuncType) *gopast.FuncType {
+func (p *converter) gopStmt(val ast.Stmt) gopast.Stmt {
+	if val == nil {
+		return nil
+	}
+	switch v := val.(type) {
+	case *ast.DeclStmt:
+		if decl, ok := v.Decl.(*ast.GenDecl); ok {
+			return &gopast.DeclStmt{Decl: p.gopGenDecl(decl)}
+		}
+	case *ast.EmptyStmt:
+		return &gopast.EmptyStmt{Semicolon: v.Semicolon, Implicit: v.Implicit}
+	case *ast.LabeledStmt:
+		return &gopast.LabeledStmt{
+			Label: p.gopIdent(v.Label),
+			Colon: v.Colon,
+			Stmt:  p.gopStmt(v.Stmt),
+		}
+	case *ast.ExprStmt:
+		return &gopast.ExprStmt{X: p.gopExpr(v.X)}
+	case *ast.SendStmt:
+		return &gopast.SendStmt{
+			Chan:  p.gopExpr(v.Chan),
+			Arrow: v.Arrow,
+			Value: p.gopExpr(v.Value),
+		}
+	case *ast.IncDecStmt:
+		return &gopast.IncDecStmt{
+			X:      p.gopExpr(v.X),
+			TokPos: v.TokPos,
+			Tok:    goptoken.Token(v.Tok),
+		}
+	case *ast.AssignStmt:
+		return &gopast.AssignStmt{
+			Lhs:    p.gopExprs(v.Lhs),
+			TokPos: v.TokPos,
+			Tok:    goptoken.Token(v.Tok),
+			Rhs:    p.gopExprs(v.Rhs),
+		}
+	case *ast.GoStmt:
+		return &gopast.GoStmt{Go: v.Go, Call: p.gopExpr(v.Call).(*gopast.CallExpr)}
+	case *ast.DeferStmt:
+		return &gopast.DeferStmt{Defer: v.Defer, Call: p.gopExpr(v.Call).(*gopast.CallExpr)}
+	case *ast.ReturnStmt:
+		return &gopast.ReturnStmt{
+			Return:  v.Return,
+			Results: p.gopExprs(v.Results),
+		}
+	case *ast.BranchStmt:
+		return &gopast.BranchStmt{
+			TokPos: v.TokPos,
+			Tok:    goptoken.Token(v.Tok),
+			Label:  p.gopIdent(v.Label),
+		}
+	case *ast.BlockStmt:
+		return p.gopBlockStmt(v)
+	case *ast.IfStmt:
+		return &gopast.IfStmt{
+			If:   v.If,
+			Init: p.gopStmt(v.Init),
+			Cond: p.gopExpr(v.Cond),
+			Body: p.gopBlockStmt(v.Body),
+			Else: p.gopStmt(v.Else),
+		}
+	case *ast.CaseClause:
+		return &gopast.CaseClause{
+			Case:  v.Case,
+			List:  p.gopExprs(v.List),
+			Colon: v.Colon,
+			Body:  p.gopStmts(v.Body),
+		}
+	case *ast.SwitchStmt:
+		return &gopast.SwitchStmt{
+			Switch: v.Switch,
+			Init:   p.gopStmt(v.Init),
+			Tag:    p.gopExpr(v.Tag),
+			Body:   p.gopBlockStmt(v.Body),
+		}
+	case *ast.TypeSwitchStmt:
+		return &gopast.TypeSwitchStmt{
+			Switch: v.Switch,
+			Init:   p.gopStmt(v.Init),
+			Assign: p.gopStmt(v.Assign),
+			Body:   p.gopBlockStmt(v.Body),
+		}
+	case *ast.CommClause:
+		return &gopast.CommClause{
+			Case:  v.Case,
+			Comm:  p.gopStmt(v.Comm),
+			Colon: v.Colon,
+			Body:  p.gopStmts(v.Body),
+		}
+	case *ast.SelectStmt:
+		return &gopast.SelectStmt{
+			Select: v.Select,
+			Body:   p.gopBlockStmt(v.Body),
+		}
+	case *ast.ForStmt:
+		return &gopast.ForStmt{
+			For:  v.For,
+			Init: p.gopStmt(v.Init),
+			Cond: p.gopExpr(v.Cond),
+			Post: p.gopStmt(v.Post),
+			Body: p.gopBlockStmt(v.Body),
+		}
+	case *ast.RangeStmt:
+		return &gopast.RangeStmt{
+			For:    v.For,
+			Key:    p.gopExpr(v.Key),
+			Value:  p.gopExpr(v.Value),
+			TokPos: v.TokPos,
+			Tok:    goptoken.Token(v.Tok),
+			X:      p.gopExpr(v.X),
+			Body:   p.gopBlockStmt(v.Body),
+		}
+	}
+	log.Panicln("gopStmt: unknown stmt -", reflect.TypeOf(val))
+	return nil
+}
+
+func (p *converter) gopStmts(vals []ast.Stmt) []gopast.Stmt {
+	n := len(vals)
+	if n == 0 {
+		return nil
+	}
+	ret := make([]gopast.Stmt, n)
+	for i, v := range vals {
+		ret[i] = p.gopStmt(v)
+	}
+	return ret
+}
+
+func (p *converter) gopBlockStmt(v *ast.BlockStmt) *gopast.BlockStmt {
+	if v == nil {
+		return nil
+	}
+	return &gopast.BlockStmt{
+		Lbrace: v.Lbrace,
+		List:   p.gopStmts(v.List),
+		Rbrace: v.Rbrace,
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+func (p *converter) gopFuncType(v *ast.FuncType) *gopast.FuncType {
 	return &gopast.FuncType{
 		Func:    v.Func,
-		Params:  gopFieldList(v.Params),
-		Results: gopFieldList(v.Results),
+		Params:  p.gopFieldList(v.Params),
+		Results: p.gopFieldList(v.Results),
 	}
 }
 
-func gopType(v ast.Expr) gopast.Expr {
-	return gopExpr(v)
+func (p *converter) gopType(v ast.Expr) gopast.Expr {
+	return p.gopExpr(v)
 }
 
-func gopBasicLit(v *ast.BasicLit) *gopast.BasicLit {
+func (p *converter) gopBasicLit(v *ast.BasicLit) *gopast.BasicLit {
 	if v == nil {
 		return nil
 	}
@@ -194,7 +338,7 @@ func gopBasicLit(v *ast.BasicLit) *gopast.BasicLit {
 	}
 }
 
-func gopIdent(v *ast.Ident) *gopast.Ident {
+func (p *converter) gopIdent(v *ast.Ident) *gopast.Ident {
 	if v == nil {
 		return nil
 	}
@@ -204,80 +348,87 @@ func gopIdent(v *ast.Ident) *gopast.Ident {
 	}
 }
 
-func gopIdents(names []*ast.Ident) []*gopast.Ident {
+func (p *converter) gopIdents(names []*ast.Ident) []*gopast.Ident {
 	ret := make([]*gopast.Ident, len(names))
 	for i, v := range names {
-		ret[i] = gopIdent(v)
+		ret[i] = p.gopIdent(v)
 	}
 	return ret
 }
 
 // ----------------------------------------------------------------------------
 
-func gopField(v *ast.Field) *gopast.Field {
+func (p *converter) gopField(v *ast.Field) *gopast.Field {
 	return &gopast.Field{
-		Names: gopIdents(v.Names),
-		Type:  gopType(v.Type),
-		Tag:   gopBasicLit(v.Tag),
+		Names: p.gopIdents(v.Names),
+		Type:  p.gopType(v.Type),
+		Tag:   p.gopBasicLit(v.Tag),
 	}
 }
 
-func gopFieldList(v *ast.FieldList) *gopast.FieldList {
+func (p *converter) gopFieldList(v *ast.FieldList) *gopast.FieldList {
 	if v == nil {
 		return nil
 	}
 	list := make([]*gopast.Field, len(v.List))
 	for i, item := range v.List {
-		list[i] = gopField(item)
+		list[i] = p.gopField(item)
 	}
 	return &gopast.FieldList{Opening: v.Opening, List: list, Closing: v.Closing}
 }
 
-func gopFuncDecl(v *ast.FuncDecl) *gopast.FuncDecl {
+func (p *converter) gopFuncDecl(v *ast.FuncDecl) *gopast.FuncDecl {
 	return &gopast.FuncDecl{
-		Recv: gopFieldList(v.Recv),
-		Name: gopIdent(v.Name),
-		Type: gopFuncType(v.Type),
-		Body: &gopast.BlockStmt{}, // ignore function body
+		Recv: p.gopFieldList(v.Recv),
+		Name: p.gopIdent(v.Name),
+		Type: p.gopFuncType(v.Type),
+		Body: p.gopFuncBody(v.Body),
 	}
 }
 
+func (p *converter) gopFuncBody(v *ast.BlockStmt) *gopast.BlockStmt {
+	if (p.mode & KeepFuncBody) == 0 {
+		return &gopast.BlockStmt{} // ignore function body
+	}
+	return p.gopBlockStmt(v)
+}
+
 // ----------------------------------------------------------------------------
 
-func gopImportSpec(spec *ast.ImportSpec) *gopast.ImportSpec {
+func (p *converter) gopImportSpec(spec *ast.ImportSpec) *gopast.ImportSpec {
 	return &gopast.ImportSpec{
-		Name:   gopIdent(spec.Name),
-		Path:   gopBasicLit(spec.Path),
+		Name:   p.gopIdent(spec.Name),
+		Path:   p.gopBasicLit(spec.Path),
 		EndPos: spec.EndPos,
 	}
 }
 
-func gopTypeSpec(spec *ast.TypeSpec) *gopast.TypeSpec {
+func (p *converter) gopTypeSpec(spec *ast.TypeSpec) *gopast.TypeSpec {
 	return &gopast.TypeSpec{
-		Name:   gopIdent(spec.Name),
+		Name:   p.gopIdent(spec.Name),
 		Assign: spec.Assign,
-		Type:   gopType(spec.Type),
+		Type:   p.gopType(spec.Type),
 	}
 }
 
-func gopValueSpec(spec *ast.ValueSpec) *gopast.ValueSpec {
+func (p *converter) gopValueSpec(spec *ast.ValueSpec) *gopast.ValueSpec {
 	return &gopast.ValueSpec{
-		Names:  gopIdents(spec.Names),
-		Type:   gopType(spec.Type),
-		Values: gopExprs(spec.Values),
+		Names:  p.gopIdents(spec.Names),
+		Type:   p.gopType(spec.Type),
+		Values: p.gopExprs(spec.Values),
 	}
 }
 
-func gopGenDecl(v *ast.GenDecl) *gopast.GenDecl {
+func (p *converter) gopGenDecl(v *ast.GenDecl) *gopast.GenDecl {
 	specs := make([]gopast.Spec, len(v.Specs))
 	for i, spec := range v.Specs {
 		switch v.Tok {
 		case token.IMPORT:
-			specs[i] = gopImportSpec(spec.(*ast.ImportSpec))
+			specs[i] = p.gopImportSpec(spec.(*ast.ImportSpec))
 		case token.TYPE:
-			specs[i] = gopTypeSpec(spec.(*ast.TypeSpec))
+			specs[i] = p.gopTypeSpec(spec.(*ast.TypeSpec))
 		case token.VAR, token.CONST:
-			specs[i] = gopValueSpec(spec.(*ast.ValueSpec))
+			specs[i] = p.gopValueSpec(spec.(*ast.ValueSpec))
 		default:
 			log.Panicln("gopGenDecl: unknown spec -", v.Tok)
 		}
@@ -293,21 +444,21 @@ func gopGenDecl(v *ast.GenDecl) *gopast.GenDecl {
 
 // ----------------------------------------------------------------------------
 
-func gopDecl(decl ast.Decl) gopast.Decl {
+func (p *converter) gopDecl(decl ast.Decl) gopast.Decl {
 	switch v := decl.(type) {
 	case *ast.GenDecl:
-		return gopGenDecl(v)
+		return p.gopGenDecl(v)
 	case *ast.FuncDecl:
-		return gopFuncDecl(v)
+		return p.gopFuncDecl(v)
 	}
 	log.Panicln("gopDecl: unkown decl -", reflect.TypeOf(decl))
 	return nil
 }
 
-func gopDecls(decls []ast.Decl) []gopast.Decl {
+func (p *converter) gopDecls(decls []ast.Decl) []gopast.Decl {
 	ret := make([]gopast.Decl, len(decls))
 	for i, decl := range decls {
-		ret[i] = gopDecl(decl)
+		ret[i] = p.gopDecl(decl)
 	}
 	return ret
 }
@@ -319,17 +470,21 @@ const (
 	KeepCgo
 )
 
+type converter struct {
+	mode int
+}
+
+// ASTFile converts a Go file into a Go+ file. Function bodies are dropped
+// unless mode has KeepFuncBody.
 func ASTFile(f *ast.File, mode int) *gopast.File {
-	if (mode & KeepFuncBody) != 0 {
-		log.Panicln("ASTFile: doesn't support keeping func body now")
-	}
 	if (mode & KeepCgo) != 0 {
 		log.Panicln("ASTFile: doesn't support keeping cgo now")
 	}
+	p := &converter{mode: mode}
 	return &gopast.File{
 		Package: f.Package,
-		Name:    gopIdent(f.Name),
-		Decls:   gopDecls(f.Decls),
+		Name:    p.gopIdent(f.Name),
+		Decls:   p.gopDecls(f.Decls),
 	}
 }
 
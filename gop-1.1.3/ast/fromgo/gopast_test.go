@@ -27,12 +27,16 @@ import (
 )
 
 func testAST(t *testing.T, from, to string) {
+	testASTEx(t, 0, from, to)
+}
+
+func testASTEx(t *testing.T, mode int, from, to string) {
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(fset, "foo.go", from, 0)
 	if err != nil {
 		t.Fatal("parser.ParseFile:", err)
 	}
-	gopf := ASTFile(f, 0)
+	gopf := ASTFile(f, mode)
 	var b bytes.Buffer
 	err = format.Node(&b, fset, gopf)
 	if err != nil {
@@ -65,17 +69,14 @@ func TestErrASTFile(t *testing.T) {
 	testPanic(t, "ASTFile: doesn't support keeping cgo now\n", func() {
 		ASTFile(nil, KeepCgo)
 	})
-	testPanic(t, "ASTFile: doesn't support keeping func body now\n", func() {
-		ASTFile(nil, KeepFuncBody)
-	})
 }
 
 func TestErrDecl(t *testing.T) {
 	testPanic(t, "gopDecl: unkown decl - <nil>\n", func() {
-		gopDecl(nil)
+		new(converter).gopDecl(nil)
 	})
 	testPanic(t, "gopGenDecl: unknown spec - ILLEGAL\n", func() {
-		gopGenDecl(&ast.GenDecl{
+		new(converter).gopGenDecl(&ast.GenDecl{
 			Specs: []ast.Spec{nil},
 		})
 	})
@@ -83,7 +84,7 @@ func TestErrDecl(t *testing.T) {
 
 func TestErrExpr(t *testing.T) {
 	testPanic(t, "gopExpr: unknown expr - *ast.BadExpr\n", func() {
-		gopExpr(&ast.BadExpr{})
+		new(converter).gopExpr(&ast.BadExpr{})
 	})
 }
 
@@ -125,3 +126,83 @@ type foo int
 func (a foo) Str() (string) {}
 `)
 }
+
+func TestFuncBody(t *testing.T) {
+	testASTEx(t, KeepFuncBody, `package main
+
+import "fmt"
+
+func foo(ch chan int, v ...interface{}) (n int) {
+	var x = 1
+	f := func() int {
+		return x
+	}
+L:
+	for i := 0; i < 10; i++ {
+		switch {
+		case i > 5:
+			break L
+		default:
+			x += i
+		}
+	}
+	for k, v := range v {
+		if k == 0 {
+			continue
+		} else if _, ok := v.(int); ok {
+			n++
+		}
+	}
+	switch t := v[0].(type) {
+	case int:
+		fmt.Println(t)
+	}
+	select {
+	case ch <- 1:
+	case n = <-ch:
+	default:
+	}
+	go f()
+	defer fmt.Println(n)
+	return f()
+}
+`, `package main
+
+import "fmt"
+
+func foo(ch chan int, v ...interface{}) (n int) {
+	var x = 1
+	f := func() (int) {
+		return x
+	}
+L:
+	for i := 0; i < 10; i++ {
+		switch {
+		case i > 5:
+			break L
+		default:
+			x += i
+		}
+	}
+	for k, v := range v {
+		if k == 0 {
+			continue
+		} else if _, ok := v.(int); ok {
+			n++
+		}
+	}
+	switch t := v[0].(type) {
+	case int:
+		fmt.Println(t)
+	}
+	select {
+	case ch <- 1:
+	case n = <-ch:
+	default:
+	}
+	go f()
+	defer fmt.Println(n)
+	return f()
+}
+`)
+}
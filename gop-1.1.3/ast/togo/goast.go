@@ -28,162 +28,322 @@ import (
 
 // ----------------------------------------------------------------------------
 
-func goExpr(val gopast.Expr) ast.Expr {
+func (p *converter) goExpr(val gopast.Expr) ast.Expr {
 	if val == nil {
 		return nil
 	}
 	switch v := val.(type) {
 	case *gopast.Ident:
-		return goIdent(v)
+		return p.goIdent(v)
 	case *gopast.SelectorExpr:
 		return &ast.SelectorExpr{
-			X:   goExpr(v.X),
-			Sel: goIdent(v.Sel),
+			X:   p.goExpr(v.X),
+			Sel: p.goIdent(v.Sel),
 		}
 	case *gopast.SliceExpr:
 		return &ast.SliceExpr{
-			X:      goExpr(v.X),
+			X:      p.goExpr(v.X),
 			Lbrack: v.Lbrack,
-			Low:    goExpr(v.Low),
-			High:   goExpr(v.High),
-			Max:    goExpr(v.Max),
+			Low:    p.goExpr(v.Low),
+			High:   p.goExpr(v.High),
+			Max:    p.goExpr(v.Max),
 			Slice3: v.Slice3,
 			Rbrack: v.Rbrack,
 		}
 	case *gopast.StarExpr:
 		return &ast.StarExpr{
 			Star: v.Star,
-			X:    goExpr(v.X),
+			X:    p.goExpr(v.X),
 		}
 	case *gopast.MapType:
 		return &ast.MapType{
 			Map:   v.Map,
-			Key:   goType(v.Key),
-			Value: goType(v.Value),
+			Key:   p.goType(v.Key),
+			Value: p.goType(v.Value),
 		}
 	case *gopast.StructType:
 		return &ast.StructType{
 			Struct: v.Struct,
-			Fields: goFieldList(v.Fields),
+			Fields: p.goFieldList(v.Fields),
 		}
 	case *gopast.FuncType:
-		return goFuncType(v)
+		return p.goFuncType(v)
 	case *gopast.InterfaceType:
 		return &ast.InterfaceType{
 			Interface: v.Interface,
-			Methods:   goFieldList(v.Methods),
+			Methods:   p.goFieldList(v.Methods),
 		}
 	case *gopast.ArrayType:
 		return &ast.ArrayType{
 			Lbrack: v.Lbrack,
-			Len:    goExpr(v.Len),
-			Elt:    goType(v.Elt),
+			Len:    p.goExpr(v.Len),
+			Elt:    p.goType(v.Elt),
 		}
 	case *gopast.ChanType:
 		return &ast.ChanType{
 			Begin: v.Begin,
 			Arrow: v.Arrow,
 			Dir:   ast.ChanDir(v.Dir),
-			Value: goType(v.Value),
+			Value: p.goType(v.Value),
 		}
 	case *gopast.BasicLit:
-		return goBasicLit(v)
+		if p.unsupported == nil || v.Kind != goptoken.CSTRING && v.Kind != goptoken.RAT {
+			return p.goBasicLit(v)
+		}
 	case *gopast.BinaryExpr:
 		return &ast.BinaryExpr{
-			X:     goExpr(v.X),
+			X:     p.goExpr(v.X),
 			OpPos: v.OpPos,
 			Op:    token.Token(v.Op),
-			Y:     goExpr(v.Y),
+			Y:     p.goExpr(v.Y),
 		}
 	case *gopast.UnaryExpr:
 		return &ast.UnaryExpr{
 			OpPos: v.OpPos,
 			Op:    token.Token(v.Op),
-			X:     goExpr(v.X),
+			X:     p.goExpr(v.X),
 		}
 	case *gopast.CallExpr:
 		return &ast.CallExpr{
-			Fun:      goExpr(v.Fun),
+			Fun:      p.goExpr(v.Fun),
 			Lparen:   v.Lparen,
-			Args:     goExprs(v.Args),
+			Args:     p.goExprs(v.Args),
 			Ellipsis: v.Ellipsis,
 			Rparen:   v.Rparen,
 		}
 	case *gopast.IndexExpr:
 		return &ast.IndexExpr{
-			X:      goExpr(v.X),
+			X:      p.goExpr(v.X),
 			Lbrack: v.Lbrack,
-			Index:  goExpr(v.Index),
+			Index:  p.goExpr(v.Index),
 			Rbrack: v.Rbrack,
 		}
 	case *gopast.ParenExpr:
 		return &ast.ParenExpr{
 			Lparen: v.Lparen,
-			X:      goExpr(v.X),
+			X:      p.goExpr(v.X),
 			Rparen: v.Rparen,
 		}
 	case *gopast.CompositeLit:
 		return &ast.CompositeLit{
-			Type:   goType(v.Type),
+			Type:   p.goType(v.Type),
 			Lbrace: v.Lbrace,
-			Elts:   goExprs(v.Elts),
+			Elts:   p.goExprs(v.Elts),
 			Rbrace: v.Rbrace,
 		}
 	case *gopast.FuncLit:
 		return &ast.FuncLit{
-			Type: goFuncType(v.Type),
-			Body: &ast.BlockStmt{}, // skip closure body
+			Type: p.goFuncType(v.Type),
+			Body: p.goFuncBody(v.Body),
 		}
 	case *gopast.TypeAssertExpr:
 		return &ast.TypeAssertExpr{
-			X:      goExpr(v.X),
+			X:      p.goExpr(v.X),
 			Lparen: v.Lparen,
-			Type:   goType(v.Type),
+			Type:   p.goType(v.Type),
 			Rparen: v.Rparen,
 		}
 	case *gopast.KeyValueExpr:
 		return &ast.KeyValueExpr{
-			Key:   goExpr(v.Key),
+			Key:   p.goExpr(v.Key),
 			Colon: v.Colon,
-			Value: goExpr(v.Value),
+			Value: p.goExpr(v.Value),
 		}
 	case *gopast.Ellipsis:
 		return &ast.Ellipsis{
 			Ellipsis: v.Ellipsis,
-			Elt:      goExpr(v.Elt),
+			Elt:      p.goExpr(v.Elt),
+		}
+	case *gopast.BadExpr:
+		if p.unsupported != nil { // reported by the parser
+			return &ast.BadExpr{From: v.From, To: v.To}
 		}
 	}
-	log.Panicln("goExpr: unknown expr -", reflect.TypeOf(val))
-	return nil
+	p.unknown(val, "goExpr: unknown expr -", reflect.TypeOf(val))
+	return &ast.BadExpr{From: val.Pos(), To: val.End()}
 }
 
-func goExprs(vals []gopast.Expr) []ast.Expr {
+func (p *converter) goExprs(vals []gopast.Expr) []ast.Expr {
 	n := len(vals)
 	if n == 0 {
 		return nil
 	}
 	ret := make([]ast.Expr, n)
 	for i, v := range vals {
-		ret[i] = goExpr(v)
+		ret[i] = p.goExpr(v)
 	}
 	return ret
 }
 
 // ----------------------------------------------------------------------------
 
-func goFuncType(v *gopast.FuncType) *ast.FuncType {
+func (p *converter) goStmt(val gopast.Stmt) ast.Stmt {
+	if val == nil {
+		return nil
+	}
+	switch v := val.(type) {
+	case *gopast.DeclStmt:
+		if decl, ok := v.Decl.(*gopast.GenDecl); ok {
+			return &ast.DeclStmt{Decl: p.goGenDecl(decl)}
+		}
+	case *gopast.EmptyStmt:
+		return &ast.EmptyStmt{Semicolon: v.Semicolon, Implicit: v.Implicit}
+	case *gopast.LabeledStmt:
+		return &ast.LabeledStmt{
+			Label: p.goIdent(v.Label),
+			Colon: v.Colon,
+			Stmt:  p.goStmt(v.Stmt),
+		}
+	case *gopast.ExprStmt:
+		return &ast.ExprStmt{X: p.goExpr(v.X)}
+	case *gopast.SendStmt:
+		return &ast.SendStmt{
+			Chan:  p.goExpr(v.Chan),
+			Arrow: v.Arrow,
+			Value: p.goExpr(v.Value),
+		}
+	case *gopast.IncDecStmt:
+		return &ast.IncDecStmt{
+			X:      p.goExpr(v.X),
+			TokPos: v.TokPos,
+			Tok:    token.Token(v.Tok),
+		}
+	case *gopast.AssignStmt:
+		return &ast.AssignStmt{
+			Lhs:    p.goExprs(v.Lhs),
+			TokPos: v.TokPos,
+			Tok:    token.Token(v.Tok),
+			Rhs:    p.goExprs(v.Rhs),
+		}
+	case *gopast.GoStmt:
+		if call, ok := p.goExpr(v.Call).(*ast.CallExpr); ok {
+			return &ast.GoStmt{Go: v.Go, Call: call}
+		}
+		return &ast.BadStmt{From: v.Pos(), To: v.End()}
+	case *gopast.DeferStmt:
+		if call, ok := p.goExpr(v.Call).(*ast.CallExpr); ok {
+			return &ast.DeferStmt{Defer: v.Defer, Call: call}
+		}
+		return &ast.BadStmt{From: v.Pos(), To: v.End()}
+	case *gopast.ReturnStmt:
+		return &ast.ReturnStmt{
+			Return:  v.Return,
+			Results: p.goExprs(v.Results),
+		}
+	case *gopast.BranchStmt:
+		return &ast.BranchStmt{
+			TokPos: v.TokPos,
+			Tok:    token.Token(v.Tok),
+			Label:  p.goIdent(v.Label),
+		}
+	case *gopast.BlockStmt:
+		return p.goBlockStmt(v)
+	case *gopast.IfStmt:
+		return &ast.IfStmt{
+			If:   v.If,
+			Init: p.goStmt(v.Init),
+			Cond: p.goExpr(v.Cond),
+			Body: p.goBlockStmt(v.Body),
+			Else: p.goStmt(v.Else),
+		}
+	case *gopast.CaseClause:
+		return &ast.CaseClause{
+			Case:  v.Case,
+			List:  p.goExprs(v.List),
+			Colon: v.Colon,
+			Body:  p.goStmts(v.Body),
+		}
+	case *gopast.SwitchStmt:
+		return &ast.SwitchStmt{
+			Switch: v.Switch,
+			Init:   p.goStmt(v.Init),
+			Tag:    p.goExpr(v.Tag),
+			Body:   p.goBlockStmt(v.Body),
+		}
+	case *gopast.TypeSwitchStmt:
+		return &ast.TypeSwitchStmt{
+			Switch: v.Switch,
+			Init:   p.goStmt(v.Init),
+			Assign: p.goStmt(v.Assign),
+			Body:   p.goBlockStmt(v.Body),
+		}
+	case *gopast.CommClause:
+		return &ast.CommClause{
+			Case:  v.Case,
+			Comm:  p.goStmt(v.Comm),
+			Colon: v.Colon,
+			Body:  p.goStmts(v.Body),
+		}
+	case *gopast.SelectStmt:
+		return &ast.SelectStmt{
+			Select: v.Select,
+			Body:   p.goBlockStmt(v.Body),
+		}
+	case *gopast.ForStmt:
+		return &ast.ForStmt{
+			For:  v.For,
+			Init: p.goStmt(v.Init),
+			Cond: p.goExpr(v.Cond),
+			Post: p.goStmt(v.Post),
+			Body: p.goBlockStmt(v.Body),
+		}
+	case *gopast.RangeStmt:
+		return &ast.RangeStmt{
+			For:    v.For,
+			Key:    p.goExpr(v.Key),
+			Value:  p.goExpr(v.Value),
+			TokPos: v.TokPos,
+			Tok:    token.Token(v.Tok),
+			X:      p.goExpr(v.X),
+			Body:   p.goBlockStmt(v.Body),
+		}
+	case *gopast.BadStmt:
+		if p.unsupported != nil { // reported by the parser
+			return &ast.BadStmt{From: v.From, To: v.To}
+		}
+	}
+	p.unknown(val, "goStmt: unknown stmt -", reflect.TypeOf(val))
+	return &ast.BadStmt{From: val.Pos(), To: val.End()}
+}
+
+func (p *converter) goStmts(vals []gopast.Stmt) []ast.Stmt {
+	n := len(vals)
+	if n == 0 {
+		return nil
+	}
+	ret := make([]ast.Stmt, n)
+	for i, v := range vals {
+		ret[i] = p.goStmt(v)
+	}
+	return ret
+}
+
+func (p *converter) goBlockStmt(v *gopast.BlockStmt) *ast.BlockStmt {
+	if v == nil {
+		return nil
+	}
+	return &ast.BlockStmt{
+		Lbrace: v.Lbrace,
+		List:   p.goStmts(v.List),
+		Rbrace: v.Rbrace,
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+func (p *converter) goFuncType(v *gopast.FuncType) *ast.FuncType {
 	return &ast.FuncType{
 		Func:    v.Func,
-		Params:  goFieldList(v.Params),
-		Results: goFieldList(v.Results),
+		Params:  p.goFieldList(v.Params),
+		Results: p.goFieldList(v.Results),
 	}
 }
 
-func goType(v gopast.Expr) ast.Expr {
-	return goExpr(v)
+func (p *converter) goType(v gopast.Expr) ast.Expr {
+	return p.goExpr(v)
 }
 
-func goBasicLit(v *gopast.BasicLit) *ast.BasicLit {
+func (p *converter) goBasicLit(v *gopast.BasicLit) *ast.BasicLit {
 	if v == nil {
 		return nil
 	}
@@ -194,7 +354,7 @@ func goBasicLit(v *gopast.BasicLit) *ast.BasicLit {
 	}
 }
 
-func goIdent(v *gopast.Ident) *ast.Ident {
+func (p *converter) goIdent(v *gopast.Ident) *ast.Ident {
 	if v == nil {
 		return nil
 	}
@@ -204,82 +364,90 @@ func goIdent(v *gopast.Ident) *ast.Ident {
 	}
 }
 
-func goIdents(names []*gopast.Ident) []*ast.Ident {
+func (p *converter) goIdents(names []*gopast.Ident) []*ast.Ident {
 	ret := make([]*ast.Ident, len(names))
 	for i, v := range names {
-		ret[i] = goIdent(v)
+		ret[i] = p.goIdent(v)
 	}
 	return ret
 }
 
 // ----------------------------------------------------------------------------
 
-func goField(v *gopast.Field) *ast.Field {
+func (p *converter) goField(v *gopast.Field) *ast.Field {
 	return &ast.Field{
-		Names: goIdents(v.Names),
-		Type:  goType(v.Type),
-		Tag:   goBasicLit(v.Tag),
+		Names: p.goIdents(v.Names),
+		Type:  p.goType(v.Type),
+		Tag:   p.goBasicLit(v.Tag),
 	}
 }
 
-func goFieldList(v *gopast.FieldList) *ast.FieldList {
+func (p *converter) goFieldList(v *gopast.FieldList) *ast.FieldList {
 	if v == nil {
 		return nil
 	}
 	list := make([]*ast.Field, len(v.List))
 	for i, item := range v.List {
-		list[i] = goField(item)
+		list[i] = p.goField(item)
 	}
 	return &ast.FieldList{Opening: v.Opening, List: list, Closing: v.Closing}
 }
 
-func goFuncDecl(v *gopast.FuncDecl) *ast.FuncDecl {
+func (p *converter) goFuncDecl(v *gopast.FuncDecl) *ast.FuncDecl {
 	return &ast.FuncDecl{
-		Recv: goFieldList(v.Recv),
-		Name: goIdent(v.Name),
-		Type: goFuncType(v.Type),
-		Body: &ast.BlockStmt{}, // ignore function body
+		Recv: p.goFieldList(v.Recv),
+		Name: p.goIdent(v.Name),
+		Type: p.goFuncType(v.Type),
+		Body: p.goFuncBody(v.Body),
 	}
 }
 
+func (p *converter) goFuncBody(v *gopast.BlockStmt) *ast.BlockStmt {
+	if (p.mode & KeepFuncBody) == 0 {
+		return &ast.BlockStmt{} // ignore function body
+	}
+	return p.goBlockStmt(v)
+}
+
 // ----------------------------------------------------------------------------
 
-func goImportSpec(spec *gopast.ImportSpec) *ast.ImportSpec {
+func (p *converter) goImportSpec(spec *gopast.ImportSpec) *ast.ImportSpec {
 	return &ast.ImportSpec{
-		Name:   goIdent(spec.Name),
-		Path:   goBasicLit(spec.Path),
+		Name:   p.goIdent(spec.Name),
+		Path:   p.goBasicLit(spec.Path),
 		EndPos: spec.EndPos,
 	}
 }
 
-func goTypeSpec(spec *gopast.TypeSpec) *ast.TypeSpec {
+func (p *converter) goTypeSpec(spec *gopast.TypeSpec) *ast.TypeSpec {
 	return &ast.TypeSpec{
-		Name:   goIdent(spec.Name),
+		Name:   p.goIdent(spec.Name),
 		Assign: spec.Assign,
-		Type:   goType(spec.Type),
+		Type:   p.goType(spec.Type),
 	}
 }
 
-func goValueSpec(spec *gopast.ValueSpec) *ast.ValueSpec {
+func (p *converter) goValueSpec(spec *gopast.ValueSpec) *ast.ValueSpec {
 	return &ast.ValueSpec{
-		Names:  goIdents(spec.Names),
-		Type:   goType(spec.Type),
-		Values: goExprs(spec.Values),
+		Names:  p.goIdents(spec.Names),
+		Type:   p.goType(spec.Type),
+		Values: p.goExprs(spec.Values),
 	}
 }
 
-func goGenDecl(v *gopast.GenDecl) *ast.GenDecl {
+func (p *converter) goGenDecl(v *gopast.GenDecl) *ast.GenDecl {
 	specs := make([]ast.Spec, len(v.Specs))
 	for i, spec := range v.Specs {
 		switch v.Tok {
 		case goptoken.IMPORT:
-			specs[i] = goImportSpec(spec.(*gopast.ImportSpec))
+			specs[i] = p.goImportSpec(spec.(*gopast.ImportSpec))
 		case goptoken.TYPE:
-			specs[i] = goTypeSpec(spec.(*gopast.TypeSpec))
+			specs[i] = p.goTypeSpec(spec.(*gopast.TypeSpec))
 		case goptoken.VAR, goptoken.CONST:
-			specs[i] = goValueSpec(spec.(*gopast.ValueSpec))
+			specs[i] = p.goValueSpec(spec.(*gopast.ValueSpec))
 		default:
-			log.Panicln("goGenDecl: unknown spec -", v.Tok)
+			p.unknown(v, "goGenDecl: unknown spec -", v.Tok)
+			return &ast.GenDecl{TokPos: v.TokPos, Tok: token.Token(v.Tok)}
 		}
 	}
 	return &ast.GenDecl{
@@ -293,21 +461,27 @@ func goGenDecl(v *gopast.GenDecl) *ast.GenDecl {
 
 // ----------------------------------------------------------------------------
 
-func goDecl(decl gopast.Decl) ast.Decl {
+func (p *converter) goDecl(decl gopast.Decl) ast.Decl {
 	switch v := decl.(type) {
 	case *gopast.GenDecl:
-		return goGenDecl(v)
+		return p.goGenDecl(v)
 	case *gopast.FuncDecl:
-		return goFuncDecl(v)
+		if !v.Operator || p.unsupported == nil {
+			return p.goFuncDecl(v)
+		}
+	case *gopast.BadDecl:
+		if p.unsupported != nil { // reported by the parser
+			return &ast.BadDecl{From: v.From, To: v.To}
+		}
 	}
-	log.Panicln("goDecl: unkown decl -", reflect.TypeOf(decl))
-	return nil
+	p.unknown(decl, "goDecl: unkown decl -", reflect.TypeOf(decl))
+	return &ast.BadDecl{From: decl.Pos(), To: decl.End()}
 }
 
-func goDecls(decls []gopast.Decl) []ast.Decl {
+func (p *converter) goDecls(decls []gopast.Decl) []ast.Decl {
 	ret := make([]ast.Decl, len(decls))
 	for i, decl := range decls {
-		ret[i] = goDecl(decl)
+		ret[i] = p.goDecl(decl)
 	}
 	return ret
 }
@@ -318,14 +492,44 @@ const (
 	KeepFuncBody = 1 << iota
 )
 
-func ASTFile(f *gopast.File, mode int) *ast.File {
-	if (mode & KeepFuncBody) != 0 {
-		log.Panicln("ASTFile: doesn't support keeping func body now")
+type converter struct {
+	mode        int
+	unsupported func(node gopast.Node)
+}
+
+// unknown handles a node that has no Go counterpart: ASTFile panics with
+// msg, while ASTFileEx reports the node and the caller replaces it by a
+// Bad node.
+func (p *converter) unknown(node gopast.Node, msg ...interface{}) {
+	if p.unsupported == nil {
+		log.Panicln(msg...)
 	}
+	p.unsupported(node)
+}
+
+// ASTFile converts a Go+ file into a Go file. It panics if the file uses
+// Go+ syntax that has no Go counterpart. Function bodies are dropped
+// unless mode has KeepFuncBody.
+func ASTFile(f *gopast.File, mode int) *ast.File {
+	p := &converter{mode: mode}
+	return p.goFile(f)
+}
+
+// ASTFileEx is like ASTFile, but doesn't panic: syntax that has no Go
+// counterpart is replaced by a BadExpr, BadStmt or BadDecl node spanning
+// the same source range, and passed to unsupported. Besides the nodes
+// ASTFile panics on, these are rational and C string literals and operator
+// methods, which ASTFile converts verbatim.
+func ASTFileEx(f *gopast.File, mode int, unsupported func(node gopast.Node)) *ast.File {
+	p := &converter{mode: mode, unsupported: unsupported}
+	return p.goFile(f)
+}
+
+func (p *converter) goFile(f *gopast.File) *ast.File {
 	return &ast.File{
 		Package: f.Package,
-		Name:    goIdent(f.Name),
-		Decls:   goDecls(f.Decls),
+		Name:    p.goIdent(f.Name),
+		Decls:   p.goDecls(f.Decls),
 	}
 }
 
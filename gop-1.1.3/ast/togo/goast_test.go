@@ -18,8 +18,11 @@ package togo
 
 import (
 	"bytes"
+	"fmt"
+	"go/ast"
 	"go/format"
 	"go/token"
+	"reflect"
 	"testing"
 
 	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
@@ -27,12 +30,27 @@ import (
 )
 
 func testAST(t *testing.T, from, to string) {
+	testASTEx(t, 0, from, to, nil)
+}
+
+func testASTEx(t *testing.T, mode int, from, to string, unsupported []string) {
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(fset, "foo.go", from, 0)
 	if err != nil {
 		t.Fatal("parser.ParseFile:", err)
 	}
-	gopf := ASTFile(f, 0)
+	var gopf *ast.File
+	if unsupported == nil {
+		gopf = ASTFile(f, mode)
+	} else {
+		var nodes []string
+		gopf = ASTFileEx(f, mode, func(node gopast.Node) {
+			nodes = append(nodes, fmt.Sprintf("%v %T", fset.Position(node.Pos()), node))
+		})
+		if !reflect.DeepEqual(nodes, unsupported) {
+			t.Fatalf("\nUnsupported:\n%v\nExpected:\n%v\n", nodes, unsupported)
+		}
+	}
 	var b bytes.Buffer
 	err = format.Node(&b, fset, gopf)
 	if err != nil {
@@ -61,18 +79,18 @@ func testPanic(t *testing.T, panicMsg string, doPanic func()) {
 	})
 }
 
-func TestErrASTFile(t *testing.T) {
-	testPanic(t, "ASTFile: doesn't support keeping func body now\n", func() {
-		ASTFile(nil, KeepFuncBody)
+func TestErrStmt(t *testing.T) {
+	testPanic(t, "goStmt: unknown stmt - *ast.BadStmt\n", func() {
+		new(converter).goStmt(&gopast.BadStmt{})
 	})
 }
 
 func TestErrDecl(t *testing.T) {
 	testPanic(t, "goDecl: unkown decl - <nil>\n", func() {
-		goDecl(nil)
+		new(converter).goDecl(nil)
 	})
 	testPanic(t, "goGenDecl: unknown spec - ILLEGAL\n", func() {
-		goGenDecl(&gopast.GenDecl{
+		new(converter).goGenDecl(&gopast.GenDecl{
 			Specs: []gopast.Spec{nil},
 		})
 	})
@@ -80,7 +98,7 @@ func TestErrDecl(t *testing.T) {
 
 func TestErrExpr(t *testing.T) {
 	testPanic(t, "goExpr: unknown expr - *ast.BadExpr\n", func() {
-		goExpr(&gopast.BadExpr{})
+		new(converter).goExpr(&gopast.BadExpr{})
 	})
 }
 
@@ -113,3 +131,119 @@ var f = a.i.(func() (int))()
 func foo(v ...interface{}) {}
 `)
 }
+
+func TestFuncBody(t *testing.T) {
+	testASTEx(t, KeepFuncBody, `package main
+
+import "fmt"
+
+func foo(ch chan int, v ...interface{}) (n int) {
+	var x = 1
+	f := func() int {
+		return x
+	}
+L:
+	for i := 0; i < 10; i++ {
+		switch {
+		case i > 5:
+			break L
+		default:
+			x += i
+		}
+	}
+	for k, v := range v {
+		if k == 0 {
+			continue
+		} else if _, ok := v.(int); ok {
+			n++
+		}
+	}
+	switch t := v[0].(type) {
+	case int:
+		fmt.Println(t)
+	}
+	select {
+	case ch <- 1:
+	case n = <-ch:
+	default:
+	}
+	go f()
+	defer fmt.Println(n)
+	return f()
+}
+`, `package main
+
+import "fmt"
+
+func foo(ch chan int, v ...interface{}) (n int) {
+	var x = 1
+	f := func() (int) {
+		return x
+	}
+L:
+	for i := 0; i < 10; i++ {
+		switch {
+		case i > 5:
+			break L
+		default:
+			x += i
+		}
+	}
+	for k, v := range v {
+		if k == 0 {
+			continue
+		} else if _, ok := v.(int); ok {
+			n++
+		}
+	}
+	switch t := v[0].(type) {
+	case int:
+		fmt.Println(t)
+	}
+	select {
+	case ch <- 1:
+	case n = <-ch:
+	default:
+	}
+	go f()
+	defer fmt.Println(n)
+	return f()
+}
+`, nil)
+}
+
+func TestUnsupported(t *testing.T) {
+	testASTEx(t, KeepFuncBody, `package main
+
+func foo(v []int) {
+	a := [x*x for x <- v]
+	b := 1r
+	for x <- v, x > 1 {
+		println(x)
+	}
+	println(a, b)
+}
+
+func bar(f func(int) int) {
+	bar(x => x * 2)
+}
+`, `package main
+
+func foo(v []int) {
+	a := BadExpr
+	b := BadExpr
+	BadStmt
+
+	println(a, b)
+}
+
+func bar(f func(int) (int)) {
+	bar(BadExpr)
+}
+`, []string{
+		"foo.go:4:7 *ast.ComprehensionExpr",
+		"foo.go:5:7 *ast.BasicLit",
+		"foo.go:6:2 *ast.ForPhraseStmt",
+		"foo.go:13:6 *ast.LambdaExpr",
+	})
+}
@@ -356,6 +356,64 @@ func Walk(v Visitor, node Node) {
 			Walk(v, n.Body)
 		}
 
+	// Go+ expressions and statements
+	// (the order of the cases matches the order
+	// of the corresponding node types in ast_gop.go)
+	case *SliceLit:
+		walkExprList(v, n.Elts)
+
+	case *ErrWrapExpr:
+		Walk(v, n.X)
+		if n.Default != nil {
+			Walk(v, n.Default)
+		}
+
+	case *LambdaExpr:
+		walkIdentList(v, n.Lhs)
+		walkExprList(v, n.Rhs)
+
+	case *LambdaExpr2:
+		walkIdentList(v, n.Lhs)
+		Walk(v, n.Body)
+
+	case *ForPhrase:
+		if n.Key != nil {
+			Walk(v, n.Key)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+		Walk(v, n.X)
+		if n.Init != nil {
+			Walk(v, n.Init)
+		}
+		if n.Cond != nil {
+			Walk(v, n.Cond)
+		}
+
+	case *ComprehensionExpr:
+		if n.Elt != nil {
+			Walk(v, n.Elt)
+		}
+		for _, f := range n.Fors {
+			Walk(v, f)
+		}
+
+	case *ForPhraseStmt:
+		Walk(v, n.ForPhrase)
+		Walk(v, n.Body)
+
+	case *RangeExpr:
+		if n.First != nil {
+			Walk(v, n.First)
+		}
+		if n.Last != nil {
+			Walk(v, n.Last)
+		}
+		if n.Expr3 != nil {
+			Walk(v, n.Expr3)
+		}
+
 	// Files and packages
 	case *File:
 		if n.Doc != nil {
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast_test
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+)
+
+// -----------------------------------------------------------------------------
+
+func parseTestdata(t *testing.T) map[string]*ast.Package {
+	const dir = "../parser/_testdata"
+	fis, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal("ReadDir failed:", err)
+	}
+	ret := make(map[string]*ast.Package)
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		fset := token.NewFileSet()
+		pkgs, err := parser.ParseDir(fset, path.Join(dir, fi.Name()), nil, parser.ParseComments|parser.ParseGoAsGoPlus)
+		if err != nil {
+			t.Fatal("ParseDir failed:", fi.Name(), err)
+		}
+		for _, pkg := range pkgs {
+			ret[fi.Name()] = pkg
+		}
+	}
+	return ret
+}
+
+func TestWalkTestdata(t *testing.T) {
+	seen := make(map[string]bool)
+	for name, pkg := range parseTestdata(t) {
+		depth := 0
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			if n == nil {
+				depth--
+				return true
+			}
+			seen[fmt.Sprintf("%T", n)] = true
+			depth++
+			return true
+		})
+		if depth != 0 {
+			t.Errorf("%s: unbalanced Inspect, depth %d", name, depth)
+		}
+	}
+	for _, n := range []ast.Node{
+		(*ast.SliceLit)(nil),
+		(*ast.ErrWrapExpr)(nil),
+		(*ast.LambdaExpr)(nil),
+		(*ast.LambdaExpr2)(nil),
+		(*ast.ForPhrase)(nil),
+		(*ast.ComprehensionExpr)(nil),
+		(*ast.ForPhraseStmt)(nil),
+		(*ast.RangeExpr)(nil),
+	} {
+		if typ := fmt.Sprintf("%T", n); !seen[typ] {
+			t.Errorf("%s not visited", typ)
+		}
+	}
+}
+
+func TestWalkGopNodes(t *testing.T) {
+	const src = `
+for x <- [1, 2], x > 1 {
+	f(y => y * 2, (a, b) => { return a + b })
+	v := g()?:0
+	_ = {k: v for k, v <- m if k != ""}
+	for i <- 1:10:2 {
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.gop", src, 0)
+	if err != nil {
+		t.Fatal("ParseFile failed:", err)
+	}
+	var idents []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			idents = append(idents, id.Name)
+		}
+		return true
+	})
+	got := fmt.Sprint(idents)
+	const want = "[main main x x f y y a b a b v g _ k v k v m k i]"
+	if got != want {
+		t.Errorf("Inspect: got idents %s, want %s", got, want)
+	}
+}
+
+// -----------------------------------------------------------------------------
@@ -173,6 +173,12 @@ func TestGetGoFile(t *testing.T) {
 	if f := getGoFile("a_test.gop", false); f != skippingGoFile {
 		t.Fatal("TestGetGoFile:", f)
 	}
+	if f := getGoFile("Kai_test.spx", true); f != testingGoFile {
+		t.Fatal("TestGetGoFile:", f)
+	}
+	if f := getGoFile("Kai.spx", true); f != defaultGoFile {
+		t.Fatal("TestGetGoFile:", f)
+	}
 }
 
 func TestC2goBase(t *testing.T) {
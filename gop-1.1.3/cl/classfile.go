@@ -21,6 +21,7 @@ import (
 	"go/constant"
 	"go/types"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
@@ -61,20 +62,13 @@ func (p *gmxSettings) getScheds(cb *gox.CodeBuilder) []goast.Stmt {
 }
 
 func newGmx(ctx *pkgCtx, pkg *gox.Package, file string, conf *Config) *gmxSettings {
-	_, name := filepath.Split(file)
-	ext := filepath.Ext(name)
-	if idx := strings.Index(name, "."); idx > 0 {
-		name = name[:idx]
-		if name == "main" {
-			name = "_main"
-		}
-	}
+	ext := filepath.Ext(file)
 	gt, ok := conf.LookupClass(ext)
 	if !ok {
 		panic("TODO: class not found")
 	}
 	pkgPaths := gt.PkgPaths
-	p := &gmxSettings{extSpx: gt.WorkExt, gameClass: name, pkgPaths: pkgPaths}
+	p := &gmxSettings{extSpx: gt.WorkExt, gameClass: ClassNameOf(file, true), pkgPaths: pkgPaths}
 	p.pkgImps = make([]*gox.PkgRef, len(pkgPaths))
 	for i, pkgPath := range pkgPaths {
 		p.pkgImps[i] = pkg.Import(pkgPath)
@@ -105,14 +99,26 @@ func spxLookup(pkgImps []*gox.PkgRef, name string) gox.Ref {
 	panic("spxLookup: symbol not found - " + name)
 }
 
-func getDefaultClass(file string) string {
+// ClassNameOf returns the name of the class that the classfile file defines:
+// the name of the file up to its first dot, such as Kai for Kai.spx. The
+// project class of main.gmx is named _main.
+func ClassNameOf(file string, isProj bool) string {
 	_, name := filepath.Split(file)
 	if idx := strings.Index(name, "."); idx > 0 {
 		name = name[:idx]
+		if isProj && name == "main" {
+			name = "_main"
+		}
 	}
 	return name
 }
 
+// IsTestFile reports whether file is a test file, such as a_test.gop, or a
+// test classfile, such as Kai_test.spx.
+func IsTestFile(file string) bool {
+	return strings.HasSuffix(ClassNameOf(file, false), "_test")
+}
+
 func spxRef(spx *gox.PkgRef, name, typ string) (obj gox.Ref, isPtr bool) {
 	obj, isPtr = spxTryRef(spx, name, typ)
 	if obj == nil {
@@ -184,4 +190,94 @@ func gmxMainFunc(p *gox.Package, ctx *pkgCtx) {
 	}
 }
 
+// ClassTestName returns the name of the test function that runs the method
+// of the class of a test classfile, such as TestKai_Jump for the method
+// TestJump of Kai_test, and TestKai for its method Test. It reports false if
+// method is not named like a test or benchmark.
+func ClassTestName(class, method string) (string, bool) {
+	for _, prefix := range []string{"Test", "Benchmark"} {
+		if !strings.HasPrefix(method, prefix) {
+			continue
+		}
+		rest := method[len(prefix):]
+		if rest != "" && rest[0] >= 'a' && rest[0] <= 'z' { // Testing is not a test
+			continue
+		}
+		name := prefix + strings.TrimSuffix(class, "_test")
+		if rest != "" {
+			name += "_" + rest
+		}
+		return name, true
+	}
+	return "", false
+}
+
+// gmxTestFuncs generates a function for each test and benchmark method of the
+// classes of the test classfiles, as go test only runs functions:
+//
+//	func TestKai_Jump(t *testing.T) { new(Kai_test).TestJump(t) }
+func gmxTestFuncs(p *gox.Package, files map[string]*ast.File) {
+	fnames := make([]string, 0, len(files))
+	for fname, f := range files {
+		if f.IsClass && IsTestFile(fname) {
+			fnames = append(fnames, fname)
+		}
+	}
+	sort.Strings(fnames)
+	scope := p.Types.Scope()
+	for _, fname := range fnames {
+		class := ClassNameOf(fname, files[fname].IsProj)
+		o, ok := scope.Lookup(class).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		t, ok := o.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		for i, n := 0, t.NumMethods(); i < n; i++ {
+			m := t.Method(i)
+			name, ok := ClassTestName(class, m.Name())
+			if !ok || !m.Exported() || scope.Lookup(name) != nil {
+				continue
+			}
+			sig := m.Type().(*types.Signature)
+			if sig.Results().Len() != 0 || sig.Params().Len() != 1 || !isTestingParam(sig.Params().At(0).Type(), name) {
+				continue
+			}
+			argName := "t"
+			if strings.HasPrefix(name, "Benchmark") {
+				argName = "b"
+			}
+			old, _ := p.SetCurFile(testingGoFile, true)
+			arg := p.NewParam(token.NoPos, argName, sig.Params().At(0).Type())
+			p.NewFunc(nil, name, types.NewTuple(arg), nil, false).BodyStart(p).
+				Val(p.Builtin().Ref("new")).Val(o).Call(1).
+				MemberVal(m.Name()).Val(arg).Call(1).EndStmt().
+				End()
+			p.RestoreCurFile(old)
+		}
+	}
+}
+
+// isTestingParam reports whether typ is *testing.T for a test function name,
+// or *testing.B for a benchmark.
+func isTestingParam(typ types.Type, name string) bool {
+	want := "T"
+	if strings.HasPrefix(name, "Benchmark") {
+		want = "B"
+	} else if !strings.HasPrefix(name, "Test") {
+		return false
+	}
+	ptr, ok := typ.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == "testing" && named.Obj().Name() == want
+}
+
 // -----------------------------------------------------------------------------
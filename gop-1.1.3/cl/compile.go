@@ -93,11 +93,25 @@ type Config struct {
 	// RelativePath = true means to generate file line comments with relative file path.
 	RelativePath bool
 
+	// SourceMap, if not nil, records the ranges of the Go+ statements, and
+	// makes file line comments include columns, so that a SourceMap can be
+	// built for the generated Go code. It has no effect if NoFileLine is set.
+	SourceMap *SourceRanges
+
 	// NoAutoGenMain = true means not to auto generate main func is no entry.
 	NoAutoGenMain bool
 
 	// NoSkipConstant = true means disable optimization of skip constants
 	NoSkipConstant bool
+
+	// GenTestFuncs = true means to generate a function for each test and
+	// benchmark method of the classes of the test classfiles, named as
+	// ClassTestName does, so that go test runs them.
+	GenTestFuncs bool
+
+	// Recorder records the objects and types that the compiler resolves for
+	// the Go+ files of the package (optional).
+	Recorder Recorder
 }
 
 type nodeInterp struct {
@@ -243,6 +257,7 @@ type pkgCtx struct {
 	inits []func()
 	tylds []*typeLoader
 	errs  errors.List
+	rec   Recorder
 }
 
 type blockCtx struct {
@@ -251,6 +266,7 @@ type blockCtx struct {
 	cb           *gox.CodeBuilder
 	fset         *token.FileSet
 	imports      map[string]*gox.PkgRef
+	pkgNames     map[string]*types.PkgName // available when recording, see Config.Recorder
 	lookups      []*gox.PkgRef
 	clookups     []*cpackages.PkgRef
 	c2goBase     string // default is `github.com/goplus/`
@@ -259,6 +275,7 @@ type blockCtx struct {
 	fileLine     bool
 	relativePath bool
 	isClass      bool
+	sourceMap    *SourceRanges
 }
 
 func (bc *blockCtx) findImport(name string) (pr *gox.PkgRef, ok bool) {
@@ -365,7 +382,7 @@ func NewPackage(pkgPath string, pkg *ast.Package, conf *Config) (p *gox.Package,
 		fset: fset, files: files, workingDir: workingDir,
 	}
 	ctx := &pkgCtx{
-		syms: make(map[string]loader), nodeInterp: interp,
+		syms: make(map[string]loader), nodeInterp: interp, rec: conf.Recorder,
 	}
 	confGox := &gox.Config{
 		Fset:            fset,
@@ -400,7 +417,7 @@ func NewPackage(pkgPath string, pkg *ast.Package, conf *Config) (p *gox.Package,
 		fileLine := !conf.NoFileLine
 		ctx := &blockCtx{
 			pkg: p, pkgCtx: ctx, cb: p.CB(), fset: p.Fset, targetDir: targetDir,
-			fileLine: fileLine, relativePath: conf.RelativePath, isClass: f.IsClass,
+			fileLine: fileLine, relativePath: conf.RelativePath, isClass: f.IsClass, sourceMap: conf.SourceMap,
 			c2goBase: c2goBase(conf.C2goBase), imports: make(map[string]*gox.PkgRef),
 		}
 		preloadGopFile(p, ctx, fpath, f, conf)
@@ -431,6 +448,9 @@ func NewPackage(pkgPath string, pkg *ast.Package, conf *Config) (p *gox.Package,
 	for _, load := range ctx.inits {
 		load()
 	}
+	if conf.GenTestFuncs {
+		gmxTestFuncs(p, files)
+	}
 	err = ctx.complete()
 
 	if !conf.NoAutoGenMain && pkg.Name == "main" {
@@ -502,7 +522,7 @@ func loadFile(ctx *pkgCtx, f *ast.File) {
 
 func getGoFile(file string, genCode bool) string {
 	if genCode {
-		if strings.HasSuffix(file, "_test.gop") {
+		if IsTestFile(file) {
 			return testingGoFile
 		}
 		return defaultGoFile
@@ -526,7 +546,7 @@ func preloadGopFile(p *gox.Package, ctx *blockCtx, file string, f *ast.File, con
 		}
 	case f.IsClass:
 		if parent.gmxSettings != nil {
-			classType = getDefaultClass(file)
+			classType = ClassNameOf(file, false)
 			o := parent.sprite
 			baseTypeName, baseType, spxClass = o.Name(), o.Type(), true
 		}
@@ -574,7 +594,9 @@ func preloadGopFile(p *gox.Package, ctx *blockCtx, file string, f *ast.File, con
 					}
 					typ := toType(ctx, spec.Type)
 					for _, name := range spec.Names {
-						flds = append(flds, types.NewField(name.Pos(), pkg, name.Name, typ, false))
+						fld := types.NewField(name.Pos(), pkg, name.Name, typ, false)
+						flds = append(flds, fld)
+						ctx.recordDef(name, fld)
 					}
 				}
 				decl.InitType(p, types.NewStruct(flds, nil))
@@ -705,12 +727,14 @@ func preloadFile(p *gox.Package, ctx *blockCtx, file string, f *ast.File, genCod
 									log.Println("==> Load > AliasType", name)
 								}
 								ctx.pkg.AliasType(name, toType(ctx, t.Type), t.Pos())
+								ctx.recordDef(t.Name, ctx.pkg.Types.Scope().Lookup(name))
 								return
 							}
 							if debugLoad {
 								log.Println("==> Load > NewType", name)
 							}
 							decl := ctx.pkg.NewType(name)
+							ctx.recordDef(t.Name, decl.Type().Obj())
 							if t.Doc != nil {
 								decl.SetComments(t.Doc)
 							} else if d.Doc != nil {
@@ -748,7 +772,8 @@ func preloadFile(p *gox.Package, ctx *blockCtx, file string, f *ast.File, genCod
 				}
 			case token.CONST:
 				pkg := ctx.pkg
-				cdecl := pkg.NewConstDecl(pkg.Types.Scope())
+				scope := pkg.Types.Scope()
+				cdecl := pkg.NewConstDecl(scope)
 				for _, spec := range d.Specs {
 					vSpec := spec.(*ast.ValueSpec)
 					if debugLoad {
@@ -757,7 +782,7 @@ func preloadFile(p *gox.Package, ctx *blockCtx, file string, f *ast.File, genCod
 					setNamesLoader(parent, syms, vSpec.Names, func() {
 						if c := cdecl; c != nil {
 							cdecl = nil
-							loadConstSpecs(ctx, c, d.Specs)
+							loadConstSpecs(ctx, scope, c, d.Specs)
 							for _, s := range d.Specs {
 								v := s.(*ast.ValueSpec)
 								removeNames(syms, v.Names)
@@ -854,11 +879,11 @@ func loadFunc(ctx *blockCtx, recv *types.Var, d *ast.FuncDecl) {
 	}
 	if d.Operator {
 		if recv != nil { // binary op
-			if v, ok := binaryGopNames[name]; ok {
+			if v, ok := BinaryGopNames[name]; ok {
 				name = v
 			}
 		} else { // unary op
-			if v, ok := unaryGopNames[name]; ok {
+			if v, ok := UnaryGopNames[name]; ok {
 				name = v
 				at := ctx.pkg.Types
 				arg1 := d.Type.Params.List[0]
@@ -876,21 +901,29 @@ func loadFunc(ctx *blockCtx, recv *types.Var, d *ast.FuncDecl) {
 		ctx.handleErr(err)
 		return
 	}
+	ctx.recordDef(d.Name, fn.Func)
 	if d.Doc != nil {
 		fn.SetComments(d.Doc)
 	}
 	if body := d.Body; body != nil {
 		if recv != nil {
+			// the body goes to the file of the method, such as the _test file
+			file := ctx.pkg.CurFile()
 			ctx.inits = append(ctx.inits, func() { // interface issue: #795
-				loadFuncBody(ctx, fn, body)
+				old := ctx.pkg.CurFile()
+				ctx.pkg.RestoreCurFile(file)
+				defer ctx.pkg.RestoreCurFile(old)
+				loadFuncBody(ctx, fn, body, d.Type)
 			})
 		} else {
-			loadFuncBody(ctx, fn, body)
+			loadFuncBody(ctx, fn, body, d.Type)
 		}
 	}
 }
 
-var binaryGopNames = map[string]string{
+// BinaryGopNames maps binary operators to the names of the methods that
+// overload them, such as Gop_Add for +.
+var BinaryGopNames = map[string]string{
 	"+": "Gop_Add",
 	"-": "Gop_Sub",
 	"*": "Gop_Mul",
@@ -930,7 +963,9 @@ var binaryGopNames = map[string]string{
 	"<-": "Gop_Send",
 }
 
-var unaryGopNames = map[string]string{
+// UnaryGopNames maps unary operators to the names of the methods that
+// overload them, such as Gop_Neg for -.
+var UnaryGopNames = map[string]string{
 	"++": "Gop_Inc",
 	"--": "Gop_Dec",
 	"-":  "Gop_Neg",
@@ -940,8 +975,9 @@ var unaryGopNames = map[string]string{
 	"<-": "Gop_Recv",
 }
 
-func loadFuncBody(ctx *blockCtx, fn *gox.Func, body *ast.BlockStmt) {
+func loadFuncBody(ctx *blockCtx, fn *gox.Func, body *ast.BlockStmt, src ast.Node) {
 	cb := fn.BodyStart(ctx.pkg)
+	ctx.recordScope(src, cb.Scope())
 	compileStmts(ctx, body.List)
 	cb.End()
 }
@@ -988,12 +1024,25 @@ func loadImport(ctx *blockCtx, spec *ast.ImportSpec) {
 		name = pkg.Types.Name()
 	}
 	ctx.imports[name] = pkg
+	if ctx.recording() {
+		pkgName := types.NewPkgName(spec.Pos(), ctx.pkg.Types, name, pkg.Types)
+		if ctx.pkgNames == nil {
+			ctx.pkgNames = make(map[string]*types.PkgName)
+		}
+		ctx.pkgNames[name] = pkgName
+		if spec.Name != nil {
+			ctx.recordDef(spec.Name, pkgName)
+		} else {
+			ctx.recordImplicit(spec, pkgName)
+		}
+	}
 }
 
-func loadConstSpecs(ctx *blockCtx, cdecl *gox.ConstDecl, specs []ast.Spec) {
+func loadConstSpecs(ctx *blockCtx, scope *types.Scope, cdecl *gox.ConstDecl, specs []ast.Spec) {
 	for iotav, spec := range specs {
 		vSpec := spec.(*ast.ValueSpec)
 		loadConsts(ctx, cdecl, vSpec, iotav)
+		ctx.recordDefsInScope(scope, vSpec.Names, nil)
 	}
 }
 
@@ -1063,6 +1112,7 @@ func loadVars(ctx *blockCtx, v *ast.ValueSpec, global bool) {
 		}
 		cb.EndInit(nv)
 	}
+	ctx.recordDefsInScope(scope, v.Names, nil)
 }
 
 func makeNames(vals []*ast.Ident) []string {
@@ -482,3 +482,103 @@ func (this *Kai) onMsg(msg string) {
 }
 `, "Game.t2gmx", "Kai.t2spx")
 }
+
+func TestSpxTestFuncs(t *testing.T) {
+	cl.SetDisableRecover(true)
+	defer cl.SetDisableRecover(false)
+
+	fs := parsertest.NewTwoFilesFS("/foo", "Kai_test.tspx", `
+import "testing"
+
+func TestJump(t *testing.T) {
+	t.Log("jump")
+}
+
+func Test(t *testing.T) {
+}
+
+func BenchmarkRun(b *testing.B) {
+}
+
+func Testing(t *testing.T) {
+}
+
+func TestSay(msg string) {
+}
+`, "Game.tgmx", ``)
+	const methods = `package main
+
+import testing "testing"
+
+func (this *Kai_test) TestJump(t *testing.T) {
+	t.Log("jump")
+}
+func (this *Kai_test) Test(t *testing.T) {
+}
+func (this *Kai_test) BenchmarkRun(b *testing.B) {
+}
+func (this *Kai_test) Testing(t *testing.T) {
+}
+func (this *Kai_test) TestSay(msg string) {
+}
+`
+	const testFuncs = `func TestKai_Jump(t *testing.T) {
+	new(Kai_test).TestJump(t)
+}
+func TestKai(t *testing.T) {
+	new(Kai_test).Test(t)
+}
+func BenchmarkKai_Run(b *testing.B) {
+	new(Kai_test).BenchmarkRun(b)
+}
+`
+	for _, genTestFuncs := range []bool{false, true} {
+		pkgs, err := parser.ParseFSDir(gblFset, fs, "/foo", spxParserConf())
+		if err != nil {
+			scanner.PrintError(os.Stderr, err)
+			t.Fatal("ParseFSDir:", err)
+		}
+		conf := *gblConf
+		conf.GenTestFuncs = genTestFuncs
+		pkg, err := cl.NewPackage("", pkgs["main"], &conf)
+		if err != nil {
+			t.Fatal("NewPackage:", err)
+		}
+		var b bytes.Buffer
+		if err = pkg.WriteTo(&b, "_test"); err != nil {
+			t.Fatal("gox.WriteTo failed:", err)
+		}
+		expected := methods
+		if genTestFuncs {
+			expected += testFuncs
+		}
+		if result := b.String(); result != expected {
+			t.Fatalf("GenTestFuncs = %v:\nResult:\n%s\nExpected:\n%s\n", genTestFuncs, result, expected)
+		}
+	}
+	for _, test := range []struct{ class, method, name string }{
+		{"Kai_test", "TestJump", "TestKai_Jump"},
+		{"Kai_test", "Test", "TestKai"},
+		{"Kai_test", "BenchmarkRun", "BenchmarkKai_Run"},
+		{"Kai_test", "Testing", ""},
+		{"Kai_test", "onStart", ""},
+	} {
+		if name, _ := cl.ClassTestName(test.class, test.method); name != test.name {
+			t.Errorf("ClassTestName(%s, %s) = %q, want %q", test.class, test.method, name, test.name)
+		}
+	}
+}
+
+func TestIsTestFile(t *testing.T) {
+	for filename, want := range map[string]bool{
+		"/foo/bar_test.gop":  true,
+		"/foo/Kai_test.spx":  true,
+		"/foo/bar.gop":       false,
+		"/foo/Kai.spx":       false,
+		"/foo/test_data.gop": false,
+	} {
+		if got := cl.IsTestFile(filename); got != want {
+			t.Errorf("IsTestFile(%s) = %t, want %t", filename, got, want)
+		}
+	}
+}
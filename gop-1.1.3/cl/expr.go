@@ -95,6 +95,7 @@ func compileIdent(ctx *blockCtx, ident *ast.Ident, flags int) (obj *gox.PkgRef,
 			if recv := sig.Recv(); recv != nil {
 				ctx.cb.Val(recv)
 				if compileMember(ctx, ident, name, flags) == nil { // class member object
+					ctx.recordMember(ctx.pkg.Types, ident, nil, recv.Type(), name)
 					return
 				}
 				ctx.cb.InternalStack().PopN(1)
@@ -145,6 +146,7 @@ find:
 	} else {
 		ctx.cb.VarRef(o, ident)
 	}
+	ctx.recordUse(ident, o)
 	return
 }
 
@@ -257,6 +259,7 @@ func compileExpr(ctx *blockCtx, expr ast.Expr, inFlags ...int) {
 	default:
 		log.Panicln("compileExpr failed: unknown -", reflect.TypeOf(v))
 	}
+	ctx.recordTypeOfTop(expr)
 }
 
 func compileExprOrNone(ctx *blockCtx, expr ast.Expr) {
@@ -323,13 +326,22 @@ func compileSelectorExprLHS(ctx *blockCtx, v *ast.SelectorExpr) {
 	switch x := v.X.(type) {
 	case *ast.Ident:
 		if at, kind := compileIdent(ctx, x, clIdentLHS|clIdentSelectorExpr); kind != objNormal {
-			ctx.cb.VarRef(at.Ref(v.Sel.Name))
+			o := at.Ref(v.Sel.Name)
+			ctx.cb.VarRef(o)
+			ctx.recordUse(x, ctx.pkgNames[x.Name])
+			ctx.recordUse(v.Sel, o)
 			return
 		}
+		ctx.recordTypeOfTop(x)
 	default:
 		compileExpr(ctx, v.X)
 	}
+	var xType types.Type
+	if ctx.recording() {
+		xType = ctx.cb.Get(-1).Type
+	}
 	ctx.cb.MemberRef(v.Sel.Name, v)
+	ctx.recordMember(ctx.pkg.Types, v.Sel, v, xType, v.Sel.Name)
 }
 
 func compileSelectorExpr(ctx *blockCtx, v *ast.SelectorExpr, flags int) {
@@ -337,6 +349,9 @@ func compileSelectorExpr(ctx *blockCtx, v *ast.SelectorExpr, flags int) {
 	case *ast.Ident:
 		if at, kind := compileIdent(ctx, x, flags|clIdentCanAutoCall|clIdentSelectorExpr); kind != objNormal {
 			if compilePkgRef(ctx, at, v.Sel, flags, kind) {
+				if kind == objPkgRef {
+					ctx.recordUse(x, ctx.pkgNames[x.Name])
+				}
 				return
 			}
 			if token.IsExported(v.Sel.Name) {
@@ -344,12 +359,18 @@ func compileSelectorExpr(ctx *blockCtx, v *ast.SelectorExpr, flags int) {
 			}
 			panic(ctx.newCodeErrorf(x.Pos(), "cannot refer to unexported name %s.%s", x.Name, v.Sel.Name))
 		}
+		ctx.recordTypeOfTop(x)
 	default:
 		compileExpr(ctx, v.X)
 	}
+	var xType types.Type
+	if ctx.recording() {
+		xType = ctx.cb.Get(-1).Type
+	}
 	if err := compileMember(ctx, v, v.Sel.Name, flags); err != nil {
 		panic(err)
 	}
+	ctx.recordMember(ctx.pkg.Types, v.Sel, v, xType, v.Sel.Name)
 }
 
 func pkgRef(at *gox.PkgRef, name string) (o types.Object, alias bool) {
@@ -409,6 +430,7 @@ func compilePkgRef(ctx *blockCtx, at *gox.PkgRef, x *ast.Ident, flags, pkgKind i
 				cb.Call(0)
 			}
 		}
+		ctx.recordUse(x, v)
 		return true
 	}
 	return false
@@ -466,8 +488,10 @@ func compileCallExpr(ctx *blockCtx, v *ast.CallExpr, inFlags int) {
 	switch fn := v.Fun.(type) {
 	case *ast.Ident:
 		compileIdent(ctx, fn, clIdentAllowBuiltin|inFlags)
+		ctx.recordTypeOfTop(fn)
 	case *ast.SelectorExpr:
 		compileSelectorExpr(ctx, fn, 0)
+		ctx.recordTypeOfTop(fn)
 	case *ast.ErrWrapExpr:
 		if v.IsCommand() {
 			callExpr := *v
@@ -590,6 +614,7 @@ func makeLambdaParams(ctx *blockCtx, pos token.Pos, lhs []*ast.Ident, in *types.
 	params := make([]*types.Var, n)
 	for i, name := range lhs {
 		params[i] = pkg.NewParam(name.Pos(), name.Name, in.At(i).Type())
+		ctx.recordDef(name, params[i])
 	}
 	return types.NewTuple(params...)
 }
@@ -611,10 +636,12 @@ func compileLambdaExpr(ctx *blockCtx, v *ast.LambdaExpr, sig *types.Signature) {
 	params := makeLambdaParams(ctx, v.Pos(), v.Lhs, sig.Params())
 	results := makeLambdaResults(pkg, sig.Results())
 	ctx.cb.NewClosure(params, results, false).BodyStart(pkg)
+	ctx.recordScope(v, ctx.cb.Scope())
 	for _, v := range v.Rhs {
 		compileExpr(ctx, v)
 	}
 	ctx.cb.Return(len(v.Rhs)).End()
+	ctx.recordTypeOfTop(v)
 }
 
 func compileLambdaExpr2(ctx *blockCtx, v *ast.LambdaExpr2, sig *types.Signature) {
@@ -623,8 +650,9 @@ func compileLambdaExpr2(ctx *blockCtx, v *ast.LambdaExpr2, sig *types.Signature)
 	results := makeLambdaResults(pkg, sig.Results())
 	comments, once := ctx.cb.BackupComments()
 	fn := ctx.cb.NewClosure(params, results, false)
-	loadFuncBody(ctx, fn, v.Body)
+	loadFuncBody(ctx, fn, v.Body, v)
 	ctx.cb.SetComments(comments, once)
+	ctx.recordTypeOfTop(v)
 }
 
 func compileFuncLit(ctx *blockCtx, v *ast.FuncLit) {
@@ -633,7 +661,7 @@ func compileFuncLit(ctx *blockCtx, v *ast.FuncLit) {
 	sig := toFuncType(ctx, v.Type, nil)
 	fn := cb.NewClosureWith(sig)
 	if body := v.Body; body != nil {
-		loadFuncBody(ctx, fn, body)
+		loadFuncBody(ctx, fn, body, v.Type)
 		cb.SetComments(comments, once)
 	}
 }
@@ -710,6 +738,7 @@ func compileStructLitInKeyVal(ctx *blockCtx, elts []ast.Expr, t *types.Struct, t
 		idx := lookupField(t, name.Name)
 		if idx >= 0 {
 			ctx.cb.Val(idx)
+			ctx.recordUse(name, t.Field(idx))
 		} else {
 			src, pos := ctx.LoadExpr(name)
 			err := newCodeErrorf(&pos, "%s undefined (type %v has no field or method %s)", src, typ, name.Name)
@@ -802,6 +831,7 @@ func compileCompositeLit(ctx *blockCtx, v *ast.CompositeLit, expected types.Type
 		if hasPtr {
 			ctx.cb.UnaryOp(gotoken.AND)
 		}
+		ctx.recordTypeOfTop(v)
 		return
 	}
 	compileCompositeLitElts(ctx, v.Elts, kind, &kvType{underlying: underlying})
@@ -811,6 +841,7 @@ func compileCompositeLit(ctx *blockCtx, v *ast.CompositeLit, expected types.Type
 			panic("TODO: mapLit should be in {key: val, ...} form")
 		}
 		ctx.cb.MapLit(nil, n<<1)
+		ctx.recordTypeOfTop(v)
 		return
 	}
 	switch underlying.(type) {
@@ -828,6 +859,7 @@ func compileCompositeLit(ctx *blockCtx, v *ast.CompositeLit, expected types.Type
 	if hasPtr {
 		ctx.cb.UnaryOp(gotoken.AND)
 	}
+	ctx.recordTypeOfTop(v)
 }
 
 func compileSliceLit(ctx *blockCtx, v *ast.SliceLit, typ types.Type) {
@@ -836,6 +868,7 @@ func compileSliceLit(ctx *blockCtx, v *ast.SliceLit, typ types.Type) {
 		compileExpr(ctx, elt)
 	}
 	ctx.cb.SliceLit(typ, n)
+	ctx.recordTypeOfTop(v)
 }
 
 func compileRangeExpr(ctx *blockCtx, v *ast.RangeExpr) {
@@ -913,6 +946,8 @@ func compileComprehensionExpr(ctx *blockCtx, v *ast.ComprehensionExpr, twoValue
 		cb.ForRange(names...)
 		compileExpr(ctx, forStmt.X)
 		cb.RangeAssignThen(forStmt.TokPos)
+		ctx.recordScope(forStmt, cb.Scope())
+		ctx.recordDefsInScope(cb.Scope(), []*ast.Ident{forStmt.Key, forStmt.Value}, nil)
 		if forStmt.Cond != nil {
 			cb.If()
 			if forStmt.Init != nil {
@@ -43,7 +43,11 @@ func toRecv(ctx *blockCtx, recv *ast.FieldList) *types.Var {
 	if len(v.Names) > 0 {
 		name = v.Names[0].Name
 	}
-	return ctx.pkg.NewParam(v.Pos(), name, toType(ctx, v.Type))
+	param := ctx.pkg.NewParam(v.Pos(), name, toType(ctx, v.Type))
+	if len(v.Names) > 0 {
+		ctx.recordDef(v.Names[0], param)
+	}
+	return param
 }
 
 func getRecvTypeName(ctx *pkgCtx, recv *ast.FieldList, handleErr bool) (string, bool) {
@@ -94,7 +98,9 @@ func toParam(ctx *blockCtx, fld *ast.Field, args []*gox.Param) []*gox.Param {
 		return append(args, pkg.NewParam(fld.Pos(), "", typ))
 	}
 	for _, name := range fld.Names {
-		args = append(args, pkg.NewParam(name.Pos(), name.Name, typ))
+		param := pkg.NewParam(name.Pos(), name.Name, typ)
+		ctx.recordDef(name, param)
+		args = append(args, param)
 	}
 	return args
 }
@@ -149,6 +155,8 @@ func toExternalType(ctx *blockCtx, v *ast.SelectorExpr) types.Type {
 	if pr, ok := ctx.findImport(name); ok {
 		o := pr.TryRef(v.Sel.Name)
 		if t, ok := o.(*types.TypeName); ok {
+			ctx.recordUse(v.X.(*ast.Ident), ctx.pkgNames[name])
+			ctx.recordUse(v.Sel, t)
 			return t.Type()
 		}
 		panic(ctx.newCodeErrorf(v.Pos(), "%s.%s is not a type", name, v.Sel.Name))
@@ -171,10 +179,12 @@ func toIdentType(ctx *blockCtx, ident *ast.Ident) types.Type {
 		panic(ctx.newCodeErrorf(ident.Pos(), "use of builtin %s not in function call", ident.Name))
 	}
 	if t, ok := v.(*types.TypeName); ok {
+		ctx.recordUse(ident, t)
 		return t.Type()
 	}
 	if v, _ := lookupPkgRef(ctx, nil, ident, objPkgRef); v != nil {
 		if t, ok := v.(*types.TypeName); ok {
+			ctx.recordUse(ident, t)
 			return t.Type()
 		}
 	}
@@ -233,7 +243,7 @@ func toStructType(ctx *blockCtx, v *ast.StructType) *types.Struct {
 			if t, ok := typ.(*types.Named); ok { // #1196: embedded type should ensure loaded
 				ctx.loadNamed(ctx.pkg, t)
 			}
-			fld := types.NewField(token.NoPos, pkg, name, typ, true)
+			fld := types.NewField(field.Type.Pos(), pkg, name, typ, true)
 			fields = append(fields, fld)
 			tags = append(tags, toFieldTag(field.Tag))
 			continue
@@ -242,8 +252,9 @@ func toStructType(ctx *blockCtx, v *ast.StructType) *types.Struct {
 			if chkRedecl(name.Name, name.NamePos) {
 				continue
 			}
-			fld := types.NewField(token.NoPos, pkg, name.Name, typ, false)
+			fld := types.NewField(name.NamePos, pkg, name.Name, typ, false)
 			fields = append(fields, fld)
+			ctx.recordDef(name, fld)
 			tags = append(tags, toFieldTag(field.Tag))
 		}
 	}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cl
+
+import (
+	"go/types"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/goplus/gox"
+)
+
+// -----------------------------------------------------------------------------
+
+// Recorder represents a compiling event recorder. It is called by the
+// compiler as it resolves identifiers and types the expressions of Go+
+// files, in the spirit of types.Info, so that tools can map the compiled
+// package back to the Go+ source.
+type Recorder interface {
+	// Type maps an expression to its type, and for constant expressions,
+	// also its value. Type expressions are mapped to the type they denote.
+	Type(expr ast.Expr, tv types.TypeAndValue)
+
+	// Def maps an identifier to the object it defines.
+	Def(id *ast.Ident, obj types.Object)
+
+	// Use maps an identifier to the object it denotes.
+	Use(id *ast.Ident, obj types.Object)
+
+	// Implicit maps a node to an object declared implicitly by it, such
+	// as the package name of an unnamed import spec.
+	Implicit(node ast.Node, obj types.Object)
+
+	// Select maps a selector expression (excluding qualified identifiers)
+	// to its selection.
+	Select(e *ast.SelectorExpr, sel *Selection)
+
+	// Scope maps a node to the scope it opens.
+	Scope(n ast.Node, scope *types.Scope)
+}
+
+// A Selection describes the object denoted by a selector expression x.f.
+// It mirrors types.Selection, which can't be created outside go/types.
+type Selection struct {
+	Kind     types.SelectionKind
+	Recv     types.Type   // type of x
+	Obj      types.Object // object denoted by x.f
+	Index    []int        // path from x to x.f, see types.Selection.Index
+	Indirect bool         // whether a pointer indirection was needed
+}
+
+// Type returns the type of x.f, as types.Selection.Type does.
+func (p *Selection) Type() types.Type {
+	if p.Kind == types.MethodExpr {
+		sig := p.Obj.Type().(*types.Signature)
+		params := []*types.Var{types.NewParam(sig.Recv().Pos(), sig.Recv().Pkg(), sig.Recv().Name(), p.Recv)}
+		for i, n := 0, sig.Params().Len(); i < n; i++ {
+			params = append(params, sig.Params().At(i))
+		}
+		return types.NewSignature(nil, types.NewTuple(params...), sig.Results(), sig.Variadic())
+	}
+	if p.Kind == types.MethodVal {
+		sig := p.Obj.Type().(*types.Signature)
+		return types.NewSignature(nil, sig.Params(), sig.Results(), sig.Variadic())
+	}
+	return p.Obj.Type()
+}
+
+// -----------------------------------------------------------------------------
+
+// recording reports whether compiling events are recorded, see Config.Recorder.
+func (p *pkgCtx) recording() bool {
+	return p != nil && p.rec != nil
+}
+
+func (p *pkgCtx) recordDef(id *ast.Ident, obj types.Object) {
+	if p.recording() && id != nil && obj != nil {
+		p.rec.Def(id, obj)
+	}
+}
+
+func (p *pkgCtx) recordUse(id *ast.Ident, obj types.Object) {
+	if p.recording() && id != nil && obj != nil {
+		p.rec.Use(id, obj)
+	}
+}
+
+func (p *pkgCtx) recordImplicit(node ast.Node, obj types.Object) {
+	if p.recording() && obj != nil {
+		p.rec.Implicit(node, obj)
+	}
+}
+
+func (p *pkgCtx) recordScope(n ast.Node, scope *types.Scope) {
+	if p.recording() && scope != nil {
+		p.rec.Scope(n, scope)
+	}
+}
+
+// recordTypeOfTop records the type and constant value of the element on the
+// top of the code builder stack, which is the result of compiling expr.
+func (ctx *blockCtx) recordTypeOfTop(expr ast.Expr) {
+	if !ctx.recording() || ctx.cb.InternalStack().Len() == 0 {
+		return
+	}
+	e := ctx.cb.Get(-1)
+	typ := e.Type
+	if t, ok := typ.(*gox.TypeType); ok {
+		typ = t.Type()
+	}
+	ctx.rec.Type(expr, types.TypeAndValue{Type: typ, Value: e.CVal})
+}
+
+// recordDefsInScope records the objects that names define in scope. Names
+// that were already declared in scope before (see :=) are recorded as uses.
+func (p *pkgCtx) recordDefsInScope(scope *types.Scope, names []*ast.Ident, old map[string]bool) {
+	if !p.recording() {
+		return
+	}
+	for _, name := range names {
+		if name == nil || name.Name == "_" {
+			continue
+		}
+		if obj := scope.Lookup(name.Name); obj != nil {
+			if old[name.Name] {
+				p.rec.Use(name, obj)
+			} else {
+				p.rec.Def(name, obj)
+			}
+		}
+	}
+}
+
+// recordMember records the object denoted by the member name of a value (or,
+// for a method expression, a type) of type x, as resolved by CodeBuilder.Member.
+// If sel is not nil, the selection is recorded as well.
+func (p *pkgCtx) recordMember(at *types.Package, id *ast.Ident, sel *ast.SelectorExpr, x types.Type, name string) {
+	if !p.recording() || x == nil {
+		return
+	}
+	kind := types.MethodVal
+	if t, ok := x.(*gox.TypeType); ok {
+		x, kind = t.Type(), types.MethodExpr
+	}
+	obj, index, indirect := types.LookupFieldOrMethod(x, true, at, name)
+	if obj == nil {
+		if c := name[0]; c >= 'a' && c <= 'z' { // method alias, see gox.MemberFlagMethodAlias
+			name = string(rune(c)+('A'-'a')) + name[1:]
+			obj, index, indirect = types.LookupFieldOrMethod(x, true, at, name)
+		}
+		if obj == nil {
+			return
+		}
+	}
+	if _, ok := obj.(*types.Var); ok {
+		kind = types.FieldVal
+	}
+	p.rec.Use(id, obj)
+	if sel != nil {
+		p.rec.Select(sel, &Selection{Kind: kind, Recv: x, Obj: obj, Index: index, Indirect: indirect})
+	}
+}
+
+// -----------------------------------------------------------------------------
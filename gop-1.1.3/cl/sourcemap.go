@@ -0,0 +1,186 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cl
+
+import (
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+)
+
+// -----------------------------------------------------------------------------
+
+// A SourceMap maps ranges of a generated Go file, such as gop_autogen.go, to
+// the ranges of the Go+ statements they were compiled from. It is written as
+// JSON next to the Go file.
+//
+// Mappings are sorted by Start. They nest when a Go+ statement compiles to Go
+// statements which have Go+ statements of their own, such as the body of an
+// if statement, or the statements generated for an error wrapping expression:
+// the innermost mapping of an offset is the most precise.
+type SourceMap struct {
+	Version  int             `json:"version"`
+	File     string          `json:"file"`    // the generated Go file
+	Sources  []string        `json:"sources"` // the Go+ files, named as in the file line comments
+	Mappings []SourceMapping `json:"mappings"`
+}
+
+// SourceMapVersion is the version of the SourceMap format.
+const SourceMapVersion = 1
+
+// A SourceMapping maps a range of the generated Go file to a range of a Go+
+// file. Lines and columns are 1-based, and columns are byte counts, as in
+// token.Position; the end of each range is exclusive.
+type SourceMapping struct {
+	Start     int `json:"start"` // byte offset in the Go file
+	End       int `json:"end"`
+	Source    int `json:"source"` // index in Sources
+	StartLine int `json:"startLine"`
+	StartCol  int `json:"startCol"`
+	EndLine   int `json:"endLine"`
+	EndCol    int `json:"endCol"`
+}
+
+// SourceRanges records the ranges of the Go+ statements cl compiles, see
+// Config.SourceMap. One SourceRanges can be shared by the packages compiled
+// for a directory, such as a package and its test package.
+type SourceRanges struct {
+	ends map[sourcePos]sourcePos
+}
+
+type sourcePos struct {
+	file      string
+	line, col int
+}
+
+// NewSourceRanges returns an empty SourceRanges.
+func NewSourceRanges() *SourceRanges {
+	return &SourceRanges{ends: make(map[sourcePos]sourcePos)}
+}
+
+func (p *SourceRanges) add(start, end token.Position) {
+	p.ends[sourcePos{start.Filename, start.Line, start.Column}] = sourcePos{start.Filename, end.Line, end.Column}
+}
+
+// SourceMap builds the source map of a Go file generated from the packages
+// compiled with p: each file line comment, which gets a column, starts a
+// mapping from the Go statements after it, up to the next one, to the Go+
+// statement it was generated for.
+func (p *SourceRanges) SourceMap(file string, src []byte) (*SourceMap, error) {
+	fset := gotoken.NewFileSet()
+	f, err := goparser.ParseFile(fset, file, src, goparser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	tokFile := fset.File(f.Pos())
+	line := func(pos gotoken.Pos) int { // ignoring the file line comments
+		return tokFile.PositionFor(pos, false).Line
+	}
+
+	// the Go+ statements by the line of their file line comment
+	stmts := make(map[int]sourcePos)
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			if start, ok := parseLineDirective(c.Text); ok {
+				if _, ok := p.ends[start]; ok {
+					stmts[line(c.Pos())] = start
+				}
+			}
+		}
+	}
+
+	ret := &SourceMap{Version: SourceMapVersion, File: file, Sources: []string{}, Mappings: []SourceMapping{}}
+	sources := make(map[string]int)
+	list := func(stmts_ []goast.Stmt) {
+		cur := -1 // the mapping of the previous statements
+		for _, stmt := range stmts_ {
+			start, ok := stmts[line(stmt.Pos())-1]
+			if !ok {
+				if cur >= 0 {
+					ret.Mappings[cur].End = tokFile.Offset(stmt.End())
+				}
+				continue
+			}
+			src, ok := sources[start.file]
+			if !ok {
+				src = len(ret.Sources)
+				sources[start.file] = src
+				ret.Sources = append(ret.Sources, start.file)
+			}
+			end := p.ends[start]
+			ret.Mappings = append(ret.Mappings, SourceMapping{
+				Start:     tokFile.Offset(stmt.Pos()),
+				End:       tokFile.Offset(stmt.End()),
+				Source:    src,
+				StartLine: start.line,
+				StartCol:  start.col,
+				EndLine:   end.line,
+				EndCol:    end.col,
+			})
+			cur = len(ret.Mappings) - 1
+		}
+	}
+	goast.Inspect(f, func(n goast.Node) bool {
+		switch n := n.(type) {
+		case *goast.BlockStmt:
+			list(n.List)
+		case *goast.CaseClause:
+			list(n.Body)
+		case *goast.CommClause:
+			list(n.Body)
+		}
+		return true
+	})
+	sort.SliceStable(ret.Mappings, func(i, j int) bool {
+		return ret.Mappings[i].Start < ret.Mappings[j].Start
+	})
+	return ret, nil
+}
+
+// parseLineDirective parses a file line comment with a column, such as
+// "//line foo.gop:10:5".
+func parseLineDirective(text string) (pos sourcePos, ok bool) {
+	const prefix = "//line "
+	if !strings.HasPrefix(text, prefix) {
+		return
+	}
+	text = text[len(prefix):]
+	i := strings.LastIndexByte(text, ':')
+	if i < 0 {
+		return
+	}
+	col, err := strconv.Atoi(text[i+1:])
+	if err != nil {
+		return
+	}
+	j := strings.LastIndexByte(text[:i], ':')
+	if j < 0 {
+		return
+	}
+	line, err := strconv.Atoi(text[j+1 : i])
+	if err != nil {
+		return
+	}
+	return sourcePos{text[:j], line, col}, true
+}
+
+// -----------------------------------------------------------------------------
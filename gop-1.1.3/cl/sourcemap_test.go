@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cl_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser/parsertest"
+)
+
+func TestSourceMap(t *testing.T) {
+	const src = `import "strconv"
+
+func f() (int, error) {
+	x := strconv.Atoi("1")?
+	if x > 0 {
+		println "positive"
+	}
+	return x, nil
+}
+
+n := f()!
+println n
+`
+	fs := parsertest.NewSingleFileFS("/foo", "bar.gop", src)
+	pkgs, err := parser.ParseFSDir(gblFset, fs, "/foo", parser.Config{})
+	if err != nil {
+		t.Fatal("parser.ParseFSDir:", err)
+	}
+	ranges := cl.NewSourceRanges()
+	conf := *gblConf
+	conf.NoFileLine = false
+	conf.RelativePath = true
+	conf.TargetDir = "/foo"
+	conf.SourceMap = ranges
+	pkg, err := cl.NewPackage("", pkgs["main"], &conf)
+	if err != nil {
+		t.Fatal("NewPackage:", err)
+	}
+	var b bytes.Buffer
+	if err = pkg.WriteTo(&b); err != nil {
+		t.Fatal("gox.WriteTo failed:", err)
+	}
+	goSrc := b.Bytes()
+	m, err := ranges.SourceMap("gop_autogen.go", goSrc)
+	if err != nil {
+		t.Fatal("SourceMap:", err)
+	}
+	if m.File != "gop_autogen.go" || len(m.Sources) != 1 || m.Sources[0] != "./bar.gop" {
+		t.Fatalf("SourceMap: got file %s, sources %v", m.File, m.Sources)
+	}
+	var got []string
+	for _, mapping := range m.Mappings {
+		code := string(goSrc[mapping.Start:mapping.End])
+		if i := strings.IndexByte(code, '\n'); i >= 0 {
+			code = code[:i]
+		}
+		got = append(got, fmt.Sprintf("%d:%d-%d:%d %s", mapping.StartLine, mapping.StartCol,
+			mapping.EndLine, mapping.EndCol, code))
+	}
+	want := []string{
+		`4:2-4:25 var _autoGo_1 int`,
+		`4:2-4:25 {`,
+		`4:2-4:25 var _gop_err error`,
+		`4:2-4:25 _autoGo_1, _gop_err = strconv.Atoi("1")`,
+		`4:2-4:25 if _gop_err != nil {`,
+		`4:2-4:25 return 0, _gop_err`,
+		`4:2-4:25 goto _autoGo_2`,
+		`4:2-4:25 x := _autoGo_1`,
+		`5:2-7:3 if x > 0 {`,
+		`6:3-6:21 fmt.Println("positive")`,
+		`8:2-8:15 return x, nil`,
+		`11:1-11:10 n := func() (_gop_ret int) {`,
+		`11:1-11:10 var _gop_err error`,
+		`11:1-11:10 _gop_ret, _gop_err = f()`,
+		`11:1-11:10 if _gop_err != nil {`,
+		`11:1-11:10 panic(_gop_err)`,
+		`11:1-11:10 return`,
+		`12:1-12:10 fmt.Println(n)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("\nResult:\n%s\nExpected:\n%s\n", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
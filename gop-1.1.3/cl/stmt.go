@@ -50,6 +50,10 @@ func commentStmt(ctx *blockCtx, stmt ast.Stmt) {
 			pos.Filename = relFile(ctx.targetDir, pos.Filename)
 		}
 		line := fmt.Sprintf("\n//line %s:%d", pos.Filename, pos.Line)
+		if ctx.sourceMap != nil {
+			line = fmt.Sprintf("\n//line %s:%d:%d", pos.Filename, pos.Line, pos.Column)
+			ctx.sourceMap.add(pos, ctx.fset.Position(stmt.End()))
+		}
 		comments := &goast.CommentGroup{
 			List: []*goast.Comment{{Text: line}},
 		}
@@ -121,6 +125,7 @@ func compileStmt(ctx *blockCtx, stmt ast.Stmt) {
 		compileLabeledStmt(ctx, v)
 	case *ast.BlockStmt:
 		ctx.cb.Block()
+		ctx.recordScope(v, ctx.cb.Scope())
 		compileStmts(ctx, v.List)
 		ctx.cb.End()
 		return
@@ -206,13 +211,22 @@ func compileAssignStmt(ctx *blockCtx, expr *ast.AssignStmt) {
 	}
 	if tok == token.DEFINE {
 		names := make([]string, len(expr.Lhs))
+		idents := make([]*ast.Ident, len(expr.Lhs))
 		for i, lhs := range expr.Lhs {
 			if v, ok := lhs.(*ast.Ident); ok {
-				names[i] = v.Name
+				names[i], idents[i] = v.Name, v
 			} else {
 				log.Panicln("TODO: non-name $v on left side of :=")
 			}
 		}
+		scope := ctx.cb.Scope()
+		var old map[string]bool
+		if ctx.recording() {
+			old = make(map[string]bool)
+			for _, name := range names {
+				old[name] = scope.Lookup(name) != nil
+			}
+		}
 		ctx.cb.DefineVarStart(expr.Pos(), names...)
 		if enableRecover {
 			defer func() {
@@ -226,6 +240,7 @@ func compileAssignStmt(ctx *blockCtx, expr *ast.AssignStmt) {
 			compileExpr(ctx, rhs, inFlags)
 		}
 		ctx.cb.EndInit(len(expr.Rhs))
+		ctx.recordDefsInScope(scope, idents, old)
 		return
 	}
 	for _, lhs := range expr.Lhs {
@@ -311,6 +326,12 @@ func compileRangeStmt(ctx *blockCtx, v *ast.RangeStmt) {
 		pos = v.For
 	}
 	cb.RangeAssignThen(pos)
+	ctx.recordScope(v, cb.Scope())
+	if v.Tok == token.DEFINE {
+		key, _ := v.Key.(*ast.Ident)
+		value, _ := v.Value.(*ast.Ident)
+		ctx.recordDefsInScope(cb.Scope(), []*ast.Ident{key, value}, nil)
+	}
 	compileStmts(ctx, v.Body.List)
 	cb.SetComments(comments, once)
 	setBodyHandler(ctx)
@@ -336,6 +357,8 @@ func compileForPhraseStmt(ctx *blockCtx, v *ast.ForPhraseStmt) {
 	cb.ForRange(names...)
 	compileExpr(ctx, v.X)
 	cb.RangeAssignThen(v.TokPos)
+	ctx.recordScope(v, cb.Scope())
+	ctx.recordDefsInScope(cb.Scope(), []*ast.Ident{v.Key, v.Value}, nil)
 	if v.Cond != nil {
 		cb.If()
 		compileExpr(ctx, v.Cond)
@@ -449,6 +472,7 @@ func compileForStmt(ctx *blockCtx, v *ast.ForStmt) {
 	cb := ctx.cb
 	comments, once := cb.BackupComments()
 	cb.For()
+	ctx.recordScope(v, cb.Scope())
 	if v.Init != nil {
 		compileStmt(ctx, v.Init)
 	}
@@ -477,6 +501,7 @@ func compileIfStmt(ctx *blockCtx, v *ast.IfStmt) {
 	cb := ctx.cb
 	comments, once := cb.BackupComments()
 	cb.If()
+	ctx.recordScope(v, cb.Scope())
 	if v.Init != nil {
 		compileStmt(ctx, v.Init)
 	}
@@ -767,8 +792,9 @@ func compileDeclStmt(ctx *blockCtx, expr *ast.DeclStmt) {
 				compileType(ctx, spec.(*ast.TypeSpec))
 			}
 		case token.CONST:
-			cdecl := ctx.pkg.NewConstDecl(ctx.cb.Scope())
-			loadConstSpecs(ctx, cdecl, d.Specs)
+			scope := ctx.cb.Scope()
+			cdecl := ctx.pkg.NewConstDecl(scope)
+			loadConstSpecs(ctx, scope, cdecl, d.Specs)
 		case token.VAR:
 			for _, spec := range d.Specs {
 				v := spec.(*ast.ValueSpec)
@@ -790,6 +816,7 @@ func compileType(ctx *blockCtx, t *ast.TypeSpec) {
 	} else {
 		ctx.cb.NewType(name).InitType(ctx.pkg, toType(ctx, t.Type))
 	}
+	ctx.recordDef(t.Name, ctx.cb.Scope().Lookup(name))
 }
 
 type (
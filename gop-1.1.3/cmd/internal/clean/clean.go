@@ -31,6 +31,7 @@ const (
 	autoGenFile      = "gop_autogen.go"
 	autoGenTestFile  = "gop_autogen_test.go"
 	autoGen2TestFile = "gop_autogen2_test.go"
+	sourceMapSuffix  = ".map"
 )
 
 // -----------------------------------------------------------------------------
@@ -58,9 +59,11 @@ func cleanAGFiles(dir string) {
 	autogens := []string{autoGenFile, autoGenTestFile, autoGen2TestFile}
 	for _, autogen := range autogens {
 		file := filepath.Join(dir, autogen)
-		if _, err = os.Stat(file); err == nil {
-			fmt.Printf("Cleaning %s ...\n", file)
-			os.Remove(file)
+		for _, file := range []string{file, file + sourceMapSuffix} {
+			if _, err = os.Stat(file); err == nil {
+				fmt.Printf("Cleaning %s ...\n", file)
+				os.Remove(file)
+			}
 		}
 	}
 }
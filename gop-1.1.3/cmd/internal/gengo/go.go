@@ -32,13 +32,14 @@ import (
 
 // gop go
 var Cmd = &base.Command{
-	UsageLine: "gop go [-v] [packages]",
+	UsageLine: "gop go [-v -sourcemap] [packages]",
 	Short:     "Convert Go+ packages into Go packages",
 }
 
 var (
-	flagVerbose = flag.Bool("v", false, "print verbose information.")
-	flag        = &Cmd.Flag
+	flagVerbose   = flag.Bool("v", false, "print verbose information.")
+	flagSourceMap = flag.Bool("sourcemap", false, "write a source map next to each generated Go file.")
+	flag          = &Cmd.Flag
 )
 
 func init() {
@@ -66,12 +67,13 @@ func runCmd(cmd *base.Command, args []string) {
 		cl.SetDisableRecover(true)
 	}
 
+	conf := &gop.Config{SourceMap: *flagSourceMap}
 	for _, proj := range projs {
 		switch v := proj.(type) {
 		case *gopprojs.DirProj:
-			_, _, err = gop.GenGo(v.Dir, nil, true)
+			_, _, err = gop.GenGo(v.Dir, conf, true)
 		case *gopprojs.PkgPathProj:
-			_, _, err = gop.GenGoPkgPath("", v.Path, nil, true)
+			_, _, err = gop.GenGoPkgPath("", v.Path, conf, true)
 		default:
 			log.Panicln("`gop go` doesn't support", reflect.TypeOf(v))
 		}
@@ -72,7 +72,7 @@ func runCmd(cmd *base.Command, args []string) {
 	}
 
 	gopEnv := gopenv.Get()
-	conf := &gop.Config{Gop: gopEnv}
+	conf := &gop.Config{Gop: gopEnv, GenTestFuncs: true}
 	confCmd := &gocmd.Config{Gop: gopEnv}
 	confCmd.Flags = pass.Args
 	for _, proj := range projs {
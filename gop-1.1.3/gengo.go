@@ -17,12 +17,16 @@
 package gop
 
 import (
+	"bytes"
+	"encoding/json"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"syscall"
 
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/goplus/gox"
 	"github.com/goplus/mod/gopmod"
 	"github.com/goplus/mod/modcache"
 	"github.com/goplus/mod/modfetch"
@@ -70,7 +74,8 @@ func genGoDir(dir string, conf *Config, genTestPkg, recursively bool) (err error
 }
 
 func genGoIn(dir string, conf *Config, genTestPkg, prompt bool) (err error) {
-	out, test, err := LoadDir(dir, conf, genTestPkg, prompt)
+	ranges := newSourceRanges(conf)
+	out, test, err := loadDir(dir, conf, ranges, genTestPkg, prompt)
 	if err != nil {
 		if err == syscall.ENOENT { // no Go+ source files
 			return nil
@@ -80,20 +85,20 @@ func genGoIn(dir string, conf *Config, genTestPkg, prompt bool) (err error) {
 
 	os.MkdirAll(dir, 0755)
 	file := filepath.Join(dir, autoGenFile)
-	err = out.WriteFile(file)
+	err = writeGoFile(out, file, ranges)
 	if err != nil {
 		return errors.NewWith(err, `out.WriteFile(file)`, -2, "(*gox.Package).WriteFile", out, file)
 	}
 
 	testFile := filepath.Join(dir, autoGenTestFile)
-	err = out.WriteFile(testFile, testingGoFile)
+	err = writeGoFile(out, testFile, ranges, testingGoFile)
 	if err != nil && err != syscall.ENOENT {
 		return errors.NewWith(err, `out.WriteFile(testFile, testingGoFile)`, -2, "(*gox.Package).WriteFile", out, testFile, testingGoFile)
 	}
 
 	if test != nil {
 		testFile = filepath.Join(dir, autoGen2TestFile)
-		err = test.WriteFile(testFile, testingGoFile)
+		err = writeGoFile(test, testFile, ranges, testingGoFile)
 		if err != nil {
 			return errors.NewWith(err, `test.WriteFile(testFile, testingGoFile)`, -2, "(*gox.Package).WriteFile", test, testFile, testingGoFile)
 		}
@@ -168,19 +173,57 @@ func GenGoFiles(autogen string, files []string, conf *Config) (result []string,
 			}
 		}
 	}
-	out, err := LoadFiles(files, conf)
+	ranges := newSourceRanges(conf)
+	out, err := loadFiles(files, conf, ranges)
 	if err != nil {
 		err = errors.NewWith(err, `LoadFiles(files, conf)`, -2, "gop.LoadFiles", files, conf)
 		return
 	}
 	result = append(result, autogen)
-	err = out.WriteFile(autogen)
+	err = writeGoFile(out, autogen, ranges)
 	if err != nil {
 		err = errors.NewWith(err, `out.WriteFile(autogen)`, -2, "(*gox.Package).WriteFile", out, autogen)
 	}
 	return
 }
 
+// -----------------------------------------------------------------------------
+
+// sourceMapSuffix is the suffix of the source map of a generated Go file.
+const sourceMapSuffix = ".map"
+
+func newSourceRanges(conf *Config) *cl.SourceRanges {
+	if conf != nil && conf.SourceMap {
+		return cl.NewSourceRanges()
+	}
+	return nil
+}
+
+// writeGoFile writes the Go file fname of pkg, and its source map if ranges
+// is not nil.
+func writeGoFile(pkg *gox.Package, file string, ranges *cl.SourceRanges, fname ...string) error {
+	if ranges == nil {
+		return pkg.WriteFile(file, fname...)
+	}
+	var buf bytes.Buffer
+	if err := pkg.WriteTo(&buf, fname...); err != nil {
+		return err
+	}
+	src := buf.Bytes()
+	m, err := ranges.SourceMap(filepath.Base(file), src)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(file, src, 0666); err != nil {
+		return err
+	}
+	return os.WriteFile(file+sourceMapSuffix, data, 0666)
+}
+
 func hasMultiFiles(srcDir string, ext string) bool {
 	var has bool
 	if f, err := os.Open(srcDir); err == nil {
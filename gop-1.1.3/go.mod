@@ -10,3 +10,5 @@ require (
 	github.com/goplus/mod v0.9.12
 	github.com/qiniu/x v1.11.9
 )
+
+replace github.com/Deng-Xian-Sheng/goplus-lsp => ../
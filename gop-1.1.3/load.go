@@ -20,7 +20,9 @@ import (
 	"fmt"
 	"go/token"
 	"go/types"
+	"io"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -43,6 +45,19 @@ type Config struct {
 
 	DontUpdateGoMod     bool
 	DontCheckModChanged bool
+
+	// SourceMap = true means to write a source map next to each generated
+	// Go file, named after it with a .map suffix (see cl.SourceMap).
+	SourceMap bool
+
+	// GenTestFuncs = true means to generate a function for each test and
+	// benchmark method of the classes of the test classfiles, so that go
+	// test runs them (see cl.Config.GenTestFuncs).
+	GenTestFuncs bool
+
+	// Stdout receives the progress messages, such as "GenGo dir ...".
+	// It is os.Stdout if nil.
+	Stdout io.Writer
 }
 
 // -----------------------------------------------------------------------------
@@ -90,6 +105,10 @@ func lookupPub(mod *gopmod.Module) func(pkgPath string) (pubfile string, err err
 // -----------------------------------------------------------------------------
 
 func LoadDir(dir string, conf *Config, genTestPkg bool, promptGenGo ...bool) (out, test *gox.Package, err error) {
+	return loadDir(dir, conf, nil, genTestPkg, promptGenGo...)
+}
+
+func loadDir(dir string, conf *Config, ranges *cl.SourceRanges, genTestPkg bool, promptGenGo ...bool) (out, test *gox.Package, err error) {
 	if conf == nil {
 		conf = new(Config)
 	}
@@ -119,7 +138,11 @@ func LoadDir(dir string, conf *Config, genTestPkg bool, promptGenGo ...bool) (ou
 	}
 
 	if promptGenGo != nil && promptGenGo[0] {
-		fmt.Printf("GenGo %v ...\n", dir)
+		stdout := conf.Stdout
+		if stdout == nil {
+			stdout = os.Stdout
+		}
+		fmt.Fprintf(stdout, "GenGo %v ...\n", dir)
 	}
 
 	imp := conf.Importer
@@ -129,11 +152,13 @@ func LoadDir(dir string, conf *Config, genTestPkg bool, promptGenGo ...bool) (ou
 
 	var pkgTest *ast.Package
 	var clConf = &cl.Config{
-		WorkingDir:  dir,
-		Fset:        fset,
-		Importer:    imp,
-		LookupClass: mod.LookupClass,
-		LookupPub:   lookupPub(mod),
+		WorkingDir:   dir,
+		Fset:         fset,
+		Importer:     imp,
+		LookupClass:  mod.LookupClass,
+		LookupPub:    lookupPub(mod),
+		SourceMap:    ranges,
+		GenTestFuncs: conf.GenTestFuncs,
 	}
 	for name, pkg := range pkgs {
 		if strings.HasSuffix(name, "_test") {
@@ -166,6 +191,10 @@ func LoadDir(dir string, conf *Config, genTestPkg bool, promptGenGo ...bool) (ou
 // -----------------------------------------------------------------------------
 
 func LoadFiles(files []string, conf *Config) (out *gox.Package, err error) {
+	return loadFiles(files, conf, nil)
+}
+
+func loadFiles(files []string, conf *Config, ranges *cl.SourceRanges) (out *gox.Package, err error) {
 	if conf == nil {
 		conf = new(Config)
 	}
@@ -198,10 +227,12 @@ func LoadFiles(files []string, conf *Config) (out *gox.Package, err error) {
 			imp = NewImporter(mod, gop, fset)
 		}
 		clConf := &cl.Config{
-			Fset:        fset,
-			Importer:    imp,
-			LookupClass: mod.LookupClass,
-			LookupPub:   lookupPub(mod),
+			Fset:         fset,
+			Importer:     imp,
+			LookupClass:  mod.LookupClass,
+			LookupPub:    lookupPub(mod),
+			SourceMap:    ranges,
+			GenTestFuncs: conf.GenTestFuncs,
 		}
 		out, err = cl.NewPackage("", pkg, clConf)
 		if err != nil {
@@ -17,6 +17,7 @@
 package gop
 
 import (
+	"io"
 	"os"
 	"os/exec"
 
@@ -28,6 +29,12 @@ import (
 )
 
 func Tidy(dir string, gop *env.Gop) (err error) {
+	return TidyTo(dir, gop, os.Stdout, os.Stderr)
+}
+
+// TidyTo is like Tidy, but writes the progress messages and the output of
+// `go mod tidy` to stdout and stderr rather than to os.Stdout and os.Stderr.
+func TidyTo(dir string, gop *env.Gop, stdout, stderr io.Writer) (err error) {
 	modObj, err := gopmod.Load(dir, mod.GopModOnly)
 	if err != nil {
 		return errors.NewWith(err, `gopmod.Load(dir, mod.GopModOnly)`, -2, "gopmod.Load", dir, mod.GopModOnly)
@@ -61,7 +68,7 @@ func Tidy(dir string, gop *env.Gop) (err error) {
 		return errors.NewWith(err, `modObj.Save()`, -2, "(*gopmod.Module).Save")
 	}
 
-	conf := &Config{DontUpdateGoMod: true, Gop: gop}
+	conf := &Config{DontUpdateGoMod: true, Gop: gop, Stdout: stdout}
 	err = genGoDir(modRoot, conf, true, true)
 	if err != nil {
 		return errors.NewWith(err, `genGoDir(modRoot, conf, true, true)`, -2, "gop.genGoDir", modRoot, conf, true, true)
@@ -73,8 +80,8 @@ func Tidy(dir string, gop *env.Gop) (err error) {
 	}
 
 	cmd := exec.Command("go", "mod", "tidy")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 	cmd.Dir = modRoot
 	err = cmd.Run()
 	if err != nil {
@@ -18,14 +18,18 @@
 package types
 
 import (
+	"fmt"
 	"go/token"
 	"go/types"
+	"sort"
 	"strings"
 
 	goast "go/ast"
 
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast/togo"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	goptoken "github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
 )
 
 // A Config specifies the configuration for type checking.
@@ -36,23 +40,136 @@ type Config = types.Config
 type Package = types.Package
 
 // Load loads a package and returns the resulting package object and
-// the first error if any.
+// the first error if any. It is LoadEx without the type information.
 //
 // The package is marked as complete if no errors occurred, otherwise it is
 // incomplete. See Config.Error for controlling behavior in the presence of
 // errors.
 func Load(fset *token.FileSet, in *ast.Package, conf *Config) (pkg *Package, err error) {
-	n := len(in.Files) + len(in.GoFiles)
-	files := make([]*goast.File, 0, n)
-	for filename, f := range in.Files {
-		if !strings.HasSuffix(filename, "_test.gop") {
-			files = append(files, togo.ASTFile(f, 0))
+	pkg, _, err = LoadEx(fset, in, conf, nil)
+	return
+}
+
+// An UnsupportedError describes Go+ syntax that has no Go counterpart, and
+// that LoadEx therefore doesn't type check.
+type UnsupportedError struct {
+	Fset *token.FileSet
+	Node ast.Node
+}
+
+func (p *UnsupportedError) Error() string {
+	return fmt.Sprintf("%v: unsupported Go+ syntax %T", p.Fset.Position(p.Node.Pos()), p.Node)
+}
+
+// LoadEx loads a package, type checking function bodies unless
+// conf.IgnoreFuncBodies is set, and records type information in info, which
+// may be nil. Since info refers to the Go files that the Go+ files are
+// converted to, these are returned as well. Their nodes keep the positions
+// of the Go+ source.
+//
+// Go+ syntax that has no Go counterpart is skipped and reported as an
+// *UnsupportedError, before the errors of the type checker. As the skipped
+// syntax may use variables and imports, soft errors such as "declared and
+// not used" are dropped in the functions that contain it and in the imports
+// of its file. LoadEx doesn't stop at the first error if conf.Error is nil.
+func LoadEx(fset *token.FileSet, in *ast.Package, conf *Config, info *types.Info) (pkg *Package, files []*goast.File, err error) {
+	return loadEx(fset, in, conf, info, false)
+}
+
+// LoadTestEx is like LoadEx, but it loads the test files of the package too:
+// the _test.gop and _test.go files, and the test classfiles such as
+// Kai_test.spx. The external test package, if any, is a package of its own.
+func LoadTestEx(fset *token.FileSet, in *ast.Package, conf *Config, info *types.Info) (pkg *Package, files []*goast.File, err error) {
+	return loadEx(fset, in, conf, info, true)
+}
+
+func loadEx(fset *token.FileSet, in *ast.Package, conf *Config, info *types.Info, tests bool) (pkg *Package, files []*goast.File, err error) {
+	var skipped []posRange
+	report := func(e error) {
+		if err == nil {
+			err = e
+		}
+		if conf.Error != nil {
+			conf.Error(e)
+		}
+	}
+
+	filenames := make([]string, 0, len(in.Files))
+	for filename := range in.Files {
+		if tests || !cl.IsTestFile(filename) {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Strings(filenames)
+	mode := togo.KeepFuncBody
+	if conf.IgnoreFuncBodies { // nor report the syntax they use
+		mode = 0
+	}
+	for _, filename := range filenames {
+		f := in.Files[filename]
+		var unsupported []ast.Node
+		files = append(files, togo.ASTFileEx(f, mode, func(node ast.Node) {
+			unsupported = append(unsupported, node)
+		}))
+		for _, node := range unsupported {
+			report(&UnsupportedError{Fset: fset, Node: node})
+		}
+		skipped = appendSkipped(skipped, f, unsupported)
+	}
+	filenames = filenames[:0]
+	for filename := range in.GoFiles {
+		if tests || !strings.HasSuffix(filename, "_test.go") {
+			filenames = append(filenames, filename)
 		}
 	}
-	for filename, f := range in.GoFiles {
-		if !strings.HasSuffix(filename, "_test.go") {
-			files = append(files, f)
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		files = append(files, in.GoFiles[filename])
+	}
+
+	check := *conf
+	check.Error = func(e error) {
+		if te, ok := e.(types.Error); ok && te.Soft && inRanges(skipped, te.Pos) {
+			return
+		}
+		report(e)
+	}
+	pkg = types.NewPackage(in.Name, in.Name)
+	_ = types.NewChecker(&check, fset, pkg, info).Files(files) // errors are reported via check.Error
+	return
+}
+
+type posRange struct {
+	from, to token.Pos
+}
+
+func inRanges(ranges []posRange, pos token.Pos) bool {
+	for _, r := range ranges {
+		if r.from <= pos && pos < r.to {
+			return true
+		}
+	}
+	return false
+}
+
+// appendSkipped appends the source ranges of f in which soft errors are
+// dropped because of unsupported nodes: the declarations that contain them,
+// and the import declarations of f.
+func appendSkipped(ranges []posRange, f *ast.File, unsupported []ast.Node) []posRange {
+	if len(unsupported) == 0 {
+		return ranges
+	}
+	for _, decl := range f.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == goptoken.IMPORT {
+			ranges = append(ranges, posRange{d.Pos(), d.End()})
+			continue
+		}
+		for _, node := range unsupported {
+			if decl.Pos() <= node.Pos() && node.End() <= decl.End() {
+				ranges = append(ranges, posRange{decl.Pos(), decl.End()})
+				break
+			}
 		}
 	}
-	return conf.Check(in.Name, fset, files, nil)
+	return ranges
 }
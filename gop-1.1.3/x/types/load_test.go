@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	goast "go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+)
+
+func TestLoadEx(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.gop", `package foo
+
+import "strings"
+
+func Upper(s string) string {
+	up := strings.ToUpper(s)
+	return up
+}
+
+func Double(v []int) []int {
+	n := 2
+	return [x*n for x <- v]
+}
+
+func Bad() {
+	var x int = "x"
+	_ = x
+}
+`, 0)
+	if err != nil {
+		t.Fatal("parser.ParseFile:", err)
+	}
+	in := &ast.Package{
+		Name:    "foo",
+		Files:   map[string]*ast.File{"foo.gop": f},
+		GoFiles: map[string]*goast.File{},
+	}
+
+	var errs []error
+	conf := &Config{
+		Importer: importer.Default(),
+		Error: func(err error) {
+			errs = append(errs, err)
+		},
+	}
+	info := &types.Info{
+		Defs: make(map[*goast.Ident]types.Object),
+		Uses: make(map[*goast.Ident]types.Object),
+	}
+	pkg, files, err := LoadEx(fset, in, conf, info)
+	if len(files) != 1 {
+		t.Fatal("LoadEx: files =", len(files))
+	}
+	if pkg.Scope().Lookup("Upper") == nil || pkg.Scope().Lookup("Double") == nil {
+		t.Fatal("LoadEx: missing declarations")
+	}
+
+	// The comprehension is unsupported, and the resulting "n declared and
+	// not used" error is dropped. The error in Bad is reported.
+	if len(errs) != 2 || err != errs[0] {
+		t.Fatal("LoadEx: errors =", errs, err)
+	}
+	if e, ok := errs[0].(*UnsupportedError); !ok {
+		t.Fatal("LoadEx: unexpected error", errs[0])
+	} else if _, ok := e.Node.(*ast.ComprehensionExpr); !ok {
+		t.Fatalf("LoadEx: unsupported %T", e.Node)
+	} else if msg := e.Error(); msg != "foo.gop:12:9: unsupported Go+ syntax *ast.ComprehensionExpr" {
+		t.Fatal("UnsupportedError:", msg)
+	}
+	if e, ok := errs[1].(types.Error); !ok || fset.Position(e.Pos).Line != 16 {
+		t.Fatal("LoadEx: unexpected error", errs[1])
+	}
+
+	// Function bodies are type checked.
+	var up types.Object
+	for id, obj := range info.Defs {
+		if id.Name == "up" {
+			up = obj
+		}
+	}
+	if up == nil || up.Type().String() != "string" {
+		t.Fatal("LoadEx: up =", up)
+	}
+	if pos := fset.Position(up.Pos()); pos.Line != 6 || pos.Column != 2 {
+		t.Fatal("LoadEx: up at", pos)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.gop", `package foo
+
+func Double(v []int) []int {
+	return [x*2 for x <- v]
+}
+`, 0)
+	if err != nil {
+		t.Fatal("parser.ParseFile:", err)
+	}
+	in := &ast.Package{
+		Name:    "foo",
+		Files:   map[string]*ast.File{"foo.gop": f},
+		GoFiles: map[string]*goast.File{},
+	}
+
+	// Without function bodies, there is no unsupported syntax.
+	conf := &Config{Importer: importer.Default(), IgnoreFuncBodies: true}
+	pkg, err := Load(fset, in, conf)
+	if err != nil || pkg.Scope().Lookup("Double") == nil {
+		t.Fatal("Load:", err)
+	}
+	conf = &Config{Importer: importer.Default()}
+	if _, err = Load(fset, in, conf); err == nil {
+		t.Fatal("Load: no error")
+	} else if _, ok := err.(*UnsupportedError); !ok {
+		t.Fatal("Load: unexpected error", err)
+	}
+}
+
+func TestLoadTestEx(t *testing.T) {
+	fset := token.NewFileSet()
+	in := &ast.Package{
+		Name:    "foo",
+		Files:   make(map[string]*ast.File),
+		GoFiles: map[string]*goast.File{},
+	}
+	for filename, src := range map[string]string{
+		"foo.gop": "package foo\n\nfunc Upper(s string) string {\n\treturn s\n}\n",
+		"foo_test.gop": `package foo
+
+import "testing"
+
+func TestUpper(t *testing.T) {
+	if Upper("a") != "A" {
+		t.Fatal("Upper failed")
+	}
+}
+`,
+	} {
+		f, err := parser.ParseFile(fset, filename, src, 0)
+		if err != nil {
+			t.Fatal("parser.ParseFile:", err)
+		}
+		in.Files[filename] = f
+	}
+
+	conf := &Config{Importer: importer.Default()}
+	pkg, files, err := LoadEx(fset, in, conf, nil)
+	if err != nil || len(files) != 1 || pkg.Scope().Lookup("TestUpper") != nil {
+		t.Fatal("LoadEx:", len(files), err)
+	}
+	pkg, files, err = LoadTestEx(fset, in, conf, nil)
+	if err != nil || len(files) != 2 || pkg.Scope().Lookup("TestUpper") == nil {
+		t.Fatal("LoadTestEx:", len(files), err)
+	}
+}
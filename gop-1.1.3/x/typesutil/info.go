@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package typesutil provides type information about Go+ syntax trees, as
+// resolved by the Go+ compiler.
+package typesutil
+
+import (
+	"go/types"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+)
+
+// -----------------------------------------------------------------------------
+
+// Info holds result type information for the Go+ files of a package. It is
+// the counterpart of types.Info, keyed by gop/ast nodes. Only the maps that
+// are not nil are populated.
+type Info struct {
+	// Types maps expressions to their types, and for constant
+	// expressions, also their values.
+	Types map[ast.Expr]types.TypeAndValue
+
+	// Defs maps identifiers to the objects they define.
+	Defs map[*ast.Ident]types.Object
+
+	// Uses maps identifiers to the objects they denote.
+	Uses map[*ast.Ident]types.Object
+
+	// Implicits maps nodes to their implicitly declared objects, if any.
+	Implicits map[ast.Node]types.Object
+
+	// Selections maps selector expressions (excluding qualified identifiers)
+	// to their corresponding selections.
+	Selections map[*ast.SelectorExpr]*cl.Selection
+
+	// Scopes maps ast.Nodes to the scopes they define.
+	Scopes map[ast.Node]*types.Scope
+}
+
+// NewInfo returns an Info with all maps allocated.
+func NewInfo() *Info {
+	return &Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*cl.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+}
+
+// TypeOf returns the type of expression e, or nil if not found.
+func (info *Info) TypeOf(e ast.Expr) types.Type {
+	if t, ok := info.Types[e]; ok {
+		return t.Type
+	}
+	if id, _ := e.(*ast.Ident); id != nil {
+		if obj := info.ObjectOf(id); obj != nil {
+			return obj.Type()
+		}
+	}
+	return nil
+}
+
+// ObjectOf returns the object denoted by the specified id, or nil if not
+// found.
+func (info *Info) ObjectOf(id *ast.Ident) types.Object {
+	if obj := info.Defs[id]; obj != nil {
+		return obj
+	}
+	return info.Uses[id]
+}
+
+// -----------------------------------------------------------------------------
+
+// NewRecorder returns a cl.Recorder that records into info. Pass it as
+// cl.Config.Recorder to collect type information while compiling a package.
+func NewRecorder(info *Info) cl.Recorder {
+	return recorder{info}
+}
+
+type recorder struct {
+	*Info
+}
+
+func (p recorder) Type(e ast.Expr, tv types.TypeAndValue) {
+	if m := p.Types; m != nil {
+		m[e] = tv
+	}
+}
+
+func (p recorder) Def(id *ast.Ident, obj types.Object) {
+	if m := p.Defs; m != nil {
+		m[id] = obj
+	}
+}
+
+func (p recorder) Use(id *ast.Ident, obj types.Object) {
+	if m := p.Uses; m != nil {
+		m[id] = obj
+	}
+}
+
+func (p recorder) Implicit(node ast.Node, obj types.Object) {
+	if m := p.Implicits; m != nil {
+		m[node] = obj
+	}
+}
+
+func (p recorder) Select(e *ast.SelectorExpr, sel *cl.Selection) {
+	if m := p.Selections; m != nil {
+		m[e] = sel
+	}
+}
+
+func (p recorder) Scope(n ast.Node, scope *types.Scope) {
+	if m := p.Scopes; m != nil {
+		m[n] = scope
+	}
+}
+
+// -----------------------------------------------------------------------------
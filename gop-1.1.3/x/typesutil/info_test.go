@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2023 The GoPlus Authors (goplus.org). All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package typesutil_test
+
+import (
+	"go/types"
+	"sort"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser/parsertest"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/goplus/mod/env"
+)
+
+func checkInfo(t *testing.T, src string) *typesutil.Info {
+	fset := token.NewFileSet()
+	fs := parsertest.NewSingleFileFS("/foo", "bar.gop", src)
+	pkgs, err := parser.ParseFSDir(fset, fs, "/foo", parser.Config{Mode: parser.ParseComments})
+	if err != nil {
+		t.Fatal("ParseFSDir:", err)
+	}
+	pkg := pkgs["main"]
+	info := typesutil.NewInfo()
+	conf := &cl.Config{
+		Fset:       fset,
+		Importer:   gop.NewImporter(nil, &env.Gop{Root: "../..", Version: "1.0"}, fset),
+		NoFileLine: true,
+		Recorder:   typesutil.NewRecorder(info),
+	}
+	if _, err := cl.NewPackage("main", pkg, conf); err != nil {
+		t.Fatal("NewPackage:", err)
+	}
+	return info
+}
+
+// lookupIdents returns the resolved identifiers named name, in source order.
+func lookupIdents(info *typesutil.Info, name string) []*ast.Ident {
+	var idents []*ast.Ident
+	for _, m := range []map[*ast.Ident]types.Object{info.Defs, info.Uses} {
+		for id := range m {
+			if id.Name == name {
+				idents = append(idents, id)
+			}
+		}
+	}
+	sort.Slice(idents, func(i, j int) bool {
+		return idents[i].Pos() < idents[j].Pos()
+	})
+	return idents
+}
+
+func TestInfo(t *testing.T) {
+	info := checkInfo(t, `import "strings"
+
+type T struct {
+	N int
+}
+
+func (t *T) Get() int {
+	return t.N
+}
+
+x := [v * 2 for v <- [1, 2, 3]]
+t := &T{N: 1}
+println strings.ToUpper("a"), t.Get(), x
+`)
+
+	defs := make(map[string]types.Object)
+	for id, obj := range info.Defs {
+		defs[id.Name] = obj
+	}
+	for _, name := range []string{"T", "N", "x", "v", "Get"} {
+		def := defs[name]
+		if def == nil {
+			t.Errorf("no definition recorded for %s", name)
+			continue
+		}
+		ids := lookupIdents(info, name)
+		if len(ids) < 2 {
+			t.Errorf("no use recorded for %s", name)
+		}
+		for _, id := range ids {
+			if obj := info.ObjectOf(id); obj != def {
+				t.Errorf("%s at %d: got object %v, want %v", name, id.Pos(), obj, def)
+			}
+		}
+	}
+
+	strs := lookupIdents(info, "strings")[0]
+	if _, ok := info.Uses[strs].(*types.PkgName); !ok {
+		t.Errorf("strings: got %v, want a package name", info.Uses[strs])
+	}
+	if fn, ok := info.Uses[lookupIdents(info, "ToUpper")[0]].(*types.Func); !ok || fn.Pkg().Path() != "strings" {
+		t.Errorf("ToUpper: got %v, want strings.ToUpper", fn)
+	}
+
+	var sels int
+	for e, sel := range info.Selections {
+		switch e.Sel.Name {
+		case "Get":
+			if sel.Kind != types.MethodVal || sel.Obj != defs["Get"] {
+				t.Errorf("t.Get: got selection %v of %v", sel.Kind, sel.Obj)
+			}
+		case "N":
+			if sel.Kind != types.FieldVal || !sel.Indirect {
+				t.Errorf("t.N: got selection %v, indirect %t", sel.Kind, sel.Indirect)
+			}
+		}
+		sels++
+	}
+	if sels != 2 {
+		t.Errorf("got %d selections, want 2", sels)
+	}
+
+	var elt ast.Expr
+	for e := range info.Types {
+		if e, ok := e.(*ast.BinaryExpr); ok && e.Op == token.MUL {
+			elt = e
+		}
+	}
+	if typ := info.TypeOf(elt); typ == nil || typ.String() != "int" {
+		t.Errorf("v * 2: got type %v, want int", typ)
+	}
+}
@@ -488,6 +488,18 @@ func loadLenses(commands []*source.CommandJSON) []*source.LensJSON {
 		}
 		all[k] = struct{}{}
 	}
+	for k := range mod.GopModLensFuncs() {
+		if _, ok := all[k]; ok {
+			panic(fmt.Sprintf("duplicate lens %q", string(k)))
+		}
+		all[k] = struct{}{}
+	}
+	for k := range source.GopLensFuncs() {
+		if _, ok := all[k]; ok {
+			panic(fmt.Sprintf("duplicate lens %q", string(k)))
+		}
+		all[k] = struct{}{}
+	}
 
 	var lenses []*source.LensJSON
 
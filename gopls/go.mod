@@ -3,15 +3,19 @@ module github.com/Deng-Xian-Sheng/goplus-lsp/gopls
 go 1.20
 
 require (
-	github.com/Deng-Xian-Sheng/goplus-lsp v0.0.0-20230405141037-eeee04c42cb1
+	github.com/Deng-Xian-Sheng/goplus-lsp v0.0.0-20230409101611-9761a93ccf26
+	github.com/Deng-Xian-Sheng/goplus-lsp/gop v0.0.0-00010101000000-000000000000
 	github.com/google/go-cmp v0.5.9
+	github.com/goplus/gox v1.11.21
+	github.com/goplus/mod v0.9.12
 	github.com/jba/printsrc v0.2.2
 	github.com/jba/templatecheck v0.6.0
+	github.com/qiniu/x v1.11.9
 	github.com/sergi/go-diff v1.1.0
-	golang.org/x/mod v0.7.0
+	golang.org/x/mod v0.10.0
 	golang.org/x/sync v0.1.0
-	golang.org/x/sys v0.2.0
-	golang.org/x/text v0.4.0
+	golang.org/x/sys v0.7.0
+	golang.org/x/text v0.8.0
 	golang.org/x/vuln v0.0.0-20221109205719-3af8368ee4fe
 	gopkg.in/yaml.v3 v3.0.1
 	honnef.co/go/tools v0.3.3
@@ -25,5 +29,10 @@ require (
 	github.com/BurntSushi/toml v1.2.1 // indirect
 	github.com/google/safehtml v0.1.0 // indirect
 	golang.org/x/exp/typeparams v0.0.0-20221031165847-c99f073a8326 // indirect
-	golang.org/x/tools v0.2.1-0.20221108172846-9474ca31d0df // indirect
+	golang.org/x/tools v0.6.0 // indirect
+)
+
+replace (
+	github.com/Deng-Xian-Sheng/goplus-lsp => ../
+	github.com/Deng-Xian-Sheng/goplus-lsp/gop => ../gop-1.1.3
 )
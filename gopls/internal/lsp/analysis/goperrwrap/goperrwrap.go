@@ -0,0 +1,152 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package goperrwrap defines an Analyzer that checks the use of the error
+// wrapping expressions of Go+: expr!, expr? and expr?:default.
+package goperrwrap
+
+import (
+	"go/types"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/gopsyntax"
+)
+
+const Doc = `check the use of Go+ error wrapping expressions
+
+This analyzer reports the uses of expr? in functions whose last result
+is not an error, where there is no error to return:
+
+	func f(s string) int {
+		return strconv.Atoi(s)?
+	}
+
+the uses of expr! in packages other than main, outside of tests: it
+panics if expr fails, which a library should leave to its callers to
+decide; and the default values of expr?:default that can't be used as the
+value of expr:
+
+	n := strconv.Atoi(s)?:"0"
+`
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "goperrwrap",
+	Doc:              Doc,
+	Requires:         []*analysis.Analyzer{gopsyntax.Analyzer},
+	Run:              run,
+	RunDespiteErrors: true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	pkg := pass.ResultOf[gopsyntax.Analyzer].(*gopsyntax.Package)
+	// Classfiles have no package clause, so ask the other files.
+	lib := false
+	for _, f := range pkg.Files {
+		if f.Name != nil && f.Name.Name != "main" {
+			lib = true
+		}
+	}
+	for _, f := range pkg.Files {
+		var stack []ast.Node
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			stack = append(stack, n)
+			e, ok := n.(*ast.ErrWrapExpr)
+			if !ok {
+				return true
+			}
+			switch {
+			case e.Tok == token.NOT:
+				if lib && !cl.IsTestFile(pkg.Fset.Position(e.TokPos).Filename) {
+					pass.Reportf(e.TokPos, "! panics on error: a library should return the error instead")
+				}
+			case e.Default == nil:
+				if fn := enclosingFunc(stack); fn != nil && !returnsError(pkg.TypesInfo, fn) {
+					pass.Reportf(e.TokPos, "? used in a function with no error result")
+				}
+			default:
+				checkDefault(pass, pkg.TypesInfo, e)
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// enclosingFunc returns the innermost function of stack, not counting the
+// node at its top.
+func enclosingFunc(stack []ast.Node) ast.Node {
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit, *ast.LambdaExpr, *ast.LambdaExpr2:
+			return n
+		}
+	}
+	return nil
+}
+
+// returnsError reports whether the last result of the function fn is an
+// error. Without type information, it relies on the syntax of the result
+// types, and assumes lambdas return errors.
+func returnsError(info *typesutil.Info, fn ast.Node) bool {
+	var ftype *ast.FuncType
+	var typ types.Type
+	switch fn := fn.(type) {
+	case *ast.FuncDecl:
+		ftype = fn.Type
+		if info != nil {
+			if obj := info.Defs[fn.Name]; obj != nil {
+				typ = obj.Type()
+			}
+		}
+	case *ast.FuncLit:
+		ftype = fn.Type
+		if info != nil {
+			typ = info.TypeOf(fn)
+		}
+	case ast.Expr: // a lambda
+		if info != nil {
+			typ = info.TypeOf(fn)
+		}
+	}
+	if sig, ok := typ.(*types.Signature); ok {
+		results := sig.Results()
+		return results.Len() > 0 && types.Identical(results.At(results.Len()-1).Type(), errorType)
+	}
+	if ftype == nil {
+		return true
+	}
+	if ftype.Results == nil || len(ftype.Results.List) == 0 {
+		return false
+	}
+	last, ok := ftype.Results.List[len(ftype.Results.List)-1].Type.(*ast.Ident)
+	return !ok || last.Name == "error"
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// checkDefault reports the default value of e if it can't be used as the
+// value of e.X.
+func checkDefault(pass *analysis.Pass, info *typesutil.Info, e *ast.ErrWrapExpr) {
+	if info == nil {
+		return
+	}
+	results, ok := info.TypeOf(e.X).(*types.Tuple)
+	if !ok || results.Len() != 2 {
+		return
+	}
+	want := results.At(0).Type()
+	got := info.TypeOf(e.Default)
+	if got == nil || got == types.Typ[types.Invalid] || types.AssignableTo(got, want) {
+		return
+	}
+	pass.ReportRangef(e.Default, "cannot use %s value as the default of a %s value", got, want)
+}
@@ -0,0 +1,18 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goperrwrap_test
+
+import (
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis/analysistest"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/goperrwrap"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/goptest"
+)
+
+func Test(t *testing.T) {
+	testdata := analysistest.TestData()
+	goptest.RunAnalyzer(t, testdata, goperrwrap.Analyzer, "a", "b", "c", "script")
+}
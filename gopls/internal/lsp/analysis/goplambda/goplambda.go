@@ -0,0 +1,146 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package goplambda defines an Analyzer that checks for calls of Go+
+// lambdas whose results are ignored.
+package goplambda
+
+import (
+	"go/types"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/gopsyntax"
+)
+
+const Doc = `check for Go+ lambdas whose results are ignored
+
+A lambda with an expression body, such as x => x * 2, does nothing but
+compute its results. This analyzer reports the calls of variables bound
+to such lambdas whose results are ignored:
+
+	double := func(int) int(x => x * 2)
+	double 3 // result of lambda double is ignored
+`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "goplambda",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{gopsyntax.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	pkg := pass.ResultOf[gopsyntax.Analyzer].(*gopsyntax.Package)
+	info := pkg.TypesInfo
+	if info == nil {
+		return nil, nil
+	}
+
+	// The variables only ever bound to lambdas with expression bodies.
+	lambdas := make(map[types.Object]*ast.LambdaExpr)
+	others := make(map[types.Object]bool)
+	bind := func(lhs ast.Expr, rhs ast.Expr) {
+		id, ok := lhs.(*ast.Ident)
+		if !ok {
+			return
+		}
+		obj := info.ObjectOf(id)
+		if obj == nil || others[obj] {
+			return
+		}
+		if lambda := lambdaOf(info, rhs); lambda != nil {
+			lambdas[obj] = lambda
+		} else {
+			others[obj] = true
+			delete(lambdas, obj)
+		}
+	}
+	for _, f := range pkg.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				for i, lhs := range n.Lhs {
+					var rhs ast.Expr
+					if len(n.Lhs) == len(n.Rhs) {
+						rhs = n.Rhs[i]
+					}
+					bind(lhs, rhs)
+				}
+			case *ast.ValueSpec:
+				for i, name := range n.Names {
+					var rhs ast.Expr
+					if len(n.Names) == len(n.Values) {
+						rhs = n.Values[i]
+					}
+					bind(name, rhs)
+				}
+			}
+			return true
+		})
+	}
+	if len(lambdas) == 0 {
+		return nil, nil
+	}
+
+	for _, f := range pkg.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			var call *ast.CallExpr
+			switch n := n.(type) {
+			case *ast.ExprStmt:
+				call, _ = n.X.(*ast.CallExpr)
+			case *ast.GoStmt:
+				call = n.Call
+			case *ast.DeferStmt:
+				call = n.Call
+			}
+			if call == nil {
+				return true
+			}
+			id, ok := unparen(call.Fun).(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if lambda := lambdas[info.ObjectOf(id)]; lambda != nil {
+				pass.Report(analysis.Diagnostic{
+					Pos:     call.Pos(),
+					End:     call.End(),
+					Message: "result of lambda " + id.Name + " is ignored",
+					Related: []analysis.RelatedInformation{{
+						Pos:     lambda.Pos(),
+						End:     lambda.End(),
+						Message: "lambda " + id.Name,
+					}},
+				})
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// lambdaOf returns the lambda with an expression body that e is, or
+// converts to a function type, or nil.
+func lambdaOf(info *typesutil.Info, e ast.Expr) *ast.LambdaExpr {
+	e = unparen(e)
+	if call, ok := e.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		_, conv := unparen(call.Fun).(*ast.FuncType)
+		if tv, ok := info.Types[call.Fun]; conv || ok && tv.IsType() {
+			e = unparen(call.Args[0])
+		}
+	}
+	lambda, _ := e.(*ast.LambdaExpr)
+	return lambda
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gopsyntax defines an Analyzer that provides the syntax trees and
+// type information of the Go+ files of a package to other analyzers.
+package gopsyntax
+
+import (
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+)
+
+const Doc = `provide the Go+ files of a package to other analyzers
+
+The result of this analyzer is supplied by drivers that know about Go+
+files, such as gopls. Elsewhere, the package has no Go+ files.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "gopsyntax",
+	Doc:              Doc,
+	Run:              run,
+	RunDespiteErrors: true,
+	ResultType:       reflect.TypeOf(new(Package)),
+}
+
+// A Package holds the Go+ files of a package, and the type information
+// recorded by the Go+ compiler. Types and TypesInfo are nil if the files
+// weren't type-checked. Positions are those of Fset, which is also the
+// FileSet of the pass.
+type Package struct {
+	Fset      *token.FileSet
+	Files     []*ast.File
+	Types     *types.Package
+	TypesInfo *typesutil.Info
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	return &Package{Fset: pass.Fset}, nil
+}
@@ -14,6 +14,7 @@ import (
 	"sync"
 
 	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/gopsyntax"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/bug"
@@ -80,6 +81,8 @@ type actionData struct {
 	pkgTypes     *types.Package // types only; don't keep syntax live
 	diagnostics  []*source.Diagnostic
 	result       interface{}
+	gopDone      bool        // whether the analysis ran on the Go+ files
+	gopResult    interface{} // the result on the Go+ files
 	objectFacts  map[objectFactKey]analysis.Fact
 	packageFacts map[packageFactKey]analysis.Fact
 }
@@ -208,6 +211,7 @@ func actionImpl(ctx context.Context, snapshot *snapshot, deps []*actionHandle, a
 	var (
 		mu           sync.Mutex
 		inputs       = make(map[*analysis.Analyzer]interface{})
+		gopInputs    = make(map[*analysis.Analyzer]interface{})
 		objectFacts  = make(map[objectFactKey]analysis.Fact)
 		packageFacts = make(map[packageFactKey]analysis.Fact)
 	)
@@ -232,6 +236,9 @@ func actionImpl(ctx context.Context, snapshot *snapshot, deps []*actionHandle, a
 				// in-memory outputs of prerequisite analyzers
 				// become inputs to this analysis pass.
 				inputs[data.analyzer] = data.result
+				if data.gopDone {
+					gopInputs[data.analyzer] = data.gopResult
+				}
 
 			} else if data.analyzer == analyzer {
 				// Same analysis, different package (vertical edge):
@@ -277,6 +284,24 @@ func actionImpl(ctx context.Context, snapshot *snapshot, deps []*actionHandle, a
 		return nil, err // cancelled, or dependency failed
 	}
 
+	// The Go code compiled from the Go+ files imports the types of the
+	// same dependencies, so their facts apply to it too; save them before
+	// the pass on the Go files adds its own.
+	var (
+		gopObjectFacts  map[objectFactKey]analysis.Fact
+		gopPackageFacts map[packageFactKey]analysis.Fact
+	)
+	if pkg.gopCompiled != nil {
+		gopObjectFacts = make(map[objectFactKey]analysis.Fact, len(objectFacts))
+		for key, fact := range objectFacts {
+			gopObjectFacts[key] = fact
+		}
+		gopPackageFacts = make(map[packageFactKey]analysis.Fact, len(packageFacts))
+		for key, fact := range packageFacts {
+			gopPackageFacts[key] = fact
+		}
+	}
+
 	// Now run the (pkg, analyzer) analysis.
 	var syntax []*ast.File
 	for _, cgf := range pkg.compiledGoFiles {
@@ -381,6 +406,10 @@ func actionImpl(ctx context.Context, snapshot *snapshot, deps []*actionHandle, a
 				}
 			}
 		}()
+		if analyzer == gopsyntax.Analyzer {
+			result = gopSyntaxPackage(pkg) // the driver knows the Go+ files
+			return
+		}
 		result, err = pass.Analyzer.Run(pass)
 	}()
 	if err != nil {
@@ -420,11 +449,47 @@ func actionImpl(ctx context.Context, snapshot *snapshot, deps []*actionHandle, a
 		}
 		diagnostics = append(diagnostics, srcDiags...)
 	}
+
+	// Run the analysis on the Go code compiled from the Go+ files too,
+	// unless it failed for an analyzer this one requires.
+	var (
+		gopDone   bool
+		gopResult interface{}
+	)
+	if pkg.gopCompiled != nil && len(gopInputs) == len(analyzer.Requires) {
+		result, rawDiagnostics, err := gopActionImpl(pkg, analyzer, gopInputs, gopObjectFacts, gopPackageFacts)
+		if err != nil {
+			event.Error(ctx, "Go+ analysis failed", err, tag.Package.Of(string(pkg.ID())))
+		} else {
+			gopDone, gopResult = true, result
+		}
+		for _, diag := range rawDiagnostics {
+			srcDiags, err := analysisDiagnosticDiagnostics(snapshot, pkg, analyzer, &diag)
+			if err != nil {
+				event.Error(ctx, "unable to compute analysis error position", err, tag.Category.Of(diag.Category), tag.Package.Of(string(pkg.ID())))
+				continue
+			}
+			for _, diag := range srcDiags {
+				// Fixes computed by commands only apply to Go files.
+				var fixes []source.SuggestedFix
+				for _, fix := range diag.SuggestedFixes {
+					if fix.Command == nil {
+						fixes = append(fixes, fix)
+					}
+				}
+				diag.SuggestedFixes = fixes
+			}
+			diagnostics = append(diagnostics, srcDiags...)
+		}
+	}
+
 	return &actionData{
 		analyzer:     analyzer,
 		pkgTypes:     pkg.types,
 		diagnostics:  diagnostics,
 		result:       result,
+		gopDone:      gopDone,
+		gopResult:    gopResult,
 		objectFacts:  objectFacts,
 		packageFacts: packageFacts,
 	}, nil
@@ -0,0 +1,444 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis"
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/gopsyntax"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/goplus/gox"
+)
+
+// A gopCompiledPackage holds the Go code that cl compiled from the Go+ files
+// of a package, so that the analyzers can run on it (see gopActionImpl).
+//
+// The Go code is printed, parsed and type-checked together with the Go
+// files of the package on first use. Its file line comments record the Go+
+// statements it was compiled from, and the source maps built from them map
+// the findings of the analyzers back to the Go+ files.
+type gopCompiledPackage struct {
+	fset   *token.FileSet
+	dir    string
+	out    *gox.Package
+	ranges *cl.SourceRanges
+	imp    types.Importer
+	sizes  types.Sizes
+
+	printOnce sync.Once
+	printed   map[string][]byte // by the name of the Go file, as written by the gop command
+	printErr  error
+
+	once       sync.Once
+	files      []*ast.File // the Go files of the package, then the generated ones
+	generated  map[*token.File]*gopGeneratedFile
+	types      *types.Package
+	typesInfo  *types.Info
+	typeErrors []types.Error
+	err        error
+}
+
+// A gopGeneratedFile is a Go file printed from the output of cl.
+type gopGeneratedFile struct {
+	file *ast.File
+	tok  *token.File
+	src  []byte
+	m    *cl.SourceMap
+}
+
+// gopGeneratedFiles are the names of the files of the output of cl, and of
+// the Go files they are printed to, as written by the gop command.
+var gopGeneratedFiles = []struct{ fname, filename string }{
+	{"", "gop_autogen.go"},
+	{"_test", "gop_autogen_test.go"},
+}
+
+// print prints the Go code compiled from the Go+ files, by the names of the
+// Go files that the gop command writes it to. Files with no code, such as
+// gop_autogen_test.go of a package without tests, are left out.
+func (p *gopCompiledPackage) print() (map[string][]byte, error) {
+	p.printOnce.Do(func() {
+		p.printed = make(map[string][]byte)
+		for _, gen := range gopGeneratedFiles {
+			var buf bytes.Buffer
+			if err := p.out.WriteTo(&buf, gen.fname); err != nil {
+				if err == syscall.ENOENT { // no such file
+					continue
+				}
+				p.printErr = err
+				return
+			}
+			p.printed[gen.filename] = buf.Bytes()
+		}
+	})
+	return p.printed, p.printErr
+}
+
+// load prints, parses and type-checks the Go code compiled from the Go+
+// files, together with goFiles.
+func (p *gopCompiledPackage) load(goFiles []*ast.File) error {
+	p.once.Do(func() {
+		printed, err := p.print()
+		if err != nil {
+			p.err = err
+			return
+		}
+		p.files = append(p.files, goFiles...)
+		p.generated = make(map[*token.File]*gopGeneratedFile)
+		for _, gen := range gopGeneratedFiles {
+			src, ok := printed[gen.filename]
+			if !ok {
+				continue
+			}
+			filename := filepath.Join(p.dir, gen.filename)
+			m, err := p.ranges.SourceMap(filename, src)
+			if err != nil {
+				p.err = err
+				return
+			}
+			f, err := parser.ParseFile(p.fset, filename, src, parser.ParseComments)
+			if err != nil {
+				p.err = err
+				return
+			}
+			p.files = append(p.files, f)
+			p.generated[p.fset.File(f.Pos())] = &gopGeneratedFile{file: f, tok: p.fset.File(f.Pos()), src: src, m: m}
+		}
+
+		p.types = types.NewPackage(p.out.Types.Path(), p.out.Types.Name())
+		p.typesInfo = &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+			Scopes:     make(map[ast.Node]*types.Scope),
+		}
+		cfg := &types.Config{
+			Error: func(e error) {
+				p.typeErrors = append(p.typeErrors, e.(types.Error))
+			},
+			Importer: p.imp,
+			Sizes:    p.sizes,
+		}
+		// Type checking errors are handled via the config, so ignore them here.
+		_ = types.NewChecker(cfg, p.fset, p.types, p.typesInfo).Files(p.files)
+	})
+	return p.err
+}
+
+// gopActionImpl runs analyzer on the Go code compiled from the Go+ files of
+// pkg. Inputs holds the results of the analyzers it requires, on the same
+// code, and objectFacts and packageFacts the facts of analyzer on the
+// dependencies of pkg, which it imports. Only the findings in the compiled
+// code are reported, at the positions of the Go+ code they were compiled
+// from; see gopDiagnostic.
+func gopActionImpl(pkg *pkg, analyzer *analysis.Analyzer, inputs map[*analysis.Analyzer]interface{}, objectFacts map[objectFactKey]analysis.Fact, packageFacts map[packageFactKey]analysis.Fact) (interface{}, []analysis.Diagnostic, error) {
+	p := pkg.gopCompiled
+	var goFiles []*ast.File
+	for _, pgf := range pkg.compiledGoFiles {
+		// The generated Go code duplicates the declarations of the Go+ files.
+		if !source.IsGopAutogenFile(pgf.URI.Filename()) {
+			goFiles = append(goFiles, pgf.File)
+		}
+	}
+	if err := p.load(goFiles); err != nil {
+		return nil, nil, err
+	}
+	if len(p.typeErrors) > 0 && !analyzer.RunDespiteErrors {
+		return nil, nil, fmt.Errorf("skipping analysis %s because the Go code compiled from the Go+ files of package %s contains errors", analyzer.Name, pkg.ID())
+	}
+
+	var diagnostics []analysis.Diagnostic
+	if objectFacts == nil {
+		objectFacts = make(map[objectFactKey]analysis.Fact)
+	}
+	if packageFacts == nil {
+		packageFacts = make(map[packageFactKey]analysis.Fact)
+	}
+	pass := &analysis.Pass{
+		Analyzer:   analyzer,
+		Fset:       p.fset,
+		Files:      p.files,
+		Pkg:        p.types,
+		TypesInfo:  p.typesInfo,
+		TypesSizes: p.sizes,
+		TypeErrors: p.typeErrors,
+		ResultOf:   inputs,
+		Report: func(d analysis.Diagnostic) {
+			if p.generated[p.fset.File(d.Pos)] == nil {
+				return // reported by the analysis of the Go files
+			}
+			if d.Category == "" {
+				d.Category = analyzer.Name
+			} else {
+				d.Category = analyzer.Name + "." + d.Category
+			}
+			if d, ok := p.gopDiagnostic(pkg, d); ok {
+				diagnostics = append(diagnostics, d)
+			}
+		},
+		// The facts exported here are only seen by this pass: the
+		// importers of the package see the types of its Go files.
+		ImportObjectFact: func(obj types.Object, ptr analysis.Fact) bool {
+			if v, ok := objectFacts[objectFactKey{obj, factType(ptr)}]; ok {
+				reflect.ValueOf(ptr).Elem().Set(reflect.ValueOf(v).Elem())
+				return true
+			}
+			return false
+		},
+		ExportObjectFact: func(obj types.Object, fact analysis.Fact) {
+			objectFacts[objectFactKey{obj, factType(fact)}] = fact
+		},
+		ImportPackageFact: func(pkg *types.Package, ptr analysis.Fact) bool {
+			if v, ok := packageFacts[packageFactKey{pkg, factType(ptr)}]; ok {
+				reflect.ValueOf(ptr).Elem().Set(reflect.ValueOf(v).Elem())
+				return true
+			}
+			return false
+		},
+		ExportPackageFact: func(fact analysis.Fact) {
+			packageFacts[packageFactKey{p.types, factType(fact)}] = fact
+		},
+		AllObjectFacts: func() []analysis.ObjectFact {
+			facts := make([]analysis.ObjectFact, 0, len(objectFacts))
+			for k, fact := range objectFacts {
+				facts = append(facts, analysis.ObjectFact{Object: k.obj, Fact: fact})
+			}
+			return facts
+		},
+		AllPackageFacts: func() []analysis.PackageFact {
+			facts := make([]analysis.PackageFact, 0, len(packageFacts))
+			for k, fact := range packageFacts {
+				facts = append(facts, analysis.PackageFact{Package: k.pkg, Fact: fact})
+			}
+			return facts
+		},
+	}
+
+	var result interface{}
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("analysis %s of the Go+ files of package %s panicked: %v", analyzer.Name, pkg.PkgPath(), r)
+			}
+		}()
+		result, err = analyzer.Run(pass)
+	}()
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, diagnostics, nil
+}
+
+// gopDiagnostic maps the positions of d, a finding in the compiled Go code,
+// to the Go+ files of pkg. It reports false if the position of d can't be
+// mapped. Related information that can't be mapped is dropped, as are the
+// suggested fixes whose edits can't be mapped exactly.
+func (p *gopCompiledPackage) gopDiagnostic(pkg *pkg, d analysis.Diagnostic) (analysis.Diagnostic, bool) {
+	var ok bool
+	if d.Pos, d.End, _, ok = p.gopRange(pkg, d.Pos, d.End); !ok {
+		return d, false
+	}
+
+	var related []analysis.RelatedInformation
+	for _, r := range d.Related {
+		if p.generated[p.fset.File(r.Pos)] != nil {
+			if r.Pos, r.End, _, ok = p.gopRange(pkg, r.Pos, r.End); !ok {
+				continue
+			}
+		}
+		related = append(related, r)
+	}
+	d.Related = related
+
+	var fixes []analysis.SuggestedFix
+fixes:
+	for _, fix := range d.SuggestedFixes {
+		var edits []analysis.TextEdit
+		for _, e := range fix.TextEdits {
+			if p.generated[p.fset.File(e.Pos)] != nil {
+				var exact bool
+				if e.Pos, e.End, exact, ok = p.gopRange(pkg, e.Pos, e.End); !ok || !exact {
+					continue fixes
+				}
+			}
+			edits = append(edits, e)
+		}
+		fixes = append(fixes, analysis.SuggestedFix{Message: fix.Message, TextEdits: edits})
+	}
+	d.SuggestedFixes = fixes
+	return d, true
+}
+
+// gopRange maps the range [pos, end) of the compiled Go code to the Go+ file
+// of pkg it was compiled from.
+//
+// The range is first mapped to the Go+ statement, or else the Go+
+// declaration, that the code is part of. If the code appears once, verbatim,
+// in that statement or declaration, the result is its range there, and exact
+// is true. Otherwise the result is the range of the whole statement or
+// declaration.
+func (p *gopCompiledPackage) gopRange(pkg *pkg, pos, end token.Pos) (start, stop token.Pos, exact, ok bool) {
+	gen := p.generated[p.fset.File(pos)]
+	if gen == nil {
+		return
+	}
+	if !end.IsValid() || end < pos {
+		end = pos
+	}
+	off, endOff := gen.tok.Offset(pos), gen.tok.Offset(end)
+
+	// The innermost statement containing the range.
+	var m *cl.SourceMapping
+	for i := range gen.m.Mappings {
+		mi := &gen.m.Mappings[i]
+		if mi.Start > off {
+			break // sorted by Start
+		}
+		if endOff <= mi.End && (m == nil || mi.End-mi.Start <= m.End-m.Start) {
+			m = mi
+		}
+	}
+
+	var pgf *source.ParsedGopFile
+	if m != nil {
+		filename := gen.m.Sources[m.Source]
+		pgf, start = gopPosition(pkg, &token.Position{Filename: filename, Line: m.StartLine, Column: m.StartCol})
+		_, stop = gopPosition(pkg, &token.Position{Filename: filename, Line: m.EndLine, Column: m.EndCol})
+		if off == endOff && off == m.Start {
+			// An insertion before the statement.
+			return start, start, true, pgf != nil
+		}
+	} else {
+		pgf, start, stop = gopDeclAt(pkg, gen.file, pos)
+	}
+	if pgf == nil || !stop.IsValid() || stop < start {
+		return token.NoPos, token.NoPos, false, false
+	}
+
+	goText := gen.src[off:endOff]
+	gopText := pgf.Src[pgf.Tok.Offset(start):pgf.Tok.Offset(stop)]
+	if len(goText) > 0 && bytes.Count(gopText, goText) == 1 {
+		i := bytes.Index(gopText, goText)
+		return start + token.Pos(i), start + token.Pos(i+len(goText)), true, true
+	}
+	return start, stop, false, true
+}
+
+// gopDeclAt returns the range of the Go+ declaration that the top-level
+// declaration of the compiled Go file f at pos was compiled from, which has
+// the same name.
+func gopDeclAt(pkg *pkg, f *ast.File, pos token.Pos) (*source.ParsedGopFile, token.Pos, token.Pos) {
+	var names []string
+	for _, decl := range f.Decls {
+		if decl.Pos() <= pos && pos < decl.End() {
+			names = declNames(decl)
+			break
+		}
+	}
+	for _, name := range names {
+		for _, pgf := range pkg.gopFiles {
+			for _, decl := range pgf.File.Decls {
+				for _, gopName := range gopDeclNames(decl) {
+					if gopName == name {
+						return pgf, decl.Pos(), decl.End()
+					}
+				}
+			}
+		}
+	}
+	return nil, token.NoPos, token.NoPos
+}
+
+// declNames returns the names declared by decl. Methods are named after
+// their receiver type, as in T.M.
+func declNames(decl ast.Decl) (names []string) {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		name := decl.Name.Name
+		if decl.Recv != nil && len(decl.Recv.List) > 0 {
+			typ := decl.Recv.List[0].Type
+			if star, ok := typ.(*ast.StarExpr); ok {
+				typ = star.X
+			}
+			if id, ok := typ.(*ast.Ident); ok {
+				name = id.Name + "." + name
+			}
+		}
+		names = append(names, name)
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *ast.ValueSpec:
+				for _, id := range spec.Names {
+					names = append(names, id.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// gopDeclNames is like declNames, for the declarations of Go+ files.
+func gopDeclNames(decl gopast.Decl) (names []string) {
+	switch decl := decl.(type) {
+	case *gopast.FuncDecl:
+		name := decl.Name.Name
+		if decl.Recv != nil && len(decl.Recv.List) > 0 {
+			typ := decl.Recv.List[0].Type
+			if star, ok := typ.(*gopast.StarExpr); ok {
+				typ = star.X
+			}
+			if id, ok := typ.(*gopast.Ident); ok {
+				name = id.Name + "." + name
+			}
+		}
+		names = append(names, name)
+	case *gopast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *gopast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *gopast.ValueSpec:
+				for _, id := range spec.Names {
+					names = append(names, id.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// gopSyntaxPackage returns the result of the gopsyntax analyzer for pkg,
+// which holds its Go+ files.
+func gopSyntaxPackage(pkg *pkg) *gopsyntax.Package {
+	result := &gopsyntax.Package{
+		Fset:      pkg.fset,
+		Types:     pkg.gopTypes,
+		TypesInfo: pkg.gopTypesInfo,
+	}
+	for _, pgf := range pkg.gopFiles {
+		if pgf.File != nil {
+			result.Files = append(result.Files, pgf.File)
+		}
+	}
+	return result
+}
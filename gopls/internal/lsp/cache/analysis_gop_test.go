@@ -0,0 +1,199 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis/passes/inspect"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis/passes/printf"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/goptest"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+)
+
+func TestGopAnalysis(t *testing.T) {
+	const src = `import "fmt"
+
+func f(s string) {
+	fmt.Printf("%d\n", s)
+}
+
+printf "%d\n", "x"
+`
+	const filename = "/foo/bar.gop"
+	fset := token.NewFileSet()
+	ranges := cl.NewSourceRanges()
+	imp := goptest.NewImporter(fset)
+	p, err := goptest.Compile(t, "foo", map[string]string{filename: src}, &cl.Config{
+		Fset:       fset,
+		WorkingDir: "/foo",
+		Importer:   imp,
+		SourceMap:  ranges,
+	})
+	if err != nil {
+		t.Fatal("Compile:", err)
+	}
+	pgf := &source.ParsedGopFile{
+		URI:  span.URIFromPath(filename),
+		File: p.Files[filename],
+		Tok:  p.Tok(filename),
+		Src:  []byte(src),
+	}
+	pkg := &pkg{
+		m:        &source.Metadata{ID: "foo", PkgPath: "foo"},
+		fset:     fset,
+		gopFiles: []*source.ParsedGopFile{pgf},
+	}
+	pkg.gopCompiled = &gopCompiledPackage{fset: fset, dir: "/foo", out: p.Out, ranges: ranges, imp: imp}
+
+	inputs := make(map[*analysis.Analyzer]interface{})
+	inputs[inspect.Analyzer], _, err = gopActionImpl(pkg, inspect.Analyzer, nil, nil, nil)
+	if err != nil {
+		t.Fatal("inspect:", err)
+	}
+	_, diags, err := gopActionImpl(pkg, printf.Analyzer, inputs, nil, nil)
+	if err != nil {
+		t.Fatal("printf:", err)
+	}
+
+	var got []string
+	for _, d := range diags {
+		got = append(got, src[pgf.Tok.Offset(d.Pos):pgf.Tok.Offset(d.End)]+": "+d.Message)
+	}
+	want := []string{
+		// verbatim Go code, at its exact range
+		`fmt.Printf("%d\n", s): fmt.Printf format %d has arg s of wrong type string, see also https://pkg.go.dev/fmt#hdr-Printing`,
+		// Go code compiled from a command-style call, at the whole statement
+		`printf "%d\n", "x": fmt.Printf format %d has arg "x" of wrong type string, see also https://pkg.go.dev/fmt#hdr-Printing`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The Go code shown by gopls.gop_show_go is the one analyzed.
+	files, err := pkg.GopGeneratedGo()
+	if err != nil {
+		t.Fatal("GopGeneratedGo:", err)
+	}
+	if len(files) != 1 || !strings.Contains(string(files["gop_autogen.go"]), `fmt.Printf("%d\n", "x")`) {
+		t.Errorf("GopGeneratedGo: got %q", files)
+	}
+}
+
+// TestGopAnalysisFacts checks that the analysis of the Go+ files gets the
+// facts of the dependencies of the package.
+func TestGopAnalysisFacts(t *testing.T) {
+	// A printf wrapper, whose fact the printf analyzer exports.
+	const logSrc = `package log
+
+import "fmt"
+
+func Logf(format string, args ...interface{}) {
+	fmt.Printf(format, args...)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "/log/log.go", logSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	logPkg, err := (&types.Config{Importer: importer.Default()}).Check("example.com/log", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectFacts := make(map[objectFactKey]analysis.Fact)
+	logPass := func(a *analysis.Analyzer, inputs map[*analysis.Analyzer]interface{}) interface{} {
+		result, err := a.Run(&analysis.Pass{
+			Analyzer:          a,
+			Fset:              fset,
+			Files:             []*ast.File{f},
+			Pkg:               logPkg,
+			TypesInfo:         info,
+			ResultOf:          inputs,
+			Report:            func(analysis.Diagnostic) {},
+			ImportObjectFact:  func(types.Object, analysis.Fact) bool { return false },
+			ImportPackageFact: func(*types.Package, analysis.Fact) bool { return false },
+			ExportObjectFact: func(obj types.Object, fact analysis.Fact) {
+				objectFacts[objectFactKey{obj, factType(fact)}] = fact
+			},
+			ExportPackageFact: func(analysis.Fact) {},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", a.Name, err)
+		}
+		return result
+	}
+	logPass(printf.Analyzer, map[*analysis.Analyzer]interface{}{inspect.Analyzer: logPass(inspect.Analyzer, nil)})
+	if len(objectFacts) == 0 {
+		t.Fatal("printf: no fact for Logf")
+	}
+
+	const src = `import "example.com/log"
+
+log.Logf "%d\n", "x"
+`
+	const filename = "/foo/bar.gop"
+	ranges := cl.NewSourceRanges()
+	fallback := goptest.NewImporter(fset)
+	imp := importerFunc(func(path string) (*types.Package, error) {
+		if path == logPkg.Path() {
+			return logPkg, nil
+		}
+		return fallback.Import(path)
+	})
+	p, err := goptest.Compile(t, "foo", map[string]string{filename: src}, &cl.Config{
+		Fset:       fset,
+		WorkingDir: "/foo",
+		Importer:   imp,
+		SourceMap:  ranges,
+	})
+	if err != nil {
+		t.Fatal("Compile:", err)
+	}
+	pgf := &source.ParsedGopFile{
+		URI:  span.URIFromPath(filename),
+		File: p.Files[filename],
+		Tok:  p.Tok(filename),
+		Src:  []byte(src),
+	}
+	pkg := &pkg{
+		m:        &source.Metadata{ID: "foo", PkgPath: "foo"},
+		fset:     fset,
+		gopFiles: []*source.ParsedGopFile{pgf},
+	}
+	pkg.gopCompiled = &gopCompiledPackage{fset: fset, dir: "/foo", out: p.Out, ranges: ranges, imp: imp}
+
+	inputs := make(map[*analysis.Analyzer]interface{})
+	inputs[inspect.Analyzer], _, err = gopActionImpl(pkg, inspect.Analyzer, nil, nil, nil)
+	if err != nil {
+		t.Fatal("inspect:", err)
+	}
+	_, diags, err := gopActionImpl(pkg, printf.Analyzer, inputs, objectFacts, nil)
+	if err != nil {
+		t.Fatal("printf:", err)
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].Message, "log.Logf format %d has arg \"x\" of wrong type string") {
+		var got []string
+		for _, d := range diags {
+			got = append(got, d.Message)
+		}
+		t.Errorf("got diagnostics %q, want one for the call of log.Logf", got)
+	}
+}
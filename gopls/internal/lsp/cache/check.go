@@ -132,17 +132,20 @@ func (s *snapshot) buildPackageHandle(ctx context.Context, id PackageID, mode so
 	// syntax trees are available from (*pkg).File(URI).
 	// TODO(adonovan): consider parsing them on demand?
 	// The need should be rare.
-	goFiles, compiledGoFiles, err := readGoFiles(ctx, s, m)
+	goFiles, compiledGoFiles, gopFiles, err := readGoFiles(ctx, s, m)
 	if err != nil {
 		return nil, err
 	}
 
 	// All the file reading has now been done.
 	// Create a handle for the result of type checking.
+	// Go+ files are compiled along with the Go files, so they are
+	// part of the key.
 	experimentalKey := s.View().Options().ExperimentalPackageCacheKey
-	phKey := computePackageKey(m.ID, compiledGoFiles, m, depKey, mode, experimentalKey)
+	keyFiles := append(compiledGoFiles[:len(compiledGoFiles):len(compiledGoFiles)], gopFiles...)
+	phKey := computePackageKey(m.ID, keyFiles, m, depKey, mode, experimentalKey)
 	promise, release := s.store.Promise(phKey, func(ctx context.Context, arg interface{}) interface{} {
-		pkg, err := typeCheckImpl(ctx, arg.(*snapshot), goFiles, compiledGoFiles, m, mode, deps)
+		pkg, err := typeCheckImpl(ctx, arg.(*snapshot), goFiles, compiledGoFiles, gopFiles, m, mode, deps)
 		return typeCheckResult{pkg, err}
 	})
 
@@ -182,9 +185,9 @@ func (s *snapshot) buildPackageHandle(ctx context.Context, id PackageID, mode so
 	return ph, nil
 }
 
-// readGoFiles reads the content of Metadata.GoFiles and
-// Metadata.CompiledGoFiles, in parallel.
-func readGoFiles(ctx context.Context, s *snapshot, m *source.Metadata) (goFiles, compiledGoFiles []source.FileHandle, err error) {
+// readGoFiles reads the content of Metadata.GoFiles,
+// Metadata.CompiledGoFiles and Metadata.GopFiles, in parallel.
+func readGoFiles(ctx context.Context, s *snapshot, m *source.Metadata) (goFiles, compiledGoFiles, gopFiles []source.FileHandle, err error) {
 	var group errgroup.Group
 	getFileHandles := func(files []span.URI) []source.FileHandle {
 		fhs := make([]source.FileHandle, len(files))
@@ -199,6 +202,7 @@ func readGoFiles(ctx context.Context, s *snapshot, m *source.Metadata) (goFiles,
 	}
 	return getFileHandles(m.GoFiles),
 		getFileHandles(m.CompiledGoFiles),
+		getFileHandles(m.GopFiles),
 		group.Wait()
 }
 
@@ -304,7 +308,7 @@ func (ph *packageHandle) cached() (*pkg, error) {
 // typeCheckImpl type checks the parsed source files in compiledGoFiles.
 // (The resulting pkg also holds the parsed but not type-checked goFiles.)
 // deps holds the future results of type-checking the direct dependencies.
-func typeCheckImpl(ctx context.Context, snapshot *snapshot, goFiles, compiledGoFiles []source.FileHandle, m *source.Metadata, mode source.ParseMode, deps map[PackageID]*packageHandle) (*pkg, error) {
+func typeCheckImpl(ctx context.Context, snapshot *snapshot, goFiles, compiledGoFiles, gopFiles []source.FileHandle, m *source.Metadata, mode source.ParseMode, deps map[PackageID]*packageHandle) (*pkg, error) {
 	// Start type checking of direct dependencies,
 	// in parallel and asynchronously.
 	// As the type checker imports each of these
@@ -326,28 +330,30 @@ func typeCheckImpl(ctx context.Context, snapshot *snapshot, goFiles, compiledGoF
 	defer wg.Wait()
 
 	var filter *unexportedFilter
-	if mode == source.ParseExported {
+	// The Go+ files may refer to unexported declarations of the Go files,
+	// which the filter would remove.
+	if mode == source.ParseExported && len(m.GopFiles) == 0 {
 		filter = &unexportedFilter{uses: map[string]bool{}}
 	}
-	pkg, err := doTypeCheck(ctx, snapshot, goFiles, compiledGoFiles, m, mode, deps, filter)
+	pkg, err := doTypeCheck(ctx, snapshot, goFiles, compiledGoFiles, gopFiles, m, mode, deps, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	if mode == source.ParseExported {
+	if filter != nil {
 		// The AST filtering is a little buggy and may remove things it
 		// shouldn't. If we only got undeclared name errors, try one more
 		// time keeping those names.
 		missing, unexpected := filter.ProcessErrors(pkg.typeErrors)
 		if len(unexpected) == 0 && len(missing) != 0 {
-			pkg, err = doTypeCheck(ctx, snapshot, goFiles, compiledGoFiles, m, mode, deps, filter)
+			pkg, err = doTypeCheck(ctx, snapshot, goFiles, compiledGoFiles, gopFiles, m, mode, deps, filter)
 			if err != nil {
 				return nil, err
 			}
 			missing, unexpected = filter.ProcessErrors(pkg.typeErrors)
 		}
 		if len(unexpected) != 0 || len(missing) != 0 {
-			pkg, err = doTypeCheck(ctx, snapshot, goFiles, compiledGoFiles, m, mode, deps, nil)
+			pkg, err = doTypeCheck(ctx, snapshot, goFiles, compiledGoFiles, gopFiles, m, mode, deps, nil)
 			if err != nil {
 				return nil, err
 			}
@@ -404,6 +410,17 @@ func typeCheckImpl(ctx context.Context, snapshot *snapshot, goFiles, compiledGoF
 			pkg.diagnostics = append(pkg.diagnostics, diag)
 		}
 	}
+	for _, e := range pkg.gopParseErrors {
+		diags, err := parseErrorDiagnostics(snapshot, pkg, e)
+		if err != nil {
+			event.Error(ctx, "unable to compute positions for Go+ parse errors", err, tag.Package.Of(string(pkg.ID())))
+			continue
+		}
+		for _, diag := range diags {
+			unparseable[diag.URI] = true
+			pkg.diagnostics = append(pkg.diagnostics, diag)
+		}
+	}
 
 	if pkg.hasFixedFiles {
 		return pkg, nil
@@ -428,6 +445,19 @@ func typeCheckImpl(ctx context.Context, snapshot *snapshot, goFiles, compiledGoF
 		}
 	}
 
+	for _, e := range pkg.gopTypeErrors {
+		diags, err := gopErrorDiagnostics(snapshot, pkg, e)
+		if err != nil {
+			event.Error(ctx, "unable to compute positions for Go+ errors", err, tag.Package.Of(string(pkg.ID())))
+			continue
+		}
+		for _, diag := range diags {
+			if !unparseable[diag.URI] {
+				pkg.diagnostics = append(pkg.diagnostics, diag)
+			}
+		}
+	}
+
 	depsErrors, err := snapshot.depsErrors(ctx, pkg)
 	if err != nil {
 		return nil, err
@@ -439,7 +469,7 @@ func typeCheckImpl(ctx context.Context, snapshot *snapshot, goFiles, compiledGoF
 
 var goVersionRx = regexp.MustCompile(`^go([1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
 
-func doTypeCheck(ctx context.Context, snapshot *snapshot, goFiles, compiledGoFiles []source.FileHandle, m *source.Metadata, mode source.ParseMode, deps map[PackageID]*packageHandle, astFilter *unexportedFilter) (*pkg, error) {
+func doTypeCheck(ctx context.Context, snapshot *snapshot, goFiles, compiledGoFiles, gopFiles []source.FileHandle, m *source.Metadata, mode source.ParseMode, deps map[PackageID]*packageHandle, astFilter *unexportedFilter) (*pkg, error) {
 	ctx, done := event.Start(ctx, "cache.typeCheck", tag.Package.Of(string(m.ID)))
 	defer done()
 
@@ -485,6 +515,19 @@ func doTypeCheck(ctx context.Context, snapshot *snapshot, goFiles, compiledGoFil
 		return nil, err
 	}
 
+	// Parse the GopFiles. Go+ files are never trimmed: the Go+ compiler
+	// needs them in full for the declarations seen by importers too.
+	for _, fh := range gopFiles {
+		pgf, err := snapshot.ParseGop(ctx, fh, source.ParseFull)
+		if err != nil {
+			return nil, err
+		}
+		pkg.gopFiles = append(pkg.gopFiles, pgf)
+		if pgf.ParseErr != nil {
+			pkg.gopParseErrors = append(pkg.gopParseErrors, pgf.ParseErr)
+		}
+	}
+
 	// Use the default type information for the unsafe package.
 	if m.PkgPath == "unsafe" {
 		// Don't type check Unsafe: it's unnecessary, and doing so exposes a data
@@ -493,7 +536,7 @@ func doTypeCheck(ctx context.Context, snapshot *snapshot, goFiles, compiledGoFil
 		return pkg, nil
 	}
 
-	if len(m.CompiledGoFiles) == 0 {
+	if len(m.CompiledGoFiles) == 0 && len(m.GopFiles) == 0 {
 		// No files most likely means go/packages failed. Try to attach error
 		// messages to the file as much as possible.
 		var found bool
@@ -511,36 +554,37 @@ func doTypeCheck(ctx context.Context, snapshot *snapshot, goFiles, compiledGoFil
 		return nil, fmt.Errorf("no parsed files for package %s, expected: %v, errors: %v", pkg.m.PkgPath, pkg.compiledGoFiles, m.Errors)
 	}
 
+	importer := importerFunc(func(path string) (*types.Package, error) {
+		// While all of the import errors could be reported
+		// based on the metadata before we start type checking,
+		// reporting them via types.Importer places the errors
+		// at the correct source location.
+		id, ok := pkg.m.DepsByImpPath[ImportPath(path)]
+		if !ok {
+			// If the import declaration is broken,
+			// go list may fail to report metadata about it.
+			// See TestFixImportDecl for an example.
+			return nil, fmt.Errorf("missing metadata for import of %q", path)
+		}
+		dep, ok := deps[id] // id may be ""
+		if !ok {
+			return nil, snapshot.missingPkgError(path)
+		}
+		if !source.IsValidImport(m.PkgPath, dep.m.PkgPath) {
+			return nil, fmt.Errorf("invalid use of internal package %s", path)
+		}
+		depPkg, err := dep.await(ctx, snapshot)
+		if err != nil {
+			return nil, err
+		}
+		pkg.deps[depPkg.m.ID] = depPkg
+		return depPkg.importTypes(), nil
+	})
 	cfg := &types.Config{
 		Error: func(e error) {
 			pkg.typeErrors = append(pkg.typeErrors, e.(types.Error))
 		},
-		Importer: importerFunc(func(path string) (*types.Package, error) {
-			// While all of the import errors could be reported
-			// based on the metadata before we start type checking,
-			// reporting them via types.Importer places the errors
-			// at the correct source location.
-			id, ok := pkg.m.DepsByImpPath[ImportPath(path)]
-			if !ok {
-				// If the import declaration is broken,
-				// go list may fail to report metadata about it.
-				// See TestFixImportDecl for an example.
-				return nil, fmt.Errorf("missing metadata for import of %q", path)
-			}
-			dep, ok := deps[id] // id may be ""
-			if !ok {
-				return nil, snapshot.missingPkgError(path)
-			}
-			if !source.IsValidImport(m.PkgPath, dep.m.PkgPath) {
-				return nil, fmt.Errorf("invalid use of internal package %s", path)
-			}
-			depPkg, err := dep.await(ctx, snapshot)
-			if err != nil {
-				return nil, err
-			}
-			pkg.deps[depPkg.m.ID] = depPkg
-			return depPkg.types, nil
-		}),
+		Importer: importer,
 	}
 	if pkg.m.Module != nil && pkg.m.Module.GoVersion != "" {
 		goVersion := "go" + pkg.m.Module.GoVersion
@@ -571,6 +615,10 @@ func doTypeCheck(ctx context.Context, snapshot *snapshot, goFiles, compiledGoFil
 	// Type checking errors are handled via the config, so ignore them here.
 	_ = check.Files(files) // 50us-15ms, depending on size of package
 
+	// Type check the Go+ files, which may refer to declarations in the Go
+	// files, in all modes: importers see the declarations of both.
+	typeCheckGop(ctx, pkg, importer)
+
 	// If the context was cancelled, we may have returned a ton of transient
 	// errors to the type checker. Swallow them.
 	if ctx.Err() != nil {
@@ -0,0 +1,222 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event/tag"
+	"github.com/goplus/gox"
+	"github.com/goplus/gox/packages"
+	"github.com/goplus/mod/gopmod"
+	"github.com/qiniu/x/errors"
+)
+
+// typeCheckGop type checks the Go+ files of pkg with the Go+ compiler,
+// populating pkg.gopTypes, pkg.gopTypesInfo, pkg.gopBuiltins,
+// pkg.gopClasses and pkg.gopTypeErrors.
+//
+// Imports are resolved by imp first, so that the Go+ files share the
+// dependencies of the Go files. Packages that only the Go+ files import,
+// such as the Go+ builtin packages, are loaded from export data.
+func typeCheckGop(ctx context.Context, pkg *pkg, imp types.Importer) {
+	if len(pkg.gopFiles) == 0 {
+		return
+	}
+	ctx, done := event.Start(ctx, "cache.typeCheckGop", tag.Package.Of(string(pkg.m.ID)))
+	defer done()
+
+	gopPkg := &gopast.Package{
+		Name:    string(pkg.m.Name),
+		Files:   make(map[string]*gopast.File),
+		GoFiles: make(map[string]*ast.File),
+	}
+	for _, pgf := range pkg.gopFiles {
+		// Files of an external test package share the directory but belong
+		// to a package of their own.
+		if pgf.File.Name != nil && pgf.File.Name.Name != gopPkg.Name {
+			continue
+		}
+		gopPkg.Files[pgf.URI.Filename()] = gopCompileFile(pgf, pkg.m.GopClasses)
+	}
+	if len(gopPkg.Files) == 0 {
+		return
+	}
+	for _, pgf := range pkg.compiledGoFiles {
+		// The generated Go code duplicates the declarations of the Go+ files.
+		if source.IsGopAutogenFile(pgf.URI.Filename()) {
+			continue
+		}
+		gopPkg.GoFiles[pgf.URI.Filename()] = pgf.File
+	}
+
+	gopEnv, err := source.LoadGopEnv()
+	if err != nil {
+		pkg.gopTypeErrors = append(pkg.gopTypeErrors, err)
+		return
+	}
+
+	dir := filepath.Dir(pkg.gopFiles[0].URI.Filename())
+	lookupClass := func(ext string) (*gopmod.Class, bool) {
+		if pkg.m.GopClasses == nil { // not in a main module
+			return gopmod.ClassSpx, ext == gopmod.ClassSpx.ProjExt || ext == gopmod.ClassSpx.WorkExt
+		}
+		class, ok := pkg.m.GopClasses[ext]
+		return class, ok
+	}
+
+	// Packages unknown to go list, such as those the compiler imports
+	// implicitly, are loaded from export data.
+	fallback := gopExportImporter(pkg.fset, dir, gopEnv.Root)
+	info := typesutil.NewInfo()
+	ranges := cl.NewSourceRanges()
+	conf := &cl.Config{
+		Fset:       pkg.fset,
+		WorkingDir: dir,
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if p, err := imp.Import(path); err == nil {
+				return p, nil
+			}
+			return fallback.Import(path)
+		}),
+		LookupClass:   lookupClass,
+		NoAutoGenMain: true,
+		GenTestFuncs:  true, // as gop test does, for the generated Go code
+		Recorder:      typesutil.NewRecorder(info),
+		SourceMap:     ranges, // for the analyzers, see gopCompiledPackage
+	}
+
+	out, err := newGopPackage(string(pkg.m.PkgPath), gopPkg, conf)
+	if out != nil {
+		// The type checker only imports complete packages.
+		out.Types.MarkComplete()
+		pkg.gopTypes = out.Types
+		pkg.gopBuiltins = out.Builtin().Types.Scope()
+		for _, pgf := range pkg.gopFiles {
+			f := gopPkg.Files[pgf.URI.Filename()]
+			if f == nil || !f.IsClass {
+				continue
+			}
+			if tn, ok := out.Types.Scope().Lookup(cl.ClassNameOf(pgf.URI.Filename(), f.IsProj)).(*types.TypeName); ok {
+				if pkg.gopClasses == nil {
+					pkg.gopClasses = make(map[span.URI]*types.TypeName)
+				}
+				pkg.gopClasses[pgf.URI] = tn
+			}
+		}
+	}
+	pkg.gopTypesInfo = info
+	if err == nil {
+		pkg.gopCompiled = &gopCompiledPackage{
+			fset:   pkg.fset,
+			dir:    dir,
+			out:    out,
+			ranges: ranges,
+			imp: importerFunc(func(path string) (*types.Package, error) {
+				// The dependencies imported so far are done.
+				if id, ok := pkg.m.DepsByImpPath[ImportPath(path)]; ok {
+					if dep, ok := pkg.deps[id]; ok {
+						return dep.importTypes(), nil
+					}
+				}
+				return fallback.Import(path)
+			}),
+			sizes: pkg.m.TypesSizes,
+		}
+	}
+	if err != nil {
+		if list, ok := err.(errors.List); ok {
+			pkg.gopTypeErrors = append(pkg.gopTypeErrors, list...)
+		} else {
+			pkg.gopTypeErrors = append(pkg.gopTypeErrors, err)
+		}
+	}
+}
+
+// importTypes returns the types of p seen by the packages that import it:
+// those of the Go+ compiler, which also declares the Go+ files, if p has
+// Go+ files.
+func (p *pkg) importTypes() *types.Package {
+	if p.gopTypes != nil {
+		return p.gopTypes
+	}
+	return p.types
+}
+
+// gopExportImporter returns an importer of the export data that
+// "go list -export" reports in dir, or in gopRoot for the packages of Go+
+// itself. Unlike the importer of the gop command, it never generates Go
+// code or runs "go mod tidy" in the imported modules.
+func gopExportImporter(fset *token.FileSet, dir, gopRoot string) types.Importer {
+	imp := packages.NewImporter(fset, dir)
+	return importerFunc(func(path string) (*types.Package, error) {
+		if path == gopModulePath || strings.HasPrefix(path, gopModulePath+"/") {
+			return imp.ImportFrom(path, gopRoot, 0)
+		}
+		return imp.Import(path)
+	})
+}
+
+// gopModulePath is the module path of Go+.
+const gopModulePath = "github.com/Deng-Xian-Sheng/goplus-lsp/gop"
+
+// gopCompileFile returns the syntax tree of pgf to compile, marked as a
+// classfile if its extension is registered in classes.
+//
+// The compiler moves the fields of a classfile out of its var declaration
+// and sets the receivers of its functions to the class, so classfiles are
+// compiled from a copy of the declarations, leaving the cached syntax tree
+// intact.
+func gopCompileFile(pgf *source.ParsedGopFile, classes map[string]*gopmod.Class) *gopast.File {
+	isProj, isClass := source.GopClassKind(pgf.URI.Filename(), pgf.File, classes)
+	if !isClass && !pgf.File.IsClass {
+		return pgf.File
+	}
+	f := *pgf.File
+	f.IsProj, f.IsClass = isProj, isClass
+	if isClass {
+		f.Decls = make([]gopast.Decl, len(pgf.File.Decls))
+		for i, decl := range pgf.File.Decls {
+			switch decl := decl.(type) {
+			case *gopast.GenDecl:
+				copy := *decl
+				f.Decls[i] = &copy
+			case *gopast.FuncDecl:
+				copy := *decl
+				f.Decls[i] = &copy
+			default:
+				f.Decls[i] = decl
+			}
+		}
+	}
+	return &f
+}
+
+// newGopPackage calls cl.NewPackage, turning a panic of the compiler into
+// an error. The compiler only recovers from panics whose value is an error
+// or a string, and not at all after cl.SetDisableRecover.
+func newGopPackage(pkgPath string, pkg *gopast.Package, conf *cl.Config) (out *gox.Package, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			out, err = nil, fmt.Errorf("compiling Go+ package %s: %v", pkgPath, e)
+		}
+	}()
+	return cl.NewPackage(pkgPath, pkg, conf)
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/goptest"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/goplus/mod/gopmod"
+)
+
+// A classfile framework, registered for .gamex and .sprite files.
+const gopFramework = `package game
+
+type Game struct{}
+
+func (p *Game) Main() {}
+
+func (p *Game) Play(name string) {}
+
+type Sprite struct {
+	Name string
+}
+
+func (p *Sprite) Say(msg string) {}
+`
+
+func TestGopCompileFile(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "game.go", gopFramework, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	framework, err := (&types.Config{Importer: importer.Default()}).Check("example.com/game", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	class := &gopmod.Class{ProjExt: ".gamex", WorkExt: ".sprite", PkgPaths: []string{"example.com/game"}}
+	classes := map[string]*gopmod.Class{".gamex": class, ".sprite": class}
+
+	files := map[string]string{
+		"/foo/MyGame.gamex": "var (\n\tscore int\n)\n\nplay \"intro\"\n",
+		"/foo/Kai.sprite":   "var (\n\tlives int\n)\n\nfunc onStart() {\n\tsay \"hi\"\n\tlives = score\n}\n",
+	}
+	var pgfs []*source.ParsedGopFile
+	for filename, src := range files {
+		pgf, err := parseGopImpl(context.Background(), fset, &fileHandle{uri: span.URIFromPath(filename), bytes: []byte(src)}, source.ParseFull)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pgfs = append(pgfs, pgf)
+	}
+
+	fallback := goptest.NewImporter(fset)
+	conf := &cl.Config{
+		Fset: fset,
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == framework.Path() {
+				return framework, nil
+			}
+			return fallback.Import(path)
+		}),
+		LookupClass: func(ext string) (*gopmod.Class, bool) {
+			c, ok := classes[ext]
+			return c, ok
+		},
+		NoFileLine:    true,
+		NoAutoGenMain: true,
+	}
+
+	// The compiler changes the declarations of classfiles, so compiling the
+	// same files twice only works if it is given copies.
+	for i := 0; i < 2; i++ {
+		pkg := &gopast.Package{Name: "main", Files: make(map[string]*gopast.File)}
+		for _, pgf := range pgfs {
+			f := gopCompileFile(pgf, classes)
+			if !f.IsClass || f.IsProj != (pgf.URI.Filename() == "/foo/MyGame.gamex") {
+				t.Fatalf("gopCompileFile(%s): got IsProj=%t IsClass=%t", pgf.URI.Filename(), f.IsProj, f.IsClass)
+			}
+			pkg.Files[pgf.URI.Filename()] = f
+		}
+		out, err := newGopPackage("main", pkg, conf)
+		if err != nil {
+			t.Fatalf("compilation #%d: %v", i+1, err)
+		}
+		for _, name := range []string{"MyGame", "Kai"} {
+			if _, ok := out.Types.Scope().Lookup(name).(*types.TypeName); !ok {
+				t.Errorf("compilation #%d: no class %s", i+1, name)
+			}
+		}
+	}
+}
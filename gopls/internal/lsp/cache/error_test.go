@@ -50,3 +50,46 @@ func TestParseErrorMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGopPos(t *testing.T) {
+	tests := []struct {
+		in       string
+		filename string
+		line     int
+		column   int
+		trimmed  string
+	}{
+		{
+			in:       "./foo.gop:3:6: cannot use x (type int) as type string in assignment",
+			filename: "./foo.gop",
+			line:     3,
+			column:   6,
+			trimmed:  "cannot use x (type int) as type string in assignment",
+		},
+		{
+			in:       "\tprevious declaration at ./bar.gop:12:1",
+			filename: "./bar.gop",
+			line:     12,
+			column:   1,
+			trimmed:  "\tprevious declaration at ./bar.gop:12:1",
+		},
+		{
+			in:      "unexpected error",
+			trimmed: "unexpected error",
+		},
+	}
+
+	for _, tt := range tests {
+		posn := parseGopPos(tt.in)
+		if tt.filename == "" {
+			if posn != nil {
+				t.Errorf("parseGopPos(%q) = %v, want nil", tt.in, posn)
+			}
+		} else if posn == nil || posn.Filename != tt.filename || posn.Line != tt.line || posn.Column != tt.column {
+			t.Errorf("parseGopPos(%q) = %v, want %s:%d:%d", tt.in, posn, tt.filename, tt.line, tt.column)
+		}
+		if got := trimGopPos(tt.in); got != tt.trimmed {
+			t.Errorf("trimGopPos(%q) = %q, want %q", tt.in, got, tt.trimmed)
+		}
+	}
+}
@@ -78,12 +78,12 @@ func parseErrorDiagnostics(snapshot *snapshot, pkg *pkg, errList scanner.ErrorLi
 		return nil, fmt.Errorf("no errors in %v", errList)
 	}
 	e := errList[0]
-	pgf, err := pkg.File(span.URIFromPath(e.Pos.Filename))
+	tok, err := parsedTokFile(pkg, span.URIFromPath(e.Pos.Filename))
 	if err != nil {
 		return nil, err
 	}
-	pos := pgf.Tok.Pos(e.Pos.Offset)
-	spn, err := span.NewRange(pgf.Tok, pos, pos).Span()
+	pos := tok.Pos(e.Pos.Offset)
+	spn, err := span.NewRange(tok, pos, pos).Span()
 	if err != nil {
 		return nil, err
 	}
@@ -397,6 +397,9 @@ func parsedGoSpan(pgf *source.ParsedGoFile, start, end token.Pos) (span.Span, er
 // spanToRange converts a span.Span to a protocol.Range,
 // assuming that the span belongs to the package whose diagnostics are being computed.
 func spanToRange(pkg *pkg, spn span.Span) (protocol.Range, error) {
+	if pgf, err := pkg.GopFile(spn.URI()); err == nil {
+		return pgf.Mapper.Range(spn)
+	}
 	pgf, err := pkg.File(spn.URI())
 	if err != nil {
 		return protocol.Range{}, err
@@ -404,6 +407,19 @@ func spanToRange(pkg *pkg, spn span.Span) (protocol.Range, error) {
 	return pgf.Mapper.Range(spn)
 }
 
+// parsedTokFile returns the token.File of the parsed Go or Go+ file
+// of pkg with the given URI.
+func parsedTokFile(pkg *pkg, uri span.URI) (*token.File, error) {
+	if pgf, err := pkg.GopFile(uri); err == nil {
+		return pgf.Tok, nil
+	}
+	pgf, err := pkg.File(uri)
+	if err != nil {
+		return nil, err
+	}
+	return pgf.Tok, nil
+}
+
 // parseGoListError attempts to parse a standard `go list` error message
 // by stripping off the trailing error message.
 //
@@ -0,0 +1,185 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"errors"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/analysisinternal"
+	"github.com/goplus/gox"
+)
+
+// gopPosRe matches a position, as formatted by token.Position, in the
+// messages of the Go+ compiler. File names are relative to the package
+// directory.
+var gopPosRe = regexp.MustCompile(`(\S+\.\w+):(\d+):(\d+)`)
+
+// gopErrorDiagnostics converts an error of the Go+ compiler into
+// diagnostics.
+//
+// Most errors are gox.CodeErrors, which only record the start of the code
+// they refer to: as for go/types errors without an end, the range extends
+// to the end of the identifier or literal found there. The further lines of
+// a multi-line message, such as "\tprevious declaration at ./a.gop:3:6",
+// become related information if the client supports it.
+//
+// Errors without a position apply to all Go+ files of the package.
+func gopErrorDiagnostics(snapshot *snapshot, pkg *pkg, err error) ([]*source.Diagnostic, error) {
+	var (
+		msg        string
+		start, end token.Pos
+		pgf        *source.ParsedGopFile
+	)
+	var (
+		codeErr   *gox.CodeError
+		matchErr  *gox.MatchError
+		importErr *gox.ImportError
+	)
+	switch {
+	case errors.As(err, &codeErr):
+		msg = codeErr.Msg
+		pgf, start = gopPosition(pkg, codeErr.Pos)
+	case errors.As(err, &matchErr):
+		msg = trimGopPos(matchErr.Error())
+		if pos := matchErr.Src.Pos(); pos.IsValid() {
+			pgf = gopFileAt(pkg, pos)
+			start, end = pos, matchErr.Src.End()
+		}
+	case errors.As(err, &importErr):
+		msg = importErr.Err.Error()
+		pgf, start = gopPosition(pkg, importErr.Pos)
+	default:
+		msg = trimGopPos(err.Error())
+		pgf, start = gopPosition(pkg, parseGopPos(err.Error()))
+	}
+
+	lines := strings.Split(msg, "\n")
+	related := snapshot.View().Options().RelatedInformationSupported
+	if related {
+		msg = lines[0]
+	}
+
+	if pgf == nil {
+		var diags []*source.Diagnostic
+		for _, pgf := range pkg.gopFiles {
+			diags = append(diags, &source.Diagnostic{
+				URI:      pgf.URI,
+				Severity: protocol.SeverityError,
+				Source:   source.GopError,
+				Message:  msg,
+			})
+		}
+		return diags, nil
+	}
+
+	rng, err := gopRange(pkg.fset, pgf, start, end)
+	if err != nil {
+		return nil, err
+	}
+	diag := &source.Diagnostic{
+		URI:      pgf.URI,
+		Range:    rng,
+		Severity: protocol.SeverityError,
+		Source:   source.GopError,
+		Message:  msg,
+	}
+	if related {
+		for _, line := range lines[1:] {
+			other, pos := gopPosition(pkg, parseGopPos(line))
+			if other == nil {
+				continue
+			}
+			rng, err := gopRange(pkg.fset, other, pos, token.NoPos)
+			if err != nil {
+				return nil, err
+			}
+			// "previous declaration at ./a.gop:3:6" => "previous declaration"
+			text := strings.TrimSpace(gopPosRe.ReplaceAllString(line, ""))
+			text = strings.TrimSuffix(text, " at")
+			diag.Related = append(diag.Related, source.RelatedInformation{
+				URI:     other.URI,
+				Range:   rng,
+				Message: text,
+			})
+		}
+	}
+	return []*source.Diagnostic{diag}, nil
+}
+
+// gopRange returns the range of [start, end) in pgf. If end is invalid,
+// the range extends to the end of the token at start.
+func gopRange(fset *token.FileSet, pgf *source.ParsedGopFile, start, end token.Pos) (protocol.Range, error) {
+	if !end.IsValid() || end <= start {
+		end = analysisinternal.TypeErrorEndPos(fset, pgf.Src, start)
+	}
+	spn, err := span.FileSpan(pgf.Tok, pgf.Tok, start, end)
+	if err != nil {
+		return protocol.Range{}, err
+	}
+	return pgf.Mapper.Range(spn)
+}
+
+// gopPosition returns the Go+ file of pkg that posn refers to, and the
+// corresponding token.Pos. It returns a nil file if there is none.
+func gopPosition(pkg *pkg, posn *token.Position) (*source.ParsedGopFile, token.Pos) {
+	if posn == nil || posn.Line <= 0 {
+		return nil, token.NoPos
+	}
+	// The compiler reports positions relative to its working directory, and
+	// all Go+ files of a package are in the same directory.
+	base := filepath.Base(posn.Filename)
+	for _, pgf := range pkg.gopFiles {
+		if filepath.Base(pgf.URI.Filename()) != base {
+			continue
+		}
+		tok := pgf.Tok
+		if posn.Line > tok.LineCount() {
+			return nil, token.NoPos
+		}
+		pos := tok.LineStart(posn.Line)
+		if col := posn.Column; col > 1 && tok.Offset(pos)+col-1 <= tok.Size() {
+			pos += token.Pos(col - 1)
+		}
+		return pgf, pos
+	}
+	return nil, token.NoPos
+}
+
+// gopFileAt returns the Go+ file of pkg that contains pos, or nil.
+func gopFileAt(pkg *pkg, pos token.Pos) *source.ParsedGopFile {
+	for _, pgf := range pkg.gopFiles {
+		if tok := pgf.Tok; tok.Base() <= int(pos) && int(pos) <= tok.Base()+tok.Size() {
+			return pgf
+		}
+	}
+	return nil
+}
+
+// parseGopPos returns the first position found in msg, or nil.
+func parseGopPos(msg string) *token.Position {
+	m := gopPosRe.FindStringSubmatch(msg)
+	if m == nil {
+		return nil
+	}
+	line, _ := strconv.Atoi(m[2])
+	col, _ := strconv.Atoi(m[3])
+	return &token.Position{Filename: m[1], Line: line, Column: col}
+}
+
+// trimGopPos trims the position that prefixes msg, if any.
+func trimGopPos(msg string) string {
+	if loc := gopPosRe.FindStringIndex(msg); loc != nil && loc[0] == 0 {
+		return strings.TrimPrefix(msg[loc[1]:], ": ")
+	}
+	return msg
+}
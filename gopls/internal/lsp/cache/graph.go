@@ -68,6 +68,9 @@ func (g *metadataGraph) build() {
 		for _, uri := range m.GoFiles {
 			uris[uri] = struct{}{}
 		}
+		for _, uri := range m.GopFiles {
+			uris[uri] = struct{}{}
+		}
 		for uri := range uris {
 			g.ids[uri] = append(g.ids[uri], id)
 		}
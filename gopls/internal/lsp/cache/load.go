@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/Deng-Xian-Sheng/goplus-lsp/go/packages"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
@@ -26,6 +27,7 @@ import (
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event/tag"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/gocommand"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/packagesinternal"
+	"github.com/goplus/mod/gopmod"
 )
 
 var loadID uint64 // atomic identifier for loads
@@ -100,7 +102,8 @@ func (s *snapshot) load(ctx context.Context, allowNetwork bool, scopes ...loadSc
 			containsDir = true
 		}
 	}
-	if len(query) == 0 {
+	gopDirs := s.gopLoadDirs(ctx, scopes)
+	if len(query) == 0 && len(gopDirs) == 0 {
 		return nil
 	}
 	sort.Strings(query) // for determinism
@@ -126,7 +129,10 @@ func (s *snapshot) load(ctx context.Context, allowNetwork bool, scopes ...loadSc
 	defer cancel()
 
 	cfg := s.config(ctx, inv)
-	pkgs, err := packages.Load(cfg, query...)
+	var pkgs []*packages.Package
+	if len(query) > 0 { // otherwise, only Go+ files are to be loaded
+		pkgs, err = packages.Load(cfg, query...)
+	}
 	cleanup()
 
 	// If the context was canceled, return early. Otherwise, we might be
@@ -144,7 +150,7 @@ func (s *snapshot) load(ctx context.Context, allowNetwork bool, scopes ...loadSc
 		event.Log(ctx, eventName, labels...)
 	}
 
-	if len(pkgs) == 0 {
+	if len(pkgs) == 0 && len(gopDirs) == 0 {
 		if err == nil {
 			err = errNoPackages
 		}
@@ -154,6 +160,7 @@ func (s *snapshot) load(ctx context.Context, allowNetwork bool, scopes ...loadSc
 	moduleErrs := make(map[string][]packages.Error) // module path -> errors
 	filterer := buildFilterer(s.view.rootURI.Filename(), s.view.gomodcache, s.view.Options())
 	newMetadata := make(map[PackageID]*source.Metadata)
+	gopClasses := s.newGopClassfiles()
 	for _, pkg := range pkgs {
 		// The Go command returns synthetic list results for module queries that
 		// encountered module errors.
@@ -202,10 +209,20 @@ func (s *snapshot) load(ctx context.Context, allowNetwork bool, scopes ...loadSc
 		if s.view.allFilesExcluded(pkg, filterer) {
 			continue
 		}
-		if err := buildMetadata(ctx, pkg, cfg, query, newMetadata, nil); err != nil {
+		if err := buildMetadata(ctx, pkg, cfg, query, newMetadata, gopClasses, nil); err != nil {
 			return err
 		}
 	}
+	s.buildGopMetadata(ctx, gopDirs, cfg, gopClasses, newMetadata)
+	for _, classes := range gopClasses.byDir {
+		s.view.addGopClassExts(classes)
+	}
+	if len(newMetadata) == 0 && len(pkgs) == 0 {
+		if err == nil {
+			err = errNoPackages
+		}
+		return fmt.Errorf("packages.Load error: %w", err)
+	}
 
 	s.mu.Lock()
 
@@ -467,7 +484,7 @@ func makeWorkspaceDir(ctx context.Context, workspace *workspace, fs source.FileS
 // buildMetadata populates the updates map with metadata updates to
 // apply, based on the given pkg. It recurs through pkg.Imports to ensure that
 // metadata exists for all dependencies.
-func buildMetadata(ctx context.Context, pkg *packages.Package, cfg *packages.Config, query []string, updates map[PackageID]*source.Metadata, path []PackageID) error {
+func buildMetadata(ctx context.Context, pkg *packages.Package, cfg *packages.Config, query []string, updates map[PackageID]*source.Metadata, gopClasses *gopClassfiles, path []PackageID) error {
 	// Allow for multiple ad-hoc packages in the workspace (see #47584).
 	pkgPath := PackagePath(pkg.PkgPath)
 	id := PackageID(pkg.ID)
@@ -529,6 +546,19 @@ func buildMetadata(ctx context.Context, pkg *packages.Package, cfg *packages.Con
 		uri := span.URIFromPath(filename)
 		m.GoFiles = append(m.GoFiles, uri)
 	}
+	// Go+ files are invisible to go list, so look for them alongside the Go
+	// files of packages in the workspace modules. Dependencies are consumed
+	// through their generated Go code (gop_autogen.go) and are not scanned.
+	// As with _test.go files, Go+ test files belong to the test variants
+	// only.
+	if pkg.Module != nil && gopClasses.workspaceModule(pkg.Module) && len(pkg.GoFiles) > 0 {
+		m.GopClasses = gopClasses.get(ctx, pkg.Module.Dir)
+		files, tests := gopFilesInDir(filepath.Dir(pkg.GoFiles[0]), m.GopClasses)
+		m.GopFiles = files
+		if m.ForTest != "" {
+			m.GopFiles = append(m.GopFiles, tests...)
+		}
+	}
 
 	depsByImpPath := make(map[ImportPath]PackageID)
 	depsByPkgPath := make(map[PackagePath]PackageID)
@@ -608,7 +638,7 @@ func buildMetadata(ctx context.Context, pkg *packages.Package, cfg *packages.Con
 
 		depsByImpPath[importPath] = PackageID(imported.ID)
 		depsByPkgPath[PackagePath(imported.PkgPath)] = PackageID(imported.ID)
-		if err := buildMetadata(ctx, imported, cfg, query, updates, append(path, id)); err != nil {
+		if err := buildMetadata(ctx, imported, cfg, query, updates, gopClasses, append(path, id)); err != nil {
 			event.Error(ctx, "error in dependency", err)
 		}
 	}
@@ -618,6 +648,86 @@ func buildMetadata(ctx context.Context, pkg *packages.Package, cfg *packages.Con
 	return nil
 }
 
+// gopFilesInDir returns the URIs of the Go+ source files in dir, including
+// the classfiles registered by gop.mod, skipping files whose names begin
+// with '_' as the gop toolchain does. Test files, such as foo_test.gop, are
+// returned separately.
+func gopFilesInDir(dir string, classes map[string]*gopmod.Class) (files, tests []span.URI) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, "_") {
+			continue
+		}
+		if ext := filepath.Ext(name); !source.IsGopExt(ext) && classes[ext] == nil {
+			continue
+		}
+		uri := span.URIFromPath(filepath.Join(dir, name))
+		if cl.IsTestFile(name) {
+			tests = append(tests, uri)
+		} else {
+			files = append(files, uri)
+		}
+	}
+	return files, tests
+}
+
+// gopClassfiles records the classfiles registered by the gop.mod files of
+// the workspace modules seen by a load, by module directory, so that each
+// gop.mod is read once per load.
+type gopClassfiles struct {
+	modFiles map[span.URI]struct{} // the active go.mod files of the snapshot
+	byDir    map[string]map[string]*gopmod.Class
+}
+
+func (s *snapshot) newGopClassfiles() *gopClassfiles {
+	return &gopClassfiles{
+		modFiles: s.workspace.ActiveModFiles(),
+		byDir:    make(map[string]map[string]*gopmod.Class),
+	}
+}
+
+// workspaceModule reports whether mod is a module of the workspace, whose
+// Go+ files are loaded. As in containsPackageLocked, a module of the
+// workspace need not be a main module of go list, such as in experimental
+// workspace module mode.
+func (c *gopClassfiles) workspaceModule(mod *packages.Module) bool {
+	if mod.Main {
+		return true
+	}
+	_, ok := c.modFiles[span.URIFromPath(mod.GoMod)]
+	return ok
+}
+
+// get returns the classfiles registered by the gop.mod of the module in
+// modDir, by extension. Without a gop.mod, or if some registrations can't
+// be resolved, it falls back to the classfiles gop knows by default.
+func (c *gopClassfiles) get(ctx context.Context, modDir string) map[string]*gopmod.Class {
+	if classes, ok := c.byDir[modDir]; ok {
+		return classes
+	}
+	classes := make(map[string]*gopmod.Class)
+	register := func(class *gopmod.Class) {
+		classes[class.ProjExt] = class
+		if class.WorkExt != "" {
+			classes[class.WorkExt] = class
+		}
+	}
+	mod, err := gopmod.Load(modDir, 0)
+	if err == nil {
+		err = mod.RegisterClasses(register)
+	}
+	if err != nil {
+		event.Error(ctx, "registering classfiles", err, tag.Directory.Of(modDir))
+		register(gopmod.ClassSpx)
+	}
+	c.byDir[modDir] = classes
+	return classes
+}
+
 // containsPackageLocked reports whether p is a workspace package for the
 // snapshot s.
 //
@@ -644,6 +754,9 @@ func containsPackageLocked(s *snapshot, m *source.Metadata) bool {
 		for _, uri := range m.GoFiles {
 			uris[uri] = struct{}{}
 		}
+		for _, uri := range m.GopFiles {
+			uris[uri] = struct{}{}
+		}
 
 		filterFunc := s.view.filterFunc()
 		for uri := range uris {
@@ -671,6 +784,9 @@ func containsOpenFileLocked(s *snapshot, m *source.Metadata) bool {
 	for _, uri := range m.GoFiles {
 		uris[uri] = struct{}{}
 	}
+	for _, uri := range m.GopFiles {
+		uris[uri] = struct{}{}
+	}
 
 	for uri := range uris {
 		if s.isOpenLocked(uri) {
@@ -692,6 +808,9 @@ func containsFileInWorkspaceLocked(s *snapshot, m *source.Metadata) bool {
 	for _, uri := range m.GoFiles {
 		uris[uri] = struct{}{}
 	}
+	for _, uri := range m.GopFiles {
+		uris[uri] = struct{}{}
+	}
 
 	for uri := range uris {
 		// In order for a package to be considered for the workspace, at least one
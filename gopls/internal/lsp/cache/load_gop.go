@@ -0,0 +1,392 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/packages"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+)
+
+// gopLoadDirs returns the directories of the main modules that may hold
+// packages of Go+ files only, which go list doesn't see, for the given load
+// scopes, along with their modules:
+//   - the directory of a Go+ file;
+//   - the directory of a package path, in case it has no Go files;
+//   - all the directories of the modules of the workspace.
+func (s *snapshot) gopLoadDirs(ctx context.Context, scopes []loadScope) map[string]*packages.Module {
+	mods := s.gopModules(ctx)
+	if len(mods) == 0 {
+		return nil
+	}
+	moduleOf := func(dir string) *packages.Module {
+		var mod *packages.Module
+		for _, m := range mods {
+			if source.InDir(m.Dir, dir) && (mod == nil || len(m.Dir) > len(mod.Dir)) {
+				mod = m
+			}
+		}
+		return mod
+	}
+
+	dirs := make(map[string]*packages.Module)
+	for _, scope := range scopes {
+		switch scope := scope.(type) {
+		case fileLoadScope:
+			uri := span.URI(scope)
+			if fh := s.FindFile(uri); fh == nil || s.View().FileKind(fh) != source.Gop {
+				continue
+			}
+			dir := filepath.Dir(uri.Filename())
+			if mod := moduleOf(dir); mod != nil {
+				dirs[dir] = mod
+			}
+
+		case packageLoadScope:
+			pkgPath := string(scope)
+			for _, mod := range mods {
+				if pkgPath == mod.Path || strings.HasPrefix(pkgPath, mod.Path+"/") {
+					dir := filepath.Join(mod.Dir, filepath.FromSlash(strings.TrimPrefix(pkgPath, mod.Path)))
+					if moduleOf(dir) == mod {
+						dirs[dir] = mod
+					}
+				}
+			}
+
+		case moduleLoadScope, viewLoadScope:
+			filterFunc := s.view.filterFunc()
+			for _, mod := range mods {
+				if modPath, ok := scope.(moduleLoadScope); ok && string(modPath) != mod.Path {
+					continue
+				}
+				mod := mod
+				filepath.Walk(mod.Dir, func(path string, info os.FileInfo, err error) error {
+					if err != nil || !info.IsDir() {
+						return nil
+					}
+					// Skip the directories that the go command ignores in
+					// patterns such as ./..., and nested modules.
+					name := info.Name()
+					if path != mod.Dir {
+						if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+							return filepath.SkipDir
+						}
+						if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+							return filepath.SkipDir
+						}
+					}
+					if filterFunc(span.URIFromPath(path)) {
+						return filepath.SkipDir
+					}
+					dirs[path] = mod
+					return nil
+				})
+			}
+		}
+	}
+	return dirs
+}
+
+// gopModules returns the active modules of the snapshot, as go list would
+// report them, read from their go.mod files.
+func (s *snapshot) gopModules(ctx context.Context) []*packages.Module {
+	var mods []*packages.Module
+	for modURI := range s.workspace.ActiveModFiles() {
+		fh, err := s.GetFile(ctx, modURI)
+		if err != nil {
+			continue
+		}
+		pm, _ := s.ParseMod(ctx, fh) // partial results are enough
+		if pm == nil || pm.File == nil || pm.File.Module == nil {
+			continue
+		}
+		mod := &packages.Module{
+			Path:  pm.File.Module.Mod.Path,
+			Main:  true,
+			Dir:   filepath.Dir(modURI.Filename()),
+			GoMod: modURI.Filename(),
+		}
+		if pm.File.Go != nil {
+			mod.GoVersion = pm.File.Go.Version
+		}
+		mods = append(mods, mod)
+	}
+	sort.Slice(mods, func(i, j int) bool { return mods[i].Dir < mods[j].Dir })
+	return mods
+}
+
+// buildGopMetadata adds to updates the metadata that go list can't report
+// for Go+ files:
+//   - the packages of the directories in dirs that hold Go+ files but no Go
+//     files, along with their test variants;
+//   - the test variants of the packages in updates whose only test files
+//     are Go+ test files;
+//   - the dependencies of the Go+ files of the packages in updates (see
+//     addGopDeps).
+func (s *snapshot) buildGopMetadata(ctx context.Context, dirs map[string]*packages.Module, cfg *packages.Config, gopClasses *gopClassfiles, updates map[PackageID]*source.Metadata) {
+	var sizes types.Sizes
+	for _, m := range updates {
+		if m.TypesSizes != nil {
+			sizes = m.TypesSizes
+			break
+		}
+	}
+	if sizes == nil {
+		sizes = types.SizesFor("gc", runtime.GOARCH)
+	}
+
+	var plain []*source.Metadata // the packages whose test variants may be missing
+	for _, m := range updates {
+		if m.ForTest == "" && m.GopClasses != nil && len(m.GoFiles) > 0 {
+			plain = append(plain, m)
+		}
+	}
+	for _, m := range plain {
+		_, tests := gopFilesInDir(filepath.Dir(m.GoFiles[0].Filename()), m.GopClasses)
+		s.addGopTestMetadata(ctx, m, tests, updates)
+	}
+
+	for dir, mod := range dirs {
+		if gopHasGoFiles(dir) {
+			continue // go list reports it
+		}
+		classes := gopClasses.get(ctx, mod.Dir)
+		files, tests := gopFilesInDir(dir, classes)
+		if len(files) == 0 && len(tests) == 0 {
+			continue
+		}
+		pkgPath := mod.Path
+		if rel, err := filepath.Rel(mod.Dir, dir); err == nil && rel != "." {
+			pkgPath = path.Join(mod.Path, filepath.ToSlash(rel))
+		}
+		name := "main"
+		if len(files) > 0 {
+			name = s.gopPackageName(ctx, files[0])
+		} else {
+			name = strings.TrimSuffix(s.gopPackageName(ctx, tests[0]), "_test")
+		}
+		m := &source.Metadata{
+			ID:         PackageID(pkgPath),
+			PkgPath:    PackagePath(pkgPath),
+			Name:       PackageName(name),
+			TypesSizes: sizes,
+			Config:     cfg,
+			Module:     mod,
+			GopFiles:   files,
+			GopClasses: classes,
+		}
+		updates[m.ID] = m
+		s.addGopTestMetadata(ctx, m, tests, updates)
+	}
+	s.addGopDeps(ctx, updates)
+}
+
+// addGopDeps adds to the dependencies of the packages of updates the
+// packages that their Go+ files import, explicitly or as classfiles, and go
+// list doesn't report for the Go files, so that typeCheckGop shares them with the Go files and their
+// changes invalidate the Go+ files. Only the packages with Go files known
+// to the snapshot or to updates are added; the Go+ compiler imports the
+// others itself.
+func (s *snapshot) addGopDeps(ctx context.Context, updates map[PackageID]*source.Metadata) {
+	s.mu.Lock()
+	g := s.meta
+	s.mu.Unlock()
+	lookup := func(id PackageID) *source.Metadata {
+		if m := updates[id]; m != nil {
+			return m
+		}
+		return g.metadata[id]
+	}
+	byPkgPath := make(map[PackagePath]PackageID)
+	for _, metadata := range []map[PackageID]*source.Metadata{g.metadata, updates} {
+		for id, m := range metadata {
+			if m.ForTest == "" && len(m.CompiledGoFiles) > 0 {
+				byPkgPath[m.PkgPath] = id
+			}
+		}
+	}
+	// reaches reports whether from depends on to, which would make a cycle
+	// of a dependency of to on from.
+	reaches := func(from, to PackageID) bool {
+		seen := make(map[PackageID]bool)
+		var visit func(id PackageID) bool
+		visit = func(id PackageID) bool {
+			if id == to {
+				return true
+			}
+			if seen[id] {
+				return false
+			}
+			seen[id] = true
+			if m := lookup(id); m != nil {
+				for _, dep := range m.DepsByPkgPath {
+					if visit(dep) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+		return visit(from)
+	}
+
+	var ids []PackageID // for determinism
+	for id, m := range updates {
+		if len(m.GopFiles) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		m := updates[id]
+		var depsByImpPath map[ImportPath]PackageID
+		var depsByPkgPath map[PackagePath]PackageID
+		paths := s.gopImports(ctx, m.GopFiles)
+		for _, uri := range m.GopFiles {
+			if class := m.GopClasses[filepath.Ext(uri.Filename())]; class != nil {
+				paths = append(paths, class.PkgPaths...)
+			}
+		}
+		for _, path := range paths {
+			if _, ok := m.DepsByImpPath[ImportPath(path)]; ok {
+				continue
+			}
+			dep, ok := byPkgPath[PackagePath(path)]
+			if !ok || reaches(dep, m.ID) {
+				continue
+			}
+			// Test variants share the maps of the package they copy.
+			if depsByImpPath == nil {
+				depsByImpPath = make(map[ImportPath]PackageID)
+				for k, v := range m.DepsByImpPath {
+					depsByImpPath[k] = v
+				}
+				depsByPkgPath = make(map[PackagePath]PackageID)
+				for k, v := range m.DepsByPkgPath {
+					depsByPkgPath[k] = v
+				}
+			}
+			depsByImpPath[ImportPath(path)] = dep
+			depsByPkgPath[PackagePath(path)] = dep
+		}
+		if depsByImpPath != nil {
+			m.DepsByImpPath, m.DepsByPkgPath = depsByImpPath, depsByPkgPath
+		}
+	}
+}
+
+// gopImports returns the sorted import paths of the Go+ files uris.
+func (s *snapshot) gopImports(ctx context.Context, uris []span.URI) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, uri := range uris {
+		fh, err := s.GetFile(ctx, uri)
+		if err != nil {
+			continue
+		}
+		src, err := fh.Read()
+		if err != nil {
+			continue
+		}
+		f, _ := parser.ParseFSFile(token.NewFileSet(), nil, uri.Filename(), src, parser.ImportsOnly)
+		if f == nil {
+			continue
+		}
+		for _, imp := range f.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err != nil || seen[path] {
+				continue
+			}
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// addGopTestMetadata adds to updates the test variants of the package m
+// for its Go+ test files, unless go list has reported them: the package
+// augmented with its tests, as "p [p.test]", and, if some of the tests are
+// in the external test package, "p_test [p.test]".
+func (s *snapshot) addGopTestMetadata(ctx context.Context, m *source.Metadata, tests []span.URI, updates map[PackageID]*source.Metadata) {
+	if len(tests) == 0 {
+		return
+	}
+	testID := PackageID(fmt.Sprintf("%s [%s.test]", m.PkgPath, m.PkgPath))
+	if updates[testID] == nil {
+		test := *m
+		test.ID = testID
+		test.ForTest = m.PkgPath
+		test.GopFiles = append(m.GopFiles[:len(m.GopFiles):len(m.GopFiles)], tests...)
+		updates[testID] = &test
+	}
+
+	// As with the Go files, typeCheckGop only compiles the files of the
+	// package name of each variant.
+	xtestID := PackageID(fmt.Sprintf("%s_test [%s.test]", m.PkgPath, m.PkgPath))
+	if updates[xtestID] != nil {
+		return
+	}
+	for _, uri := range tests {
+		if s.gopPackageName(ctx, uri) == string(m.Name)+"_test" {
+			xtest := *m
+			xtest.ID = xtestID
+			xtest.PkgPath = m.PkgPath + "_test"
+			xtest.Name = m.Name + "_test"
+			xtest.ForTest = m.PkgPath
+			xtest.GoFiles, xtest.CompiledGoFiles = nil, nil
+			xtest.GopFiles = tests
+			updates[xtestID] = &xtest
+			return
+		}
+	}
+}
+
+// gopPackageName returns the package name of the Go+ file uri: main if it
+// has no package clause.
+func (s *snapshot) gopPackageName(ctx context.Context, uri span.URI) string {
+	fh, err := s.GetFile(ctx, uri)
+	if err != nil {
+		return "main"
+	}
+	src, err := fh.Read()
+	if err != nil {
+		return "main"
+	}
+	f, _ := parser.ParseFSFile(token.NewFileSet(), nil, uri.Filename(), src, parser.PackageClauseOnly)
+	if f == nil || f.Name == nil {
+		return "main"
+	}
+	return f.Name.Name
+}
+
+// gopHasGoFiles reports whether dir holds Go files, making it a directory
+// of go list.
+func gopHasGoFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
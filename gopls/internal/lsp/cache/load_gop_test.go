@@ -0,0 +1,47 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/goplus/mod/gopmod"
+)
+
+func TestGopFilesInDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "a.gop", "a_test.gop", "_b.gop", "Kai.spx", "Kai_test.spx", "game.yap", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := func(uris []span.URI) []string {
+		var names []string
+		for _, uri := range uris {
+			names = append(names, filepath.Base(uri.Filename()))
+		}
+		return names
+	}
+
+	for _, test := range []struct {
+		classes      map[string]*gopmod.Class
+		files, tests []string
+	}{
+		{nil, []string{"Kai.spx", "a.gop"}, []string{"Kai_test.spx", "a_test.gop"}},
+		{map[string]*gopmod.Class{".yap": {ProjExt: ".yap"}}, []string{"Kai.spx", "a.gop", "game.yap"}, []string{"Kai_test.spx", "a_test.gop"}},
+	} {
+		files, tests := gopFilesInDir(dir, test.classes)
+		if got := base(files); fmt.Sprint(got) != fmt.Sprint(test.files) {
+			t.Errorf("gopFilesInDir(%v): got files %v, want %v", test.classes, got, test.files)
+		}
+		if got := base(tests); fmt.Sprint(got) != fmt.Sprint(test.tests) {
+			t.Errorf("gopFilesInDir(%v): got tests %v, want %v", test.classes, got, test.tests)
+		}
+	}
+}
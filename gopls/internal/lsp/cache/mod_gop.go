@@ -0,0 +1,155 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event/tag"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/memoize"
+	gopmodfile "github.com/goplus/mod/modfile"
+	qerrors "github.com/qiniu/x/errors"
+	"golang.org/x/mod/modfile"
+)
+
+// GopModFiles returns the gop.mod files next to the active go.mod files of
+// the snapshot.
+func (s *snapshot) GopModFiles(ctx context.Context) []span.URI {
+	var uris []span.URI
+	for _, modURI := range s.ModFiles() {
+		uri := span.URIFromPath(gopModFilename(modURI))
+		// As in goSum, avoid adding nonexistent file handles to the snapshot.
+		var fh source.FileHandle = s.FindFile(uri)
+		if fh == nil {
+			var err error
+			fh, err = s.view.cache.getFile(ctx, uri)
+			if err != nil {
+				continue
+			}
+		}
+		if _, err := fh.Read(); err == nil {
+			uris = append(uris, uri)
+		}
+	}
+	sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+	return uris
+}
+
+// gopModFilename returns the name of the gop.mod file next to the go.mod
+// file at modURI.
+func gopModFilename(modURI span.URI) string {
+	return filepath.Join(filepath.Dir(modURI.Filename()), "gop.mod")
+}
+
+// ParseGopMod parses a gop.mod file, using a cache. It may return partial results and an error.
+func (s *snapshot) ParseGopMod(ctx context.Context, fh source.FileHandle) (*source.ParsedGopModule, error) {
+	uri := fh.URI()
+
+	s.mu.Lock()
+	entry, hit := s.parseGopModHandles.Get(uri)
+	s.mu.Unlock()
+
+	type parseGopModResult struct {
+		parsed *source.ParsedGopModule
+		err    error
+	}
+
+	// cache miss?
+	if !hit {
+		promise, release := s.store.Promise(fh.FileIdentity(), func(ctx context.Context, _ interface{}) interface{} {
+			parsed, err := parseGopModImpl(ctx, fh)
+			return parseGopModResult{parsed, err}
+		})
+
+		entry = promise
+		s.mu.Lock()
+		s.parseGopModHandles.Set(uri, entry, func(_, _ interface{}) { release() })
+		s.mu.Unlock()
+	}
+
+	// Await result.
+	v, err := s.awaitPromise(ctx, entry.(*memoize.Promise))
+	if err != nil {
+		return nil, err
+	}
+	res := v.(parseGopModResult)
+	return res.parsed, res.err
+}
+
+// parseGopModImpl parses the gop.mod file whose name and contents are in fh.
+// It may return partial results and an error.
+func parseGopModImpl(ctx context.Context, fh source.FileHandle) (*source.ParsedGopModule, error) {
+	_, done := event.Start(ctx, "cache.ParseGopMod", tag.URI.Of(fh.URI()))
+	defer done()
+
+	contents, err := fh.Read()
+	if err != nil {
+		return nil, err
+	}
+	m := protocol.NewColumnMapper(fh.URI(), contents)
+	// Like gop, accept versions as they are written.
+	file, parseErr := gopmodfile.Parse(fh.URI().Filename(), contents, func(path, vers string) (string, error) {
+		return vers, nil
+	})
+	// Attempt to convert the error to a standardized parse error. Syntax errors
+	// are reported by x/mod, and errors in the Go+ directives by goplus/mod.
+	var parseErrors []*source.Diagnostic
+	addError := func(pos modfile.Position, msg string) error {
+		rng, err := m.OffsetRange(pos.Byte, pos.Byte)
+		if err != nil {
+			return err
+		}
+		parseErrors = append(parseErrors, &source.Diagnostic{
+			URI:      fh.URI(),
+			Range:    rng,
+			Severity: protocol.SeverityError,
+			Source:   source.ParseError,
+			Message:  msg,
+		})
+		return nil
+	}
+	if parseErr != nil {
+		var mfErrList modfile.ErrorList
+		var gopErrList gopmodfile.ErrorList
+		switch {
+		case errors.As(parseErr, &mfErrList):
+			for _, mfErr := range mfErrList {
+				if err := addError(mfErr.Pos, mfErr.Err.Error()); err != nil {
+					return nil, err
+				}
+			}
+		case errors.As(parseErr, &gopErrList):
+			for _, e := range gopErrList {
+				var gopErr *gopmodfile.Error
+				if !errors.As(e, &gopErr) {
+					return nil, fmt.Errorf("unexpected parse error type %v", e)
+				}
+				msg := qerrors.Summary(gopErr.Err)
+				if gopErr.ModPath != "" {
+					msg = fmt.Sprintf("%s %s: %s", gopErr.Verb, gopErr.ModPath, msg)
+				}
+				if err := addError(gopErr.Pos, msg); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unexpected parse error type %v", parseErr)
+		}
+	}
+	return &source.ParsedGopModule{
+		URI:         fh.URI(),
+		Mapper:      m,
+		File:        file,
+		ParseErrors: parseErrors,
+	}, parseErr
+}
@@ -0,0 +1,42 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+)
+
+func TestParseGopMod(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		want []string // the parse errors, as "line:col: message"
+	}{
+		{"module example.com/foo\n\ngop 1.1\n\nregister example.com/game\n", nil},
+		{"module example.com/foo\n\nrequire example.com/bar\n", []string{"3:1: usage: require module/path v1.2.3"}},
+		{"module example.com/foo\n\nclassfile .gamex\n", []string{"3:1: usage: classfile projExt workExt classFilePkgPath ..."}},
+		{"module example.com/foo\n\ngop 1.1\ngop 1.2\nregister a b\n", []string{"4:1: repeated go statement", "5:1: register directive expects exactly one argument"}},
+		{"module example.com/foo\n\nclassfile .gamex .sprite \"example.com/game\"\nunknown\n", []string{"4:1: unknown directive: unknown"}},
+	} {
+		fh := &fileHandle{uri: span.URIFromPath("/foo/gop.mod"), bytes: []byte(test.src)}
+		pm, err := parseGopModImpl(context.Background(), fh)
+		if (err != nil) != (test.want != nil) {
+			t.Errorf("parseGopModImpl(%q): got error %v", test.src, err)
+		}
+		if pm == nil {
+			t.Fatalf("parseGopModImpl(%q): no result", test.src)
+		}
+		var got []string
+		for _, d := range pm.ParseErrors {
+			got = append(got, fmt.Sprintf("%d:%d: %s", d.Range.Start.Line+1, d.Range.Start.Character+1, d.Message))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("parseGopModImpl(%q): got parse errors %q, want %q", test.src, got, test.want)
+		}
+	}
+}
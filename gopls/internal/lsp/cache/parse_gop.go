@@ -0,0 +1,187 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"path/filepath"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event/tag"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/memoize"
+)
+
+// parseGopKey is the key under which Go+ parse results are stored in the
+// shared memoize.Store. It is distinct from parseKey so that a Go+ file
+// never shares a promise with an attempt to parse it as Go.
+type parseGopKey parseKey
+
+// ParseGop parses the Go+ file whose contents are provided by fh, using a
+// cache. Unlike ParseGo, the resulting tree is never fixed up.
+//
+// Token position information will be added to the snapshot's FileSet.
+//
+// The parser mode must be ParseHeader or ParseFull: Go+ files are never
+// trimmed for type checking, since the compiler needs their bodies to
+// infer the types of lambdas and comprehensions.
+func (s *snapshot) ParseGop(ctx context.Context, fh source.FileHandle, mode source.ParseMode) (*source.ParsedGopFile, error) {
+	if mode == source.ParseExported {
+		panic("Go+ files cannot be parsed in Exported mode")
+	}
+
+	key := parseKey{
+		file: fh.FileIdentity(),
+		mode: mode,
+	}
+
+	s.mu.Lock()
+	entry, hit := s.parsedGopFiles.Get(key)
+	s.mu.Unlock()
+
+	// cache miss?
+	if !hit {
+		promise, release := s.store.Promise(parseGopKey(key), func(ctx context.Context, arg interface{}) interface{} {
+			parsed, err := parseGopImpl(ctx, arg.(*snapshot).FileSet(), fh, mode)
+			return parseGopResult{parsed, err}
+		})
+
+		s.mu.Lock()
+		// Check cache again in case another thread got there first.
+		if prev, ok := s.parsedGopFiles.Get(key); ok {
+			entry = prev
+			release()
+		} else {
+			entry = promise
+			s.parsedGopFiles.Set(key, entry, func(_, _ interface{}) { release() })
+
+			// Record the key so that it is invalidated along with the
+			// parsed Go files of the same URI.
+			keys, _ := s.parseKeysByURI.Get(fh.URI())
+			foundKey := false
+			for _, existing := range keys {
+				if existing == key {
+					foundKey = true
+					break
+				}
+			}
+			if !foundKey {
+				keys = append(keys, key)
+				s.parseKeysByURI.Set(fh.URI(), keys)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	// Await result.
+	v, err := s.awaitPromise(ctx, entry.(*memoize.Promise))
+	if err != nil {
+		return nil, err
+	}
+	res := v.(parseGopResult)
+	return res.parsed, res.err
+}
+
+// parseGopResult holds the result of a call to parseGopImpl.
+type parseGopResult struct {
+	parsed *source.ParsedGopFile
+	err    error
+}
+
+// parseGopImpl parses the Go+ source file whose content is provided by fh.
+func parseGopImpl(ctx context.Context, fset *token.FileSet, fh source.FileHandle, mode source.ParseMode) (*source.ParsedGopFile, error) {
+	ctx, done := event.Start(ctx, "cache.parseGop", tag.File.Of(fh.URI().Filename()))
+	defer done()
+
+	// Besides .gop files and the default classfiles, Go+ files include the
+	// classfiles registered by gop.mod, which may have any extension.
+	ext := filepath.Ext(fh.URI().Filename())
+	if nonGopExts[ext] {
+		return nil, fmt.Errorf("cannot parse non-Go+ file %s", fh.URI())
+	}
+	src, err := fh.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	parserMode := parser.AllErrors | parser.ParseComments
+	if mode == source.ParseHeader {
+		parserMode = parser.ImportsOnly | parser.ParseComments
+	}
+
+	file, err := parser.ParseFSFile(fset, nil, fh.URI().Filename(), src, parserMode)
+	var parseErr scanner.ErrorList
+	if err != nil {
+		// We passed a byte slice, so the only possible error is a parse error.
+		parseErr = err.(scanner.ErrorList)
+	}
+	// ParseFSFile doesn't know about classfiles; only the directory-level
+	// entry points do. Mark the default ones here; the classfiles registered
+	// by gop.mod are marked for the compiler, see gopCompileFile.
+	file.IsProj, file.IsClass = gopClassKind(ext)
+
+	tok := gopTokFile(fset, file)
+	if tok == nil {
+		// The file is empty apart from whitespace, so nothing refers to the
+		// token.File created by the parser. Create one of our own.
+		tok = fset.AddFile(fh.URI().Filename(), -1, len(src))
+		tok.SetLinesForContent(src)
+	}
+
+	return &source.ParsedGopFile{
+		URI:  fh.URI(),
+		Mode: mode,
+		Src:  src,
+		File: file,
+		Tok:  tok,
+		Mapper: &protocol.ColumnMapper{
+			URI:     fh.URI(),
+			TokFile: tok,
+			Content: src,
+		},
+		ParseErr: parseErr,
+	}, nil
+}
+
+// gopTokFile returns the token.File the parser created for file.
+//
+// Go+ files may omit the package clause, in which case file.Pos is invalid
+// and the file must be found through its first declaration or comment.
+func gopTokFile(fset *token.FileSet, file *gopast.File) *token.File {
+	pos := file.Pos()
+	if !pos.IsValid() && len(file.Decls) > 0 {
+		pos = file.Decls[0].Pos()
+	}
+	if !pos.IsValid() && len(file.Comments) > 0 {
+		pos = file.Comments[0].Pos()
+	}
+	if !pos.IsValid() {
+		return nil
+	}
+	return fset.File(pos)
+}
+
+// nonGopExts are the extensions of the files that gopls knows not to be Go+
+// files.
+var nonGopExts = map[string]bool{".go": true, ".mod": true, ".sum": true, ".work": true}
+
+// gopClassKind reports whether a Go+ file with the given extension is a
+// project classfile or a (work) classfile, using the default classfile
+// registrations of the gop toolchain.
+func gopClassKind(ext string) (isProj, isClass bool) {
+	switch ext {
+	case ".gmx":
+		return true, true
+	case ".spx":
+		return false, true
+	}
+	return false, false
+}
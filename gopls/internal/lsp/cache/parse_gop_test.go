@@ -0,0 +1,60 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"go/token"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+)
+
+func TestParseGop(t *testing.T) {
+	tests := []struct {
+		filename        string
+		src             string
+		mode            source.ParseMode
+		wantErr         bool
+		wantDecls       int
+		isProj, isClass bool
+	}{
+		{"a.gop", "println \"hello\"\n", source.ParseFull, false, 1, false, false},
+		{"a.gop", "package a\n\nimport \"fmt\"\n\nfunc f() { fmt.Println(1) }\n", source.ParseHeader, false, 1, false, false},
+		{"a.gop", "package a\n\nfunc f() {\n", source.ParseFull, true, 1, false, false},
+		{"a.gop", "", source.ParseFull, false, 0, false, false},
+		{"Game.gmx", "var (\n\tx int\n)\n", source.ParseFull, false, 1, true, true},
+		{"Sprite.spx", "onStart => {\n}\n", source.ParseFull, false, 1, false, true},
+	}
+
+	for _, test := range tests {
+		fh := &fileHandle{
+			uri:   span.URIFromPath("/tmp/" + test.filename),
+			bytes: []byte(test.src),
+		}
+		pgf, err := parseGopImpl(context.Background(), token.NewFileSet(), fh, test.mode)
+		if err != nil {
+			t.Fatalf("parseGopImpl(%q): %v", test.src, err)
+		}
+		if gotErr := pgf.ParseErr != nil; gotErr != test.wantErr {
+			t.Errorf("parseGopImpl(%q): got parse error %v, want error: %t", test.src, pgf.ParseErr, test.wantErr)
+		}
+		if got := len(pgf.File.Decls); got != test.wantDecls {
+			t.Errorf("parseGopImpl(%q): got %d decls, want %d", test.src, got, test.wantDecls)
+		}
+		if pgf.File.IsProj != test.isProj || pgf.File.IsClass != test.isClass {
+			t.Errorf("parseGopImpl(%s): got IsProj=%t IsClass=%t, want %t %t", test.filename, pgf.File.IsProj, pgf.File.IsClass, test.isProj, test.isClass)
+		}
+		if pgf.Tok == nil || pgf.Tok.Size() != len(test.src) {
+			t.Errorf("parseGopImpl(%q): token.File does not cover the source", test.src)
+		}
+	}
+
+	fh := &fileHandle{uri: span.URIFromPath("/tmp/a.go")}
+	if _, err := parseGopImpl(context.Background(), token.NewFileSet(), fh, source.ParseFull); err == nil {
+		t.Errorf("parseGopImpl(a.go) succeeded, want error")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"go/token"
 	"go/types"
 
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/memoize"
@@ -32,6 +33,7 @@ type pkg struct {
 	fset            *token.FileSet // for now, same as the snapshot's FileSet
 	goFiles         []*source.ParsedGoFile
 	compiledGoFiles []*source.ParsedGoFile
+	gopFiles        []*source.ParsedGopFile
 	diagnostics     []*source.Diagnostic
 	deps            map[PackageID]*pkg // use m.DepsBy{Pkg,Imp}Path to look up ID
 	version         *module.Version    // may be nil; may differ from m.Module.Version
@@ -39,7 +41,14 @@ type pkg struct {
 	typeErrors      []types.Error
 	types           *types.Package
 	typesInfo       *types.Info
-	hasFixedFiles   bool // if true, AST was sufficiently mangled that we should hide type errors
+	gopTypes        *types.Package               // nil unless the Go+ files were type-checked
+	gopTypesInfo    *typesutil.Info              // nil unless the Go+ files were type-checked
+	gopBuiltins     *types.Scope                 // nil unless the Go+ files were type-checked
+	gopClasses      map[span.URI]*types.TypeName // by classfile, nil unless the Go+ files were type-checked
+	gopParseErrors  []scanner.ErrorList
+	gopTypeErrors   []error
+	gopCompiled     *gopCompiledPackage // nil unless the Go+ files compiled without errors
+	hasFixedFiles   bool                // if true, AST was sufficiently mangled that we should hide type errors
 
 	analyses memoize.Store // maps analyzer.Name to Promise[actionResult]
 }
@@ -90,6 +99,19 @@ func (p *pkg) File(uri span.URI) (*source.ParsedGoFile, error) {
 	return nil, fmt.Errorf("no parsed file for %s in %v", uri, p.m.ID)
 }
 
+func (p *pkg) GopFiles() []*source.ParsedGopFile {
+	return p.gopFiles
+}
+
+func (p *pkg) GopFile(uri span.URI) (*source.ParsedGopFile, error) {
+	for _, pgf := range p.gopFiles {
+		if pgf.URI == uri {
+			return pgf, nil
+		}
+	}
+	return nil, fmt.Errorf("no parsed Go+ file for %s in %v", uri, p.m.ID)
+}
+
 func (p *pkg) GetSyntax() []*ast.File {
 	var syntax []*ast.File
 	for _, pgf := range p.compiledGoFiles {
@@ -110,6 +132,32 @@ func (p *pkg) GetTypesInfo() *types.Info {
 	return p.typesInfo
 }
 
+func (p *pkg) GetGopTypes() *types.Package {
+	return p.gopTypes
+}
+
+func (p *pkg) GetGopTypesInfo() *typesutil.Info {
+	return p.gopTypesInfo
+}
+
+func (p *pkg) GetGopBuiltins() *types.Scope {
+	return p.gopBuiltins
+}
+
+func (p *pkg) GopClass(uri span.URI) *types.TypeName {
+	return p.gopClasses[uri]
+}
+
+func (p *pkg) GopGeneratedGo() (map[string][]byte, error) {
+	if p.gopCompiled == nil {
+		if len(p.gopTypeErrors) > 0 {
+			return nil, fmt.Errorf("the Go+ files of %s do not compile: %v", p.m.PkgPath, p.gopTypeErrors[0])
+		}
+		return nil, fmt.Errorf("%s has no Go+ files", p.m.PkgPath)
+	}
+	return p.gopCompiled.print()
+}
+
 func (p *pkg) GetTypesSizes() types.Sizes {
 	return p.m.TypesSizes
 }
@@ -154,6 +202,11 @@ func (p *pkg) Version() *module.Version {
 	return p.version
 }
 
+// HasListOrParseErrors and HasTypeErrors only report the errors of the Go
+// files: the errors of the Go+ files don't prevent the analysis of the Go
+// files, and the Go+ files are only analyzed if they compile (see
+// gopActionImpl).
+
 func (p *pkg) HasListOrParseErrors() bool {
 	return len(p.m.Errors) != 0 || len(p.parseErrors) != 0
 }
@@ -270,6 +270,7 @@ func (s *Session) createView(ctx context.Context, name string, folder span.URI,
 		files:                newFilesMap(),
 		isActivePackageCache: newIsActivePackageCacheMap(),
 		parsedGoFiles:        persistent.NewMap(parseKeyLessInterface),
+		parsedGopFiles:       persistent.NewMap(parseKeyLessInterface),
 		parseKeysByURI:       newParseKeysByURIMap(),
 		symbolizeHandles:     persistent.NewMap(uriLessInterface),
 		actions:              persistent.NewMap(actionKeyLessInterface),
@@ -277,6 +278,7 @@ func (s *Session) createView(ctx context.Context, name string, folder span.URI,
 		unloadableFiles:      make(map[span.URI]struct{}),
 		parseModHandles:      persistent.NewMap(uriLessInterface),
 		parseWorkHandles:     persistent.NewMap(uriLessInterface),
+		parseGopModHandles:   persistent.NewMap(uriLessInterface),
 		modTidyHandles:       persistent.NewMap(uriLessInterface),
 		modVulnHandles:       persistent.NewMap(uriLessInterface),
 		modWhyHandles:        persistent.NewMap(uriLessInterface),
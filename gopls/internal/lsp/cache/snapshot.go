@@ -86,7 +86,10 @@ type snapshot struct {
 	// parsedGoFiles maps a parseKey to the handle of the future result of parsing it.
 	parsedGoFiles *persistent.Map // from parseKey to *memoize.Promise[parseGoResult]
 
-	// parseKeysByURI records the set of keys of parsedGoFiles that
+	// parsedGopFiles is the analogue of parsedGoFiles for Go+ files.
+	parsedGopFiles *persistent.Map // from parseKey to *memoize.Promise[parseGopResult]
+
+	// parseKeysByURI records the set of keys of parsedGoFiles and parsedGopFiles that
 	// need to be invalidated for each URI.
 	// TODO(adonovan): opt: parseKey = ParseMode + URI, so this could
 	// be just a set of ParseModes, or we could loop over AllParseModes.
@@ -135,6 +138,10 @@ type snapshot struct {
 	// The handles need not refer to only the view's go.work file.
 	parseWorkHandles *persistent.Map // from span.URI to *memoize.Promise[parseWorkResult]
 
+	// parseGopModHandles keeps track of any parseGopModHandles for the snapshot.
+	// The handles need not refer to only the view's gop.mod files.
+	parseGopModHandles *persistent.Map // from span.URI to *memoize.Promise[parseGopModResult]
+
 	// Preserve go.mod-related handles to avoid garbage-collecting the results
 	// of various calls to the go command. The handles need not refer to only
 	// the view's go.mod file.
@@ -225,11 +232,13 @@ func (s *snapshot) destroy(destroyedBy string) {
 	s.actions.Destroy()
 	s.files.Destroy()
 	s.parsedGoFiles.Destroy()
+	s.parsedGopFiles.Destroy()
 	s.parseKeysByURI.Destroy()
 	s.knownSubdirs.Destroy()
 	s.symbolizeHandles.Destroy()
 	s.parseModHandles.Destroy()
 	s.parseWorkHandles.Destroy()
+	s.parseGopModHandles.Destroy()
 	s.modTidyHandles.Destroy()
 	s.modVulnHandles.Destroy()
 	s.modWhyHandles.Destroy()
@@ -698,7 +707,7 @@ func (s *snapshot) packageHandlesForFile(ctx context.Context, uri span.URI, mode
 	if err != nil {
 		return nil, err
 	}
-	if kind := s.view.FileKind(fh); kind != source.Go {
+	if kind := s.view.FileKind(fh); kind != source.Go && kind != source.Gop {
 		return nil, fmt.Errorf("no packages for non-Go file %s (%v)", uri, kind)
 	}
 	metas, err := s.MetadataForFile(ctx, uri)
@@ -893,10 +902,13 @@ func (s *snapshot) resetIsActivePackageLocked() {
 	s.isActivePackageCache = newIsActivePackageCacheMap()
 }
 
-const fileExtensions = "go,mod,sum,work"
+const fileExtensions = "go,mod,sum,work,gop,gmx,spx"
 
 func (s *snapshot) fileWatchingGlobPatterns(ctx context.Context) map[string]struct{} {
 	extensions := fileExtensions
+	for _, ext := range s.view.gopClassExtensions() {
+		extensions += "," + ext
+	}
 	for _, ext := range s.View().Options().TemplateExtensions {
 		extensions += "," + ext
 	}
@@ -1104,11 +1116,11 @@ func (s *snapshot) activePackageHandles(ctx context.Context) ([]*packageHandle,
 
 // Symbols extracts and returns the symbols for each file in all the snapshot's views.
 func (s *snapshot) Symbols(ctx context.Context) map[span.URI][]source.Symbol {
-	// Read the set of Go files out of the snapshot.
+	// Read the set of Go and Go+ files out of the snapshot.
 	var goFiles []source.VersionedFileHandle
 	s.mu.Lock()
 	s.files.Range(func(uri span.URI, f source.VersionedFileHandle) {
-		if s.View().FileKind(f) == source.Go {
+		if kind := s.View().FileKind(f); kind == source.Go || kind == source.Gop {
 			goFiles = append(goFiles, f)
 		}
 	})
@@ -1725,6 +1737,14 @@ func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileC
 			break
 		}
 	}
+	// The classfiles registered by gop.mod are recorded in the metadata of
+	// the packages of its module (see Metadata.GopClasses).
+	for uri := range changes {
+		if filepath.Base(uri.Filename()) == "gop.mod" {
+			reinit = true
+			break
+		}
+	}
 
 	bgCtx, cancel := context.WithCancel(bgCtx)
 	result := &snapshot{
@@ -1742,12 +1762,14 @@ func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileC
 		actions:              s.actions.Clone(),
 		files:                s.files.Clone(),
 		parsedGoFiles:        s.parsedGoFiles.Clone(),
+		parsedGopFiles:       s.parsedGopFiles.Clone(),
 		parseKeysByURI:       s.parseKeysByURI.Clone(),
 		symbolizeHandles:     s.symbolizeHandles.Clone(),
 		workspacePackages:    make(map[PackageID]PackagePath, len(s.workspacePackages)),
 		unloadableFiles:      make(map[span.URI]struct{}, len(s.unloadableFiles)),
 		parseModHandles:      s.parseModHandles.Clone(),
 		parseWorkHandles:     s.parseWorkHandles.Clone(),
+		parseGopModHandles:   s.parseGopModHandles.Clone(),
 		modTidyHandles:       s.modTidyHandles.Clone(),
 		modWhyHandles:        s.modWhyHandles.Clone(),
 		modVulnHandles:       s.modVulnHandles.Clone(),
@@ -1794,6 +1816,7 @@ func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileC
 			if ok {
 				for _, key := range keys {
 					result.parsedGoFiles.Delete(key)
+					result.parsedGopFiles.Delete(key)
 				}
 				result.parseKeysByURI.Delete(uri)
 			}
@@ -1849,6 +1872,14 @@ func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileC
 		var invalidateMetadata, pkgFileChanged, importDeleted bool
 		if strings.HasSuffix(uri.Filename(), ".go") {
 			invalidateMetadata, pkgFileChanged, importDeleted = metadataChanges(ctx, s, originalFH, change.fileHandle)
+		} else if s.view.isGopExt(filepath.Ext(uri.Filename())) && !strings.HasPrefix(filepath.Base(uri.Filename()), "_") {
+			// The go command doesn't see Go+ files, but the set of Go+ files in
+			// a package directory is recorded in its metadata (see GopFiles), so
+			// adding or removing one must reload the package. Whether the
+			// snapshot has read the file before doesn't matter.
+			_, known := s.meta.ids[uri]
+			invalidateMetadata = known != change.exists
+			pkgFileChanged = invalidateMetadata
 		}
 
 		invalidateMetadata = invalidateMetadata || forceReloadMetadata || reinit
@@ -1873,6 +1904,7 @@ func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileC
 
 		result.parseModHandles.Delete(uri)
 		result.parseWorkHandles.Delete(uri)
+		result.parseGopModHandles.Delete(uri)
 		// Handle the invalidated file; it may have new contents or not exist.
 		if !change.exists {
 			result.files.Delete(uri)
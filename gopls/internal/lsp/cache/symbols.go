@@ -34,9 +34,21 @@ func (s *snapshot) symbolize(ctx context.Context, fh source.FileHandle) ([]sourc
 	// Cache miss?
 	if !hit {
 		type symbolHandleKey source.Hash
-		key := symbolHandleKey(fh.FileIdentity().Hash)
+		var key interface{} = symbolHandleKey(fh.FileIdentity().Hash)
+		symbolize := func(snapshot *snapshot) ([]source.Symbol, error) {
+			return symbolizeImpl(snapshot, fh)
+		}
+		if s.view.FileKind(fh) == source.Gop {
+			s.mu.Lock()
+			isProj, isClass := s.gopClassKindLocked(uri)
+			s.mu.Unlock()
+			key = gopSymbolHandleKey{fh.FileIdentity().Hash, isProj, isClass}
+			symbolize = func(*snapshot) ([]source.Symbol, error) {
+				return symbolizeGopImpl(fh, isProj, isClass)
+			}
+		}
 		promise, release := s.store.Promise(key, func(_ context.Context, arg interface{}) interface{} {
-			symbols, err := symbolizeImpl(arg.(*snapshot), fh)
+			symbols, err := symbolize(arg.(*snapshot))
 			return symbolizeResult{symbols, err}
 		})
 
@@ -0,0 +1,247 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	goptoken "github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/lsppos"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+)
+
+// gopSymbolHandleKey is the key under which the symbols of a Go+ file are
+// stored. They depend on whether the file is a classfile as well as on its
+// content.
+type gopSymbolHandleKey struct {
+	hash            source.Hash
+	isProj, isClass bool
+}
+
+// gopClassKindLocked reports whether the Go+ file uri is a project
+// classfile or a classfile, as gopCompileFile does for the package of the
+// file. s.mu must be held.
+func (s *snapshot) gopClassKindLocked(uri span.URI) (isProj, isClass bool) {
+	if ids := s.meta.ids[uri]; len(ids) > 0 {
+		if m := s.meta.metadata[ids[0]]; m != nil && m.GopClasses != nil {
+			return source.GopClassKind(uri.Filename(), nil, m.GopClasses)
+		}
+	}
+	return gopClassKind(filepath.Ext(uri.Filename()))
+}
+
+// symbolizeGopImpl reads and parses a Go+ file and extracts symbols from
+// it. Like symbolizeImpl, it doesn't populate the cache, and it skips
+// comments, which are unnecessary for symbol construction.
+func symbolizeGopImpl(fh source.FileHandle, isProj, isClass bool) ([]source.Symbol, error) {
+	src, err := fh.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFSFile(fset, nil, fh.URI().Filename(), src, 0)
+	if file == nil {
+		return nil, err
+	}
+	fileDesc := gopTokFile(fset, file)
+	if fileDesc == nil { // the file is empty
+		return nil, nil
+	}
+
+	w := &gopSymbolWalker{
+		symbolWalker: symbolWalker{
+			mapper: lsppos.NewTokenMapper(src, fileDesc),
+		},
+	}
+	if isClass {
+		class := cl.ClassNameOf(fh.URI().Filename(), isProj)
+		start := token.Pos(fileDesc.Base())
+		w.atRange(start, start, class, protocol.Class)
+		w.classDecls(file, class, isProj)
+	} else {
+		w.fileDecls(file)
+	}
+
+	return w.symbols, w.firstError
+}
+
+// gopSymbolWalker is a symbolWalker for the syntax of a Go+ file, whose
+// paths of nested identifiers are names.
+type gopSymbolWalker struct {
+	symbolWalker
+}
+
+func (w *gopSymbolWalker) atNode(node gopast.Node, name string, kind protocol.SymbolKind, path ...string) {
+	w.atRange(node.Pos(), node.End(), name, kind, path...)
+}
+
+func (w *gopSymbolWalker) atRange(start, end token.Pos, name string, kind protocol.SymbolKind, path ...string) {
+	var b strings.Builder
+	for _, elem := range path {
+		if elem != "" {
+			b.WriteString(elem)
+			b.WriteString(".")
+		}
+	}
+	b.WriteString(name)
+
+	rng, err := w.mapper.Range(start, end)
+	if err != nil {
+		w.error(err)
+		return
+	}
+	sym := source.Symbol{
+		Name:  b.String(),
+		Kind:  kind,
+		Range: rng,
+	}
+	w.symbols = append(w.symbols, sym)
+}
+
+// fileDecls processes the declarations of a Go+ file that isn't a
+// classfile. Its statements are reported as its entry point, such as main.
+func (w *gopSymbolWalker) fileDecls(file *gopast.File) {
+	entry := source.GopShadowEntry(file)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *gopast.FuncDecl:
+			if decl == entry {
+				if len(entry.Body.List) > 0 {
+					w.atNode(entry.Body, source.GopEntrypoint(file, false, false), protocol.Function)
+				}
+				continue
+			}
+			kind := protocol.Function
+			var recv string
+			if decl.Recv.NumFields() > 0 {
+				kind = protocol.Method
+				recv = gopUnpackRecv(decl.Recv.List[0].Type)
+			}
+			w.atNode(decl.Name, decl.Name.Name, kind, recv)
+		case *gopast.GenDecl:
+			w.genDecl(decl)
+		}
+	}
+}
+
+// classDecls processes the declarations of a classfile, whose functions
+// and fields are members of its class.
+func (w *gopSymbolWalker) classDecls(file *gopast.File, class string, isProj bool) {
+	entry := source.GopShadowEntry(file)
+	fields := source.GopClassFields(file)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *gopast.FuncDecl:
+			if decl == entry {
+				if len(entry.Body.List) > 0 {
+					w.atNode(entry.Body, source.GopEntrypoint(file, isProj, true), protocol.Method, class)
+				}
+				continue
+			}
+			w.atNode(decl.Name, decl.Name.Name, protocol.Method, class)
+		case *gopast.GenDecl:
+			if decl != fields {
+				w.genDecl(decl)
+				continue
+			}
+			for _, spec := range decl.Specs {
+				if spec, ok := spec.(*gopast.ValueSpec); ok {
+					for _, name := range spec.Names {
+						w.atNode(name, name.Name, protocol.Field, class)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (w *gopSymbolWalker) genDecl(decl *gopast.GenDecl) {
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *gopast.TypeSpec:
+			kind := gopGuessKind(spec)
+			w.atNode(spec.Name, spec.Name.Name, kind)
+			w.walkType(spec.Type, spec.Name.Name)
+		case *gopast.ValueSpec:
+			for _, name := range spec.Names {
+				kind := protocol.Variable
+				if decl.Tok == goptoken.CONST {
+					kind = protocol.Constant
+				}
+				w.atNode(name, name.Name, kind)
+			}
+		}
+	}
+}
+
+func gopGuessKind(spec *gopast.TypeSpec) protocol.SymbolKind {
+	switch spec.Type.(type) {
+	case *gopast.InterfaceType:
+		return protocol.Interface
+	case *gopast.StructType:
+		return protocol.Struct
+	case *gopast.FuncType:
+		return protocol.Function
+	}
+	return protocol.Class
+}
+
+func gopUnpackRecv(rtyp gopast.Expr) string {
+L:
+	for {
+		switch t := rtyp.(type) {
+		case *gopast.ParenExpr:
+			rtyp = t.X
+		case *gopast.StarExpr:
+			rtyp = t.X
+		default:
+			break L
+		}
+	}
+	if name, _ := rtyp.(*gopast.Ident); name != nil {
+		return name.Name
+	}
+	return ""
+}
+
+// walkType is symbolWalker.walkType for a Go+ type expression.
+func (w *gopSymbolWalker) walkType(typ gopast.Expr, path ...string) {
+	switch st := typ.(type) {
+	case *gopast.StructType:
+		for _, field := range st.Fields.List {
+			w.walkField(field, protocol.Field, protocol.Field, path...)
+		}
+	case *gopast.InterfaceType:
+		for _, field := range st.Methods.List {
+			w.walkField(field, protocol.Interface, protocol.Method, path...)
+		}
+	}
+}
+
+// walkField is symbolWalker.walkField for a Go+ struct field or interface
+// method.
+func (w *gopSymbolWalker) walkField(field *gopast.Field, unnamedKind, namedKind protocol.SymbolKind, path ...string) {
+	if len(field.Names) == 0 {
+		switch typ := field.Type.(type) {
+		case *gopast.SelectorExpr:
+			// embedded qualified type
+			w.atNode(field, typ.Sel.Name, unnamedKind, path...)
+		default:
+			w.atNode(field, source.GopExprString(field.Type), unnamedKind, path...)
+		}
+	}
+	for _, name := range field.Names {
+		w.atNode(name, name.Name, namedKind, path...)
+		w.walkType(field.Type, append(path, name.Name)...)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+)
+
+func TestSymbolizeGop(t *testing.T) {
+	tests := []struct {
+		filename        string
+		src             string
+		isProj, isClass bool
+		want            []string // name kind line
+	}{
+		{"a.gop", `type T struct {
+	v int
+}
+
+func (t *T) Get() int { return t.v }
+
+const c = 1
+
+x := T{c}
+println x.Get()
+`, false, false, []string{"T Struct 1", "T.v Field 2", "T.Get Method 5", "c Constant 7", "main Function 9"}},
+		{"b.gop", "package b\n\nprintln 1\n", false, false, []string{"init Function 3"}},
+		{"a.gop", "package a\n\nfunc f() {}\n", false, false, []string{"f Function 3"}},
+		{"Kai.spx", `import "fmt"
+
+var (
+	n int
+)
+
+func inc() { n++ }
+
+onStart => {
+	fmt.Println(n)
+}
+`, false, true, []string{"Kai Class 1", "Kai.n Field 4", "Kai.inc Method 7", "Kai.Main Method 9"}},
+		{"main.gmx", "var (\n\tx int\n)\n", true, true, []string{"_main Class 1", "_main.x Field 2"}},
+		{"main.gmx", "var (\n\tx int\n)\n", false, false, []string{"x Variable 2"}}, // not a registered classfile
+	}
+
+	for _, test := range tests {
+		fh := &fileHandle{
+			uri:   span.URIFromPath("/tmp/" + test.filename),
+			bytes: []byte(test.src),
+		}
+		symbols, err := symbolizeGopImpl(fh, test.isProj, test.isClass)
+		if err != nil {
+			t.Fatalf("symbolizeGopImpl(%s): %v", test.filename, err)
+		}
+		var got []string
+		for _, sym := range symbols {
+			got = append(got, fmt.Sprintf("%s %s %d", sym.Name, sym.Kind, sym.Range.Start.Line+1))
+		}
+		if strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("symbolizeGopImpl(%s): got %q, want %q", test.filename, got, test.want)
+		}
+	}
+}
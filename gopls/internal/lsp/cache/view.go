@@ -31,6 +31,7 @@ import (
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/gocommand"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/imports"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/xcontext"
+	"github.com/goplus/mod/gopmod"
 	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/semver"
 	exec "golang.org/x/sys/execabs"
@@ -66,6 +67,12 @@ type View struct {
 
 	vulns map[span.URI]*govulncheck.Result
 
+	// gopClassExts holds the extensions of the classfiles registered by the
+	// gop.mod files of the modules loaded in the view, such as .yap. It
+	// only grows, as there are few of them.
+	gopClassExtsMu sync.Mutex
+	gopClassExts   map[string]struct{}
+
 	// filesByURI maps URIs to the canonical URI for the file it denotes.
 	// We also keep a set of candidates for a given basename
 	// to reduce the set of pairs that need to be tested for sameness.
@@ -276,6 +283,10 @@ func (v *View) Options() *source.Options {
 }
 
 func (v *View) FileKind(fh source.FileHandle) source.FileKind {
+	// Editors that know nothing of Go+ open gop.mod files as go.mod files.
+	if filepath.Base(fh.URI().Filename()) == "gop.mod" {
+		return source.GopMod
+	}
 	if o, ok := fh.(source.Overlay); ok {
 		if o.Kind() != source.UnknownKind {
 			return o.Kind()
@@ -292,6 +303,9 @@ func (v *View) FileKind(fh source.FileHandle) source.FileKind {
 	case ".work":
 		return source.Work
 	}
+	if v.isGopExt(fext) {
+		return source.Gop
+	}
 	exts := v.Options().TemplateExtensions
 	for _, ext := range exts {
 		if fext == ext || fext == "."+ext {
@@ -302,6 +316,48 @@ func (v *View) FileKind(fh source.FileHandle) source.FileKind {
 	return source.Go
 }
 
+// isGopExt reports whether ext, including its leading dot, is the extension
+// of a Go+ source file: one of source.GopExtensions, or that of a classfile
+// registered by a gop.mod file of the view.
+func (v *View) isGopExt(ext string) bool {
+	if source.IsGopExt(ext) {
+		return true
+	}
+	v.gopClassExtsMu.Lock()
+	defer v.gopClassExtsMu.Unlock()
+	_, ok := v.gopClassExts[ext]
+	return ok
+}
+
+// addGopClassExts records the extensions of classes, the classfiles
+// registered by a gop.mod file, by extension.
+func (v *View) addGopClassExts(classes map[string]*gopmod.Class) {
+	v.gopClassExtsMu.Lock()
+	defer v.gopClassExtsMu.Unlock()
+	for ext := range classes {
+		if source.IsGopExt(ext) {
+			continue
+		}
+		if v.gopClassExts == nil {
+			v.gopClassExts = make(map[string]struct{})
+		}
+		v.gopClassExts[ext] = struct{}{}
+	}
+}
+
+// gopClassExtensions returns the sorted extensions recorded by
+// addGopClassExts, without their leading dot.
+func (v *View) gopClassExtensions() []string {
+	v.gopClassExtsMu.Lock()
+	defer v.gopClassExtsMu.Unlock()
+	exts := make([]string, 0, len(v.gopClassExts))
+	for ext := range v.gopClassExts {
+		exts = append(exts, strings.TrimPrefix(ext, "."))
+	}
+	sort.Strings(exts)
+	return exts
+}
+
 func minorOptionsChange(a, b *source.Options) bool {
 	// Check if any of the settings that modify our understanding of files have been changed
 	if !reflect.DeepEqual(a.Env, b.Env) {
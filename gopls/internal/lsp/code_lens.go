@@ -28,6 +28,10 @@ func (s *Server) codeLens(ctx context.Context, params *protocol.CodeLensParams)
 		lenses = mod.LensFuncs()
 	case source.Go:
 		lenses = source.LensFuncs()
+	case source.Gop:
+		lenses = source.GopLensFuncs()
+	case source.GopMod:
+		lenses = mod.GopModLensFuncs()
 	default:
 		// Unsupported file kind for a code lens.
 		return nil, nil
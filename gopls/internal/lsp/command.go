@@ -19,7 +19,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast/astutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/gocmd"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/govulncheck"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/command"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/debug"
@@ -312,6 +314,61 @@ func (c *commandHandler) Vendor(ctx context.Context, args command.URIArg) error
 	})
 }
 
+func (c *commandHandler) GopModTidy(ctx context.Context, args command.URIArg) error {
+	return c.run(ctx, commandConfig{
+		requireSave: true,
+		progress:    "Running gop mod tidy",
+		forURI:      args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		gopEnv, err := source.LoadGopEnv()
+		if err != nil {
+			return err
+		}
+		// gop.Tidy rewrites gop.mod, the generated Go files and go.mod in
+		// place, so the changes reach the snapshot as file system events.
+		out := io.MultiWriter(progress.NewEventWriter(ctx, "gop mod tidy"), progress.NewWorkDoneWriter(ctx, deps.work))
+		return gop.TidyTo(filepath.Dir(args.URI.SpanURI().Filename()), gopEnv, out, out)
+	})
+}
+
+func (c *commandHandler) GopGenGo(ctx context.Context, args command.URIArg) error {
+	return c.run(ctx, commandConfig{
+		requireSave: true,
+		progress:    "Running gop go",
+		forURI:      args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		gopEnv, err := source.LoadGopEnv()
+		if err != nil {
+			return err
+		}
+		// The generated Go files reach the snapshot as file system events.
+		out := io.MultiWriter(progress.NewEventWriter(ctx, "gop go"), progress.NewWorkDoneWriter(ctx, deps.work))
+		_, _, err = gop.GenGo(filepath.Dir(args.URI.SpanURI().Filename()), &gop.Config{Gop: gopEnv, GenTestFuncs: true, Stdout: out}, true)
+		return err
+	})
+}
+
+func (c *commandHandler) GopShowGo(ctx context.Context, args command.URIArg) error {
+	return c.run(ctx, commandConfig{
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		filename := source.GopGeneratedGoFile(args.URI.SpanURI().Filename())
+		// Report why there is no Go code, rather than open an empty document.
+		if _, err := source.GopGeneratedGo(ctx, deps.snapshot, filename); err != nil {
+			return err
+		}
+		uri := source.GopGeneratedGoURI(filename)
+		c.s.gopGeneratedGoMu.Lock()
+		c.s.gopGeneratedGo[uri] = struct{}{}
+		c.s.gopGeneratedGoMu.Unlock()
+		_, err := c.s.client.ShowDocument(ctx, &protocol.ShowDocumentParams{
+			URI:       protocol.URI(uri),
+			TakeFocus: true,
+		})
+		return err
+	})
+}
+
 func (c *commandHandler) EditGoDirective(ctx context.Context, args command.EditGoDirectiveArgs) error {
 	return c.run(ctx, commandConfig{
 		requireSave: true, // if go.mod isn't saved it could cause a problem
@@ -478,6 +535,12 @@ func (c *commandHandler) runTests(ctx context.Context, snapshot source.Snapshot,
 		}
 	}
 
+	return c.showTestResults(ctx, tests, benchmarks, failedTests, failedBenchmarks, buf)
+}
+
+// showTestResults tells the client how many of the tests and benchmarks
+// failed, along with the output of the test runs if any failed.
+func (c *commandHandler) showTestResults(ctx context.Context, tests, benchmarks []string, failedTests, failedBenchmarks int, output *bytes.Buffer) error {
 	var title string
 	if len(tests) > 0 && len(benchmarks) > 0 {
 		title = "tests and benchmarks"
@@ -497,7 +560,7 @@ func (c *commandHandler) runTests(ctx context.Context, snapshot source.Snapshot,
 		message = fmt.Sprintf("%d / %d benchmarks failed", failedBenchmarks, len(benchmarks))
 	}
 	if failedTests > 0 || failedBenchmarks > 0 {
-		message += "\n" + buf.String()
+		message += "\n" + output.String()
 	}
 
 	return c.s.client.ShowMessage(ctx, &protocol.ShowMessageParams{
@@ -506,6 +569,97 @@ func (c *commandHandler) runTests(ctx context.Context, snapshot source.Snapshot,
 	})
 }
 
+func (c *commandHandler) GopTest(ctx context.Context, args command.RunTestsArgs) error {
+	return c.run(ctx, commandConfig{
+		async:       true,
+		progress:    "Running gop test",
+		requireSave: true,
+		forURI:      args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		if err := c.runGopTests(ctx, deps.snapshot, deps.work, args.URI, args.Tests, args.Benchmarks); err != nil {
+			return fmt.Errorf("running tests failed: %w", err)
+		}
+		return nil
+	})
+}
+
+// runGopTests is like runTests, but runs gop test, which generates the Go
+// files of the Go+ package in the directory of uri before running go test.
+func (c *commandHandler) runGopTests(ctx context.Context, snapshot source.Snapshot, work *progress.WorkDone, uri protocol.DocumentURI, tests, benchmarks []string) error {
+	gopEnv, err := source.LoadGopEnv()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(uri.SpanURI().Filename())
+
+	// create output
+	buf := &bytes.Buffer{}
+	ew := progress.NewEventWriter(ctx, "test")
+	out := io.MultiWriter(ew, progress.NewWorkDoneWriter(ctx, work), buf)
+
+	gopTest := func(flags ...string) error {
+		conf := &gop.Config{Gop: gopEnv, GenTestFuncs: true, Stdout: out}
+		test := &gocmd.TestConfig{
+			Gop:   gopEnv,
+			Flags: flags,
+			Run: func(cmd *exec.Cmd) error {
+				// gocmd names the package by its directory, so the go
+				// command must run in it to find its module.
+				cmd.Dir = dir
+				cmd.Env = append(os.Environ(), snapshot.View().Options().EnvSlice()...)
+				cmd.Stdout, cmd.Stderr = out, out
+				if err := cmd.Start(); err != nil {
+					return err
+				}
+				done := make(chan struct{})
+				defer close(done)
+				go func() {
+					select {
+					case <-ctx.Done():
+						cmd.Process.Kill()
+					case <-done:
+					}
+				}()
+				return cmd.Wait()
+			},
+		}
+		if err := gop.TestDir(dir, conf, test); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Like gop test, report the error, such as a compile error of
+			// the Go+ files, along with the output.
+			fmt.Fprintln(out, err)
+			return err
+		}
+		return nil
+	}
+
+	// Run `gop test -run Func` on each test.
+	var failedTests int
+	for _, funcName := range tests {
+		if err := gopTest("-v", "-count=1", "-run", fmt.Sprintf("^%s$", funcName)); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			failedTests++
+		}
+	}
+
+	// Run `gop test -run=^$ -bench Func` on each test.
+	var failedBenchmarks int
+	for _, funcName := range benchmarks {
+		if err := gopTest("-v", "-run=^$", "-bench", fmt.Sprintf("^%s$", funcName)); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			failedBenchmarks++
+		}
+	}
+
+	return c.showTestResults(ctx, tests, benchmarks, failedTests, failedBenchmarks, buf)
+}
+
 func (c *commandHandler) Generate(ctx context.Context, args command.GenerateArgs) error {
 	title := "Running go generate ."
 	if args.Recursive {
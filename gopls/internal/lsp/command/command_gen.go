@@ -29,6 +29,10 @@ const (
 	Generate              Command = "generate"
 	GenerateGoplsMod      Command = "generate_gopls_mod"
 	GoGetPackage          Command = "go_get_package"
+	GopGenGo              Command = "gop_gen_go"
+	GopModTidy            Command = "gop_mod_tidy"
+	GopShowGo             Command = "gop_show_go"
+	GopTest               Command = "gop_test"
 	ListImports           Command = "list_imports"
 	ListKnownPackages     Command = "list_known_packages"
 	RegenerateCgo         Command = "regenerate_cgo"
@@ -56,6 +60,10 @@ var Commands = []Command{
 	Generate,
 	GenerateGoplsMod,
 	GoGetPackage,
+	GopGenGo,
+	GopModTidy,
+	GopShowGo,
+	GopTest,
 	ListImports,
 	ListKnownPackages,
 	RegenerateCgo,
@@ -134,6 +142,30 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.GoGetPackage(ctx, a0)
+	case "gopls.gop_gen_go":
+		var a0 URIArg
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.GopGenGo(ctx, a0)
+	case "gopls.gop_mod_tidy":
+		var a0 URIArg
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.GopModTidy(ctx, a0)
+	case "gopls.gop_show_go":
+		var a0 URIArg
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.GopShowGo(ctx, a0)
+	case "gopls.gop_test":
+		var a0 RunTestsArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.GopTest(ctx, a0)
 	case "gopls.list_imports":
 		var a0 URIArg
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -344,6 +376,54 @@ func NewGoGetPackageCommand(title string, a0 GoGetPackageArgs) (protocol.Command
 	}, nil
 }
 
+func NewGopGenGoCommand(title string, a0 URIArg) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.gop_gen_go",
+		Arguments: args,
+	}, nil
+}
+
+func NewGopModTidyCommand(title string, a0 URIArg) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.gop_mod_tidy",
+		Arguments: args,
+	}, nil
+}
+
+func NewGopShowGoCommand(title string, a0 URIArg) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.gop_show_go",
+		Arguments: args,
+	}, nil
+}
+
+func NewGopTestCommand(title string, a0 RunTestsArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.gop_test",
+		Arguments: args,
+	}, nil
+}
+
 func NewListImportsCommand(title string, a0 URIArg) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -49,6 +49,12 @@ type Interface interface {
 	// Runs `go test` for a specific set of test or benchmark functions.
 	RunTests(context.Context, RunTestsArgs) error
 
+	// GopTest: Run Go+ test(s)
+	//
+	// Runs `gop test` for a specific set of test or benchmark functions of a
+	// Go+ package, streaming the output as progress.
+	GopTest(context.Context, RunTestsArgs) error
+
 	// Generate: Run go generate
 	//
 	// Runs `go generate` for a given directory.
@@ -69,6 +75,35 @@ type Interface interface {
 	// Runs `go mod vendor` for a module.
 	Vendor(context.Context, URIArg) error
 
+	// GopModTidy: Run gop mod tidy
+	//
+	// Runs `gop mod tidy` for the module of a gop.mod file: updates the
+	// requirements of the gop.mod file, regenerates the Go files of the
+	// module's Go+ packages and runs `go mod tidy`.
+	GopModTidy(context.Context, URIArg) error
+
+	// GopGenGo: Run gop go
+	//
+	// Runs `gop go` for the package of a Go+ file: writes the Go code that
+	// its Go+ files compile to, gop_autogen.go, to the package directory.
+	GopGenGo(context.Context, URIArg) error
+
+	// GopShowGo: Show generated Go code
+	//
+	// Opens the Go code that the Go+ files of a package compile to in a
+	// read-only document, such as gop-go:///path/to/pkg/gop_autogen.go,
+	// for the package of a Go+ file. The test files of the package are
+	// compiled to gop_autogen_test.go.
+	//
+	// The client gets the content of the document from the nonstandard
+	// gopls/gopGeneratedGo request, which takes the URI of the document
+	// as {"uri": string} and returns {"content": string}. The content
+	// reflects the unsaved edits of the Go+ files. gopls sends the
+	// nonstandard gopls/gopGeneratedGoChanged notification, with the
+	// URI of the document as {"uri": string}, when they change, until
+	// the client closes the document with textDocument/didClose.
+	GopShowGo(context.Context, URIArg) error
+
 	// EditGoDirective: Run go mod edit -go=version
 	//
 	// Runs `go mod edit -go=version` for a module.
@@ -30,6 +30,8 @@ func (s *Server) completion(ctx context.Context, params *protocol.CompletionPara
 	switch snapshot.View().FileKind(fh) {
 	case source.Go:
 		candidates, surrounding, err = completion.Completion(ctx, snapshot, fh, params.Position, params.Context)
+	case source.Gop:
+		candidates, surrounding, err = completion.GopCompletion(ctx, snapshot, fh, params.Position, params.Context)
 	case source.Mod:
 		candidates, surrounding = nil, nil
 	case source.Work:
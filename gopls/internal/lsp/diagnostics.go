@@ -39,6 +39,7 @@ const (
 	workSource
 	modCheckUpgradesSource
 	modVulncheckSource
+	gopModSource
 )
 
 // A diagnosticReport holds results for a single diagnostic source.
@@ -99,6 +100,8 @@ func (d diagnosticSource) String() string {
 		return "FromCheckForUpgrades"
 	case modVulncheckSource:
 		return "FromModVulncheck"
+	case gopModSource:
+		return "FromGopMod"
 	default:
 		return fmt.Sprintf("From?%d?", d)
 	}
@@ -268,6 +271,14 @@ func (s *Server) diagnose(ctx context.Context, snapshot source.Snapshot, forceAn
 	}
 	store(workSource, "diagnosing go.work file", workReports, workErr, true)
 
+	// Diagnose gop.mod files.
+	gopModReports, gopModErr := mod.GopModDiagnostics(ctx, snapshot)
+	if ctx.Err() != nil {
+		log.Trace.Log(ctx, "diagnose cancelled")
+		return
+	}
+	store(gopModSource, "diagnosing gop.mod files", gopModReports, gopModErr, true)
+
 	// All subsequent steps depend on the completion of
 	// type-checking of the all active packages in the workspace.
 	// This step may take many seconds initially.
@@ -361,6 +372,10 @@ func (s *Server) diagnosePkg(ctx context.Context, snapshot source.Snapshot, pkg
 		enableDiagnostics = enableDiagnostics || !snapshot.IgnoredFile(pgf.URI)
 		includeAnalysis = includeAnalysis || snapshot.IsOpen(pgf.URI)
 	}
+	for _, pgf := range pkg.GopFiles() { // a package may have Go+ files only
+		enableDiagnostics = enableDiagnostics || !snapshot.IgnoredFile(pgf.URI)
+		includeAnalysis = includeAnalysis || snapshot.IsOpen(pgf.URI)
+	}
 	// Don't show any diagnostics on ignored files.
 	if !enableDiagnostics {
 		return
@@ -378,6 +393,9 @@ func (s *Server) diagnosePkg(ctx context.Context, snapshot source.Snapshot, pkg
 			s.storeDiagnostics(snapshot, cgf.URI, typeCheckSource, pkgDiagnostics[cgf.URI], true)
 		}
 	}
+	for _, pgf := range pkg.GopFiles() {
+		s.storeDiagnostics(snapshot, pgf.URI, typeCheckSource, pkgDiagnostics[pgf.URI], true)
+	}
 	if includeAnalysis && !pkg.HasListOrParseErrors() {
 		reports, err := source.Analyze(ctx, snapshot, pkg, false)
 		if err != nil {
@@ -26,6 +26,10 @@ func (s *Server) formatting(ctx context.Context, params *protocol.DocumentFormat
 		return source.Format(ctx, snapshot, fh)
 	case source.Work:
 		return work.Format(ctx, snapshot, fh)
+	case source.Gop:
+		return source.FormatGop(ctx, snapshot, fh)
+	case source.GopMod:
+		return mod.GopModFormat(ctx, snapshot, fh)
 	}
 	return nil, nil
 }
@@ -0,0 +1,85 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/jsonrpc2"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/xcontext"
+)
+
+// gopGeneratedGoContent implements the nonstandard gopls/gopGeneratedGo
+// request, which returns the content of a read-only document opened by
+// gopls.gop_show_go.
+func (s *Server) gopGeneratedGoContent(ctx context.Context, params interface{}) (interface{}, error) {
+	paramMap, ok := params.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid params %v", jsonrpc2.ErrInvalidParams, params)
+	}
+	uri, _ := paramMap["uri"].(string)
+	filename, err := source.GopGeneratedGoFilename(protocol.DocumentURI(uri))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", jsonrpc2.ErrInvalidParams, err)
+	}
+	view, err := s.session.ViewOf(span.URIFromPath(filename))
+	if err != nil {
+		return nil, err
+	}
+	snapshot, release := view.Snapshot(ctx)
+	defer release()
+	src, err := source.GopGeneratedGo(ctx, snapshot, filename)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"content": string(src)}, nil
+}
+
+// gopGeneratedGoChanged sends the nonstandard gopls/gopGeneratedGoChanged
+// notification for the documents opened by gopls.gop_show_go whose package
+// directory has a file among the changed ones. The notifications are sent
+// in the background, so as not to hold up the edits.
+func (s *Server) gopGeneratedGoChanged(ctx context.Context, snapshots map[source.Snapshot][]span.URI) {
+	dirs := make(map[string]bool)
+	for _, uris := range snapshots {
+		for _, uri := range uris {
+			dirs[filepath.Dir(uri.Filename())] = true
+		}
+	}
+	var changed []protocol.DocumentURI
+	s.gopGeneratedGoMu.Lock()
+	for uri := range s.gopGeneratedGo {
+		if filename, err := source.GopGeneratedGoFilename(uri); err == nil && dirs[filepath.Dir(filename)] {
+			changed = append(changed, uri)
+		}
+	}
+	s.gopGeneratedGoMu.Unlock()
+	if len(changed) == 0 {
+		return
+	}
+
+	ctx = xcontext.Detach(ctx)
+	go func() {
+		for _, uri := range changed {
+			if err := protocol.NotifyClient(ctx, s.client, "gopls/gopGeneratedGoChanged", map[string]interface{}{"uri": uri}); err != nil {
+				event.Error(ctx, "notifying of generated Go changes", err)
+			}
+		}
+	}()
+}
+
+// gopGeneratedGoClosed forgets uri, a document opened by gopls.gop_show_go
+// that the client closed.
+func (s *Server) gopGeneratedGoClosed(uri protocol.DocumentURI) {
+	s.gopGeneratedGoMu.Lock()
+	defer s.gopGeneratedGoMu.Unlock()
+	delete(s.gopGeneratedGo, uri)
+}
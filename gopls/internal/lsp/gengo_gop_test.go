@@ -0,0 +1,71 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/jsonrpc2"
+)
+
+func TestGopGeneratedGoChanged(t *testing.T) {
+	ctx := context.Background()
+	serverSide, clientSide := net.Pipe()
+	serverConn := jsonrpc2.NewConn(jsonrpc2.NewHeaderStream(serverSide))
+	serverConn.Go(ctx, jsonrpc2.MethodNotFound)
+	defer serverConn.Close()
+	clientConn := jsonrpc2.NewConn(jsonrpc2.NewHeaderStream(clientSide))
+	notified := make(chan protocol.DocumentURI, 10)
+	clientConn.Go(ctx, func(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+		var params struct {
+			URI protocol.DocumentURI `json:"uri"`
+		}
+		if req.Method() != "gopls/gopGeneratedGoChanged" {
+			t.Errorf("unexpected notification %s", req.Method())
+		} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+			t.Error(err)
+		}
+		notified <- params.URI
+		return reply(ctx, nil, nil)
+	})
+	defer clientConn.Close()
+
+	shown := source.GopGeneratedGoURI("/src/a/gop_autogen.go")
+	other := source.GopGeneratedGoURI("/src/b/gop_autogen.go")
+	s := &Server{
+		client:         protocol.ClientDispatcher(serverConn),
+		gopGeneratedGo: map[protocol.DocumentURI]struct{}{shown: {}, other: {}},
+	}
+	changes := map[source.Snapshot][]span.URI{nil: {span.URIFromPath("/src/a/a.gop")}}
+	s.gopGeneratedGoChanged(ctx, changes)
+	select {
+	case got := <-notified:
+		if got != shown {
+			t.Errorf("got a notification for %s, want %s", got, shown)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no notification for the changed package")
+	}
+
+	// The documents that the client closed are forgotten.
+	if err := s.didClose(ctx, &protocol.DidCloseTextDocumentParams{TextDocument: protocol.TextDocumentIdentifier{URI: shown}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.gopGeneratedGo[shown]; ok || len(s.gopGeneratedGo) != 1 {
+		t.Errorf("after didClose(%s): got documents %v, want %s only", shown, s.gopGeneratedGo, other)
+	}
+
+	// Clients that can't get the notification are reported.
+	if err := protocol.NotifyClient(ctx, struct{ protocol.Client }{}, "gopls/gopGeneratedGoChanged", nil); err == nil {
+		t.Error("NotifyClient: got no error for a client without a connection")
+	}
+}
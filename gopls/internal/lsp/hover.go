@@ -23,12 +23,14 @@ func (s *Server) hover(ctx context.Context, params *protocol.HoverParams) (*prot
 	switch snapshot.View().FileKind(fh) {
 	case source.Mod:
 		return mod.Hover(ctx, snapshot, fh, params.Position)
-	case source.Go:
+	case source.Go, source.Gop:
 		return source.Hover(ctx, snapshot, fh, params.Position)
 	case source.Tmpl:
 		return template.Hover(ctx, snapshot, fh, params.Position)
 	case source.Work:
 		return work.Hover(ctx, snapshot, fh, params.Position)
+	case source.GopMod:
+		return mod.GopModHover(ctx, snapshot, fh, params.Position)
 	}
 	return nil, nil
 }
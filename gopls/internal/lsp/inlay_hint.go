@@ -12,10 +12,13 @@ import (
 )
 
 func (s *Server) inlayHint(ctx context.Context, params *protocol.InlayHintParams) ([]protocol.InlayHint, error) {
-	snapshot, fh, ok, release, err := s.beginFileRequest(ctx, params.TextDocument.URI, source.Go)
+	snapshot, fh, ok, release, err := s.beginFileRequest(ctx, params.TextDocument.URI, source.UnknownKind)
 	defer release()
 	if !ok {
 		return nil, err
 	}
+	if kind := snapshot.View().FileKind(fh); kind != source.Go && kind != source.Gop {
+		return nil, nil
+	}
 	return source.InlayHint(ctx, snapshot, fh, params.Range)
 }
@@ -0,0 +1,216 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mod
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/command"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+	"github.com/goplus/mod/gopmod"
+	"github.com/goplus/mod/modcache"
+	gopmodfile "github.com/goplus/mod/modfile"
+	"github.com/goplus/mod/modload"
+	"github.com/qiniu/x/errors"
+)
+
+// GopModDiagnostics returns diagnostics for the gop.mod files in the
+// workspace: parse errors, and register directives that do not name a
+// classfile module.
+func GopModDiagnostics(ctx context.Context, snapshot source.Snapshot) (map[source.VersionedFileIdentity][]*source.Diagnostic, error) {
+	ctx, done := event.Start(ctx, "mod.GopModDiagnostics", source.SnapshotLabels(snapshot)...)
+	defer done()
+
+	reports := make(map[source.VersionedFileIdentity][]*source.Diagnostic)
+	for _, uri := range snapshot.GopModFiles(ctx) {
+		fh, err := snapshot.GetVersionedFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics, err := gopModDiagnostics(ctx, snapshot, fh)
+		if err != nil {
+			return nil, err
+		}
+		reports[fh.VersionedFileIdentity()] = append([]*source.Diagnostic{}, diagnostics...)
+	}
+	return reports, nil
+}
+
+func gopModDiagnostics(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle) ([]*source.Diagnostic, error) {
+	pm, err := snapshot.ParseGopMod(ctx, fh)
+	if err != nil {
+		if pm == nil || len(pm.ParseErrors) == 0 {
+			return nil, err
+		}
+		return pm.ParseErrors, nil
+	}
+
+	var diagnostics []*source.Diagnostic
+	mod := gopmod.New(modload.Module{File: pm.File})
+	for _, r := range pm.File.Register {
+		_, classErr := registeredClass(mod, r)
+		if classErr == nil {
+			continue
+		}
+		rng, err := pm.Mapper.OffsetRange(r.Syntax.Start.Byte, r.Syntax.End.Byte)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, &source.Diagnostic{
+			URI:      fh.URI(),
+			Range:    rng,
+			Severity: protocol.SeverityError,
+			Source:   source.GopModFileError,
+			Message:  classErr.Error(),
+		})
+	}
+	return diagnostics, nil
+}
+
+// registeredClass returns the classfile of the module named by a register
+// directive of mod. Unlike gopmod.Module.RegisterClasses, it only looks in
+// the module cache, and never downloads the module.
+func registeredClass(mod *gopmod.Module, r *gopmodfile.Register) (*gopmod.Class, error) {
+	modVer, ok := mod.LookupDepMod(r.ClassfileMod)
+	if !ok {
+		return nil, fmt.Errorf("classfile module %s is not required by this module", r.ClassfileMod)
+	}
+	dir, err := modcache.Path(modVer)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if modVer.Version == "" { // replaced by a directory
+			return nil, fmt.Errorf("classfile module %s: directory %s does not exist", r.ClassfileMod, dir)
+		}
+		return nil, fmt.Errorf("classfile module %s is not downloaded; run gop mod tidy", modVer)
+	}
+	classMod, err := modload.Load(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("loading classfile module %s: %s", modVer, errors.Summary(err))
+	}
+	if classMod.Classfile == nil {
+		return nil, fmt.Errorf("%s is %v", r.ClassfileMod, gopmod.ErrNotClassFileMod)
+	}
+	return classMod.Classfile, nil
+}
+
+// GopModHover returns hover information for the classfile and register
+// directives of a gop.mod file.
+func GopModHover(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, position protocol.Position) (*protocol.Hover, error) {
+	ctx, done := event.Start(ctx, "mod.GopModHover")
+	defer done()
+
+	pm, err := snapshot.ParseGopMod(ctx, fh)
+	if err != nil {
+		// The file must parse to resolve its register directives.
+		return nil, nil
+	}
+	offset, err := pm.Mapper.Offset(position)
+	if err != nil {
+		return nil, fmt.Errorf("computing cursor position: %w", err)
+	}
+
+	var (
+		modPath string
+		class   *gopmod.Class
+		line    *gopmodfile.Line
+	)
+	if c := pm.File.Classfile; c != nil && c.Syntax.Start.Byte <= offset && offset <= c.Syntax.End.Byte {
+		class, line = c, c.Syntax
+		if pm.File.Module != nil {
+			modPath = pm.File.Module.Mod.Path
+		}
+	}
+	mod := gopmod.New(modload.Module{File: pm.File})
+	for _, r := range pm.File.Register {
+		if r.Syntax.Start.Byte <= offset && offset <= r.Syntax.End.Byte {
+			class, err = registeredClass(mod, r)
+			if err != nil {
+				// Reported by the diagnostics.
+				return nil, nil
+			}
+			modPath, line = r.ClassfileMod, r.Syntax
+			break
+		}
+	}
+	if class == nil {
+		return nil, nil
+	}
+
+	rng, err := pm.Mapper.OffsetRange(line.Start.Byte, line.End.Byte)
+	if err != nil {
+		return nil, err
+	}
+	options := snapshot.View().Options()
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{
+			Kind:  options.PreferredContentFormat,
+			Value: formatHeader(modPath, options) + formatClass(class),
+		},
+		Range: rng,
+	}, nil
+}
+
+func formatClass(class *gopmod.Class) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Project files: `*%s`\n\n", class.ProjExt)
+	if class.WorkExt != "" {
+		fmt.Fprintf(&b, "Work files: `*%s`\n\n", class.WorkExt)
+	}
+	b.WriteString("Packages:")
+	for _, pkgPath := range class.PkgPaths {
+		fmt.Fprintf(&b, " `%s`", pkgPath)
+	}
+	return b.String()
+}
+
+// GopModFormat formats a gop.mod file.
+func GopModFormat(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle) ([]protocol.TextEdit, error) {
+	ctx, done := event.Start(ctx, "mod.GopModFormat")
+	defer done()
+
+	pm, err := snapshot.ParseGopMod(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+	formatted := gopmodfile.Format(pm.File.Syntax)
+	// Calculate the edits to be made due to the change.
+	diffs := snapshot.View().Options().ComputeEdits(string(pm.Mapper.Content), string(formatted))
+	return source.ToProtocolEdits(pm.Mapper, diffs)
+}
+
+// GopModLensFuncs returns the supported lensFuncs for gop.mod files.
+func GopModLensFuncs() map[command.Command]source.LensFunc {
+	return map[command.Command]source.LensFunc{
+		command.GopModTidy: gopModTidyLens,
+	}
+}
+
+func gopModTidyLens(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle) ([]protocol.CodeLens, error) {
+	pm, err := snapshot.ParseGopMod(ctx, fh)
+	if err != nil || pm.File == nil {
+		return nil, err
+	}
+	if pm.File.Module == nil || pm.File.Module.Syntax == nil {
+		return nil, fmt.Errorf("no module statement in %s", fh.URI())
+	}
+	syntax := pm.File.Module.Syntax
+	rng, err := pm.Mapper.OffsetRange(syntax.Start.Byte, syntax.End.Byte)
+	if err != nil {
+		return nil, err
+	}
+	uri := protocol.URIFromSpanURI(fh.URI())
+	cmd, err := command.NewGopModTidyCommand("Run gop mod tidy", command.URIArg{URI: uri})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeLens{{Range: rng, Command: cmd}}, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mod
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goplus/mod/gopmod"
+	gopmodfile "github.com/goplus/mod/modfile"
+	"github.com/goplus/mod/modload"
+)
+
+func TestRegisteredClass(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"game/gop.mod": "module example.com/game\n\nclassfile .gamex .sprite \"example.com/game\"\n",
+		"lib/gop.mod":  "module example.com/lib\n",
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const src = `module example.com/foo
+
+require (
+	example.com/game v1.0.0
+	example.com/lib v1.0.0
+	example.com/missing v1.0.0
+)
+
+replace (
+	example.com/game v1.0.0 => ./game
+	example.com/lib v1.0.0 => ./lib
+	example.com/missing v1.0.0 => ./missing
+)
+
+register example.com/game
+register example.com/lib
+register example.com/missing
+register example.com/other
+`
+	filename := filepath.Join(dir, "gop.mod")
+	f, err := gopmodfile.Parse(filename, []byte(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod := gopmod.New(modload.Module{File: f})
+	var got []string
+	for _, r := range f.Register {
+		class, err := registeredClass(mod, r)
+		if err != nil {
+			got = append(got, err.Error())
+		} else {
+			got = append(got, class.ProjExt+" "+class.WorkExt+" "+class.PkgPaths[0])
+		}
+	}
+	want := []string{
+		".gamex .sprite example.com/game",
+		"example.com/lib is not a classfile module",
+		"classfile module example.com/missing: directory " + filepath.Join(dir, "missing") + " does not exist",
+		"classfile module example.com/other is not required by this module",
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("registeredClass(%s): got %q, want %q", f.Register[i].ClassfileMod, got[i], want[i])
+		}
+	}
+}
@@ -42,6 +42,28 @@ func (c *clientDispatcher) Close() error {
 	return c.sender.Close()
 }
 
+// Notifier is implemented by clients that can send notifications that are
+// not part of the protocol, such as the clients returned by
+// ClientDispatcher and ClientDispatcherV2.
+type Notifier interface {
+	Notify(ctx context.Context, method string, params interface{}) error
+}
+
+// Notify sends the notification method to the client.
+func (c *clientDispatcher) Notify(ctx context.Context, method string, params interface{}) error {
+	return c.sender.Notify(ctx, method, params)
+}
+
+// NotifyClient sends a notification that is not part of the protocol, such
+// as gopls/gopGeneratedGoChanged, to client. It fails unless client
+// implements Notifier.
+func NotifyClient(ctx context.Context, client Client, method string, params interface{}) error {
+	if n, ok := client.(Notifier); ok {
+		return n.Notify(ctx, method, params)
+	}
+	return fmt.Errorf("cannot send %s to a client of type %T", method, client)
+}
+
 // ClientDispatcher returns a Client that dispatches LSP requests across the
 // given jsonrpc2 connection.
 func ClientDispatcher(conn jsonrpc2.Conn) ClientCloser {
@@ -89,6 +89,9 @@ func (s *Server) computeSemanticTokens(ctx context.Context, td protocol.TextDocu
 		}
 		return template.SemanticTokens(ctx, snapshot, fh.URI(), add, data)
 	}
+	if kind == source.Gop {
+		return s.computeGopSemanticTokens(ctx, snapshot, fh, rng)
+	}
 	if kind != source.Go {
 		return nil, nil
 	}
@@ -109,6 +112,8 @@ func (s *Server) computeSemanticTokens(ctx context.Context, td protocol.TextDocu
 	e := &encoded{
 		ctx:       ctx,
 		pgf:       pgf,
+		tok:       pgf.Tok,
+		mapper:    pgf.Mapper,
 		rng:       rng,
 		ti:        pkg.GetTypesInfo(),
 		pkg:       pkg,
@@ -188,7 +193,7 @@ func (e *encoded) token(start token.Pos, leng int, typ tokenType, mods []string)
 	}
 	// want a line and column from start (in LSP coordinates)
 	// [//line directives should be ignored]
-	rng := source.NewMappedRange(e.pgf.Tok, e.pgf.Mapper, start, start+token.Pos(leng))
+	rng := source.NewMappedRange(e.tok, e.mapper, start, start+token.Pos(leng))
 	lspRange, err := rng.Range()
 	if err != nil {
 		// possibly a //line directive. TODO(pjw): fix this somehow
@@ -231,6 +236,8 @@ type encoded struct {
 	ctx               context.Context
 	tokTypes, tokMods []string
 	pgf               *source.ParsedGoFile
+	tok               *token.File // pgf.Tok, or the token.File of a Go+ file
+	mapper            *protocol.ColumnMapper
 	rng               *protocol.Range
 	ti                *types.Info
 	pkg               source.Package
@@ -845,14 +852,14 @@ func (e *encoded) findKeyword(keyword string, start, end token.Pos) token.Pos {
 }
 
 func (e *encoded) init() error {
-	e.start = token.Pos(e.pgf.Tok.Base())
-	e.end = e.start + token.Pos(e.pgf.Tok.Size())
+	e.start = token.Pos(e.tok.Base())
+	e.end = e.start + token.Pos(e.tok.Size())
 	if e.rng == nil {
 		return nil
 	}
-	span, err := e.pgf.Mapper.RangeSpan(*e.rng)
+	span, err := e.mapper.RangeSpan(*e.rng)
 	if err != nil {
-		return fmt.Errorf("range span (%w) error for %s", err, e.pgf.File.Name)
+		return fmt.Errorf("range span (%w) error for %s", err, e.tok.Name())
 	}
 	e.end = e.start + token.Pos(span.End().Offset())
 	e.start += token.Pos(span.Start().Offset())
@@ -0,0 +1,389 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+	"time"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	goptoken "github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+)
+
+func (s *Server) computeGopSemanticTokens(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, rng *protocol.Range) (*protocol.SemanticTokens, error) {
+	pkg, pgf, err := source.GetParsedGopFile(ctx, snapshot, fh, source.WidestPackage)
+	if err != nil {
+		return nil, err
+	}
+	if rng == nil && len(pgf.Src) > maxFullFileSize {
+		err := fmt.Errorf("semantic tokens: file %s too large for full (%d>%d)",
+			fh.URI().Filename(), len(pgf.Src), maxFullFileSize)
+		return nil, err
+	}
+	vv := snapshot.View()
+	e := &encoded{
+		ctx:       ctx,
+		tok:       pgf.Tok,
+		mapper:    pgf.Mapper,
+		rng:       rng,
+		pkg:       pkg,
+		fset:      snapshot.FileSet(),
+		tokTypes:  s.session.Options().SemanticTypes,
+		tokMods:   s.session.Options().SemanticMods,
+		noStrings: vv.Options().NoSemanticString,
+		noNumbers: vv.Options().NoSemanticNumber,
+	}
+	if err := e.init(); err != nil {
+		return nil, err
+	}
+	e.gopSemantics(pgf, pkg.GetGopTypes(), pkg.GetGopTypesInfo())
+	return &protocol.SemanticTokens{
+		Data:     e.Data(),
+		ResultID: fmt.Sprintf("%v", time.Now()),
+	}, nil
+}
+
+// gopObject is the type checker's object for an identifier of a Go+ file.
+type gopObject struct {
+	obj types.Object
+	def bool
+}
+
+// gopEncoder emits the tokens of the syntax tree of a Go+ file.
+type gopEncoder struct {
+	*encoded
+	pgf    *source.ParsedGopFile
+	pkg    *types.Package
+	objs   map[token.Pos]gopObject // by the position of their identifier
+	params map[types.Object]bool
+	stack  []gopast.Node // path from the root of the current declaration
+}
+
+// gopSemantics emits the tokens of a Go+ file, walking its syntax tree as
+// semantics walks that of a Go file, and finds the objects of identifiers
+// by their position. Besides what Go has, it classifies the => of lambdas,
+// the for, <- and if of for phrases, the ? and ! of error wrapping, the
+// colons of range expressions such as 1:10:2, rational literals and the
+// identifiers a classfile gets from its framework, such as spx Sprite
+// methods.
+func (e *encoded) gopSemantics(pgf *source.ParsedGopFile, pkg *types.Package, info *typesutil.Info) {
+	g := &gopEncoder{
+		encoded: e,
+		pgf:     pgf,
+		pkg:     pkg,
+		objs:    make(map[token.Pos]gopObject),
+		params:  make(map[types.Object]bool),
+	}
+	if info != nil {
+		for id, obj := range info.Uses {
+			g.objs[id.Pos()] = gopObject{obj: obj}
+		}
+		for id, obj := range info.Defs {
+			if obj != nil {
+				g.objs[id.Pos()] = gopObject{obj: obj, def: true}
+			}
+		}
+		for n, scope := range info.Scopes {
+			var names []*gopast.Ident
+			switch n := n.(type) {
+			case *gopast.FuncType:
+				for _, fl := range []*gopast.FieldList{n.Params, n.Results} {
+					if fl == nil {
+						continue
+					}
+					for _, f := range fl.List {
+						names = append(names, f.Names...)
+					}
+				}
+			case *gopast.LambdaExpr:
+				names = n.Lhs
+			case *gopast.LambdaExpr2:
+				names = n.Lhs
+			}
+			for _, id := range names {
+				if obj := scope.Lookup(id.Name); obj != nil {
+					g.params[obj] = true
+				}
+			}
+		}
+	}
+
+	f := pgf.File
+	// A Go+ file may have no package clause.
+	if f.Package.IsValid() {
+		e.token(f.Package, len("package"), tokKeyword, nil)
+		e.token(f.Name.NamePos, len(f.Name.Name), tokNamespace, nil)
+	}
+	for _, d := range f.Decls {
+		// only look at the decls that overlap the range
+		start, end := d.Pos(), d.End()
+		if end <= e.start || start >= e.end {
+			continue
+		}
+		gopast.Inspect(d, g.inspect)
+	}
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			if !strings.Contains(c.Text, "\n") {
+				e.token(c.Pos(), len(c.Text), tokComment, nil)
+				continue
+			}
+			e.multiline(c.Pos(), c.End(), c.Text, tokComment)
+		}
+	}
+}
+
+func (g *gopEncoder) inspect(n gopast.Node) bool {
+	if n == nil {
+		g.stack = g.stack[:len(g.stack)-1]
+		return true
+	}
+	g.stack = append(g.stack, n)
+	e := g.encoded
+	switch x := n.(type) {
+	case *gopast.AssignStmt:
+		e.token(x.TokPos, len(x.Tok.String()), tokOperator, nil)
+	case *gopast.BasicLit:
+		what := tokNumber // including the rationals, such as 3/4r
+		if x.Kind == goptoken.STRING || x.Kind == goptoken.CSTRING {
+			what = tokString
+		}
+		if strings.Contains(x.Value, "\n") {
+			e.multiline(x.Pos(), x.End(), x.Value, what)
+			break
+		}
+		e.token(x.Pos(), len(x.Value), what, nil)
+	case *gopast.BinaryExpr:
+		e.token(x.OpPos, len(x.Op.String()), tokOperator, nil)
+	case *gopast.BranchStmt:
+		e.token(x.TokPos, len(x.Tok.String()), tokKeyword, nil)
+	case *gopast.CallExpr:
+		if x.Ellipsis != token.NoPos {
+			e.token(x.Ellipsis, len("..."), tokOperator, nil)
+		}
+	case *gopast.CaseClause:
+		iam := "case"
+		if x.List == nil {
+			iam = "default"
+		}
+		e.token(x.Case, len(iam), tokKeyword, nil)
+	case *gopast.ChanType:
+		// chan | chan <- | <- chan
+		switch {
+		case x.Arrow == token.NoPos:
+			e.token(x.Begin, len("chan"), tokKeyword, nil)
+		case x.Arrow == x.Begin:
+			e.token(x.Arrow, 2, tokOperator, nil)
+			pos := g.findKeyword("chan", x.Begin+2, x.Value.Pos())
+			e.token(pos, len("chan"), tokKeyword, nil)
+		case x.Arrow != x.Begin:
+			e.token(x.Begin, len("chan"), tokKeyword, nil)
+			e.token(x.Arrow, 2, tokOperator, nil)
+		}
+	case *gopast.CommClause:
+		iam := len("case")
+		if x.Comm == nil {
+			iam = len("default")
+		}
+		e.token(x.Case, iam, tokKeyword, nil)
+	case *gopast.DeferStmt:
+		e.token(x.Defer, len("defer"), tokKeyword, nil)
+	case *gopast.Ellipsis:
+		e.token(x.Ellipsis, len("..."), tokOperator, nil)
+	case *gopast.ForStmt:
+		e.token(x.For, len("for"), tokKeyword, nil)
+	case *gopast.FuncType:
+		if x.Func != token.NoPos {
+			e.token(x.Func, len("func"), tokKeyword, nil)
+		}
+	case *gopast.GenDecl:
+		e.token(x.TokPos, len(x.Tok.String()), tokKeyword, nil)
+	case *gopast.GoStmt:
+		e.token(x.Go, len("go"), tokKeyword, nil)
+	case *gopast.Ident:
+		g.ident(x)
+	case *gopast.IfStmt:
+		e.token(x.If, len("if"), tokKeyword, nil)
+		if x.Else != nil {
+			pos := g.findKeyword("else", x.Body.End(), x.Else.Pos())
+			e.token(pos, len("else"), tokKeyword, nil)
+		}
+	case *gopast.IncDecStmt:
+		e.token(x.TokPos, len(x.Tok.String()), tokOperator, nil)
+	case *gopast.InterfaceType:
+		e.token(x.Interface, len("interface"), tokKeyword, nil)
+	case *gopast.MapType:
+		e.token(x.Map, len("map"), tokKeyword, nil)
+	case *gopast.RangeStmt:
+		e.token(x.For, len("for"), tokKeyword, nil)
+		// x.TokPos == token.NoPos is legal (for range foo {})
+		offset := x.TokPos
+		if offset == token.NoPos {
+			offset = x.For
+		} else {
+			e.token(x.TokPos, len(x.Tok.String()), tokOperator, nil)
+		}
+		pos := g.findKeyword("range", offset, x.X.Pos())
+		e.token(pos, len("range"), tokKeyword, nil)
+	case *gopast.ReturnStmt:
+		e.token(x.Return, len("return"), tokKeyword, nil)
+	case *gopast.SelectStmt:
+		e.token(x.Select, len("select"), tokKeyword, nil)
+	case *gopast.SendStmt:
+		e.token(x.Arrow, len("<-"), tokOperator, nil)
+	case *gopast.StarExpr:
+		e.token(x.Star, len("*"), tokOperator, nil)
+	case *gopast.StructType:
+		e.token(x.Struct, len("struct"), tokKeyword, nil)
+	case *gopast.SwitchStmt:
+		e.token(x.Switch, len("switch"), tokKeyword, nil)
+	case *gopast.TypeAssertExpr:
+		if x.Type == nil {
+			pos := g.findKeyword("type", x.Lparen, x.Rparen)
+			e.token(pos, len("type"), tokKeyword, nil)
+		}
+	case *gopast.TypeSwitchStmt:
+		e.token(x.Switch, len("switch"), tokKeyword, nil)
+	case *gopast.UnaryExpr:
+		if x.Op == goptoken.RANGE { // as in k, v := range m, outside of a for statement
+			e.token(x.OpPos, len("range"), tokKeyword, nil)
+			break
+		}
+		e.token(x.OpPos, len(x.Op.String()), tokOperator, nil)
+	// the syntax of Go+
+	case *gopast.ErrWrapExpr:
+		e.token(x.TokPos, len(x.Tok.String()), tokOperator, nil)
+		if x.Default != nil {
+			// expr?:default
+			pos := g.findKeyword(":", x.TokPos+1, x.Default.Pos())
+			e.token(pos, len(":"), tokOperator, nil)
+		}
+	case *gopast.ForPhrase:
+		e.token(x.For, len("for"), tokKeyword, nil)
+		e.token(x.TokPos, len("<-"), tokOperator, nil)
+		// IfPos is that of the comma, if the condition follows one.
+		if x.IfPos.IsValid() && g.findKeyword("if", x.IfPos, x.IfPos+token.Pos(len("if"))) == x.IfPos {
+			e.token(x.IfPos, len("if"), tokKeyword, nil)
+		}
+	case *gopast.LambdaExpr:
+		e.token(x.Rarrow, len("=>"), tokOperator, nil)
+	case *gopast.LambdaExpr2:
+		e.token(x.Rarrow, len("=>"), tokOperator, nil)
+	case *gopast.RangeExpr:
+		e.token(x.To, len(":"), tokOperator, nil)
+		if x.Colon2.IsValid() {
+			e.token(x.Colon2, len(":"), tokOperator, nil)
+		}
+	case *gopast.ArrayType, *gopast.BlockStmt, *gopast.CompositeLit, *gopast.DeclStmt,
+		*gopast.EmptyStmt, *gopast.ExprStmt, *gopast.Field, *gopast.FieldList, *gopast.FuncDecl,
+		*gopast.FuncLit, *gopast.ImportSpec, *gopast.IndexExpr, *gopast.KeyValueExpr,
+		*gopast.LabeledStmt, *gopast.ParenExpr, *gopast.SelectorExpr, *gopast.SliceExpr,
+		*gopast.TypeSpec, *gopast.ValueSpec,
+		*gopast.ComprehensionExpr, *gopast.ForPhraseStmt, *gopast.SliceLit:
+	// things only seen with parsing or type errors, so ignore them
+	case *gopast.BadDecl, *gopast.BadExpr, *gopast.BadStmt:
+	// other things we knowingly ignore
+	case *gopast.Comment, *gopast.CommentGroup:
+		g.stack = g.stack[:len(g.stack)-1]
+		return false
+	default:
+		e.unexpected(fmt.Sprintf("failed to implement %T", x))
+	}
+	return true
+}
+
+// findKeyword is encoded.findKeyword for the Go+ file. It returns
+// token.NoPos if keyword is not found.
+func (g *gopEncoder) findKeyword(keyword string, start, end token.Pos) token.Pos {
+	offset := int(start) - g.pgf.Tok.Base()
+	last := int(end) - g.pgf.Tok.Base()
+	if offset < 0 || offset > last || last > len(g.pgf.Src) {
+		return token.NoPos
+	}
+	if idx := bytes.Index(g.pgf.Src[offset:last], []byte(keyword)); idx != -1 {
+		return start + token.Pos(idx)
+	}
+	return token.NoPos
+}
+
+// ident emits the token of an identifier, if its object is known.
+func (g *gopEncoder) ident(x *gopast.Ident) {
+	o, ok := g.objs[x.Pos()]
+	if !ok {
+		return
+	}
+	sel := false
+	if n := len(g.stack); n > 1 {
+		if s, ok := g.stack[n-2].(*gopast.SelectorExpr); ok && s.Sel == x {
+			sel = true
+		}
+	}
+	if what, mods := g.gopIdent(o, sel); what != "" {
+		g.token(x.Pos(), len(x.Name), what, mods)
+	}
+}
+
+// gopIdent classifies the object of an identifier of a Go+ file. sel reports
+// whether the identifier is the selector of a selector expression.
+func (g *gopEncoder) gopIdent(o gopObject, sel bool) (tokenType, []string) {
+	pkg := g.pkg
+	var mods []string
+	if o.def {
+		mods = append(mods, "definition")
+	}
+	switch y := o.obj.(type) {
+	case nil:
+		return "", nil
+	case *types.Builtin:
+		return tokFunction, []string{"defaultLibrary"}
+	case *types.Const:
+		return tokVariable, append(mods, "readonly")
+	case *types.Func:
+		sig, _ := y.Type().(*types.Signature)
+		if sig == nil || sig.Recv() == nil {
+			return tokFunction, mods
+		}
+		if !sel && !o.def && y.Pkg() != pkg {
+			// a method of the class framework, used without a receiver
+			mods = append(mods, "defaultLibrary")
+		}
+		return tokMethod, mods
+	case *types.Label:
+		return "", nil
+	case *types.Nil:
+		return tokVariable, []string{"readonly", "defaultLibrary"}
+	case *types.PkgName:
+		return tokNamespace, mods
+	case *types.TypeName:
+		if _, ok := y.Type().(*types.Basic); ok {
+			mods = append(mods, "defaultLibrary")
+		}
+		return tokType, mods
+	case *types.Var:
+		if y.Name() == "_" {
+			return "", nil
+		}
+		if isSignature(y) {
+			return tokFunction, mods
+		}
+		if g.params[y] {
+			return tokParameter, mods
+		}
+		if y.IsField() && !sel && !o.def && y.Pkg() != pkg {
+			mods = append(mods, "defaultLibrary")
+		}
+		return tokVariable, mods
+	}
+	g.unexpected(fmt.Sprintf("%s %T", o.obj.Name(), o.obj))
+	return "", nil
+}
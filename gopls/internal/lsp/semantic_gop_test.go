@@ -0,0 +1,90 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+)
+
+func TestGopSemantics(t *testing.T) {
+	const src = `for i <- 1:10:2 {
+	f(x => x * 2)
+	v := foo()?
+	w := foo()!
+	r := 3/4r
+}
+m := {"a": 1}
+s := a[1:2]
+for k, v <- [y for y <- :5, y > 1] {
+}
+for i := range :3 {
+}
+z := [y for y <- s if y > 0]
+`
+	uri := span.URIFromPath("/src/a.gop")
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, uri.Filename(), src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgf := &source.ParsedGopFile{
+		File:   f,
+		Tok:    fset.File(f.Decls[0].Pos()),
+		Src:    []byte(src),
+		Mapper: protocol.NewColumnMapper(uri, []byte(src)),
+	}
+	e := &encoded{
+		ctx:    context.Background(),
+		tok:    pgf.Tok,
+		mapper: pgf.Mapper,
+		fset:   fset,
+	}
+	if err := e.init(); err != nil {
+		t.Fatal(err)
+	}
+	e.gopSemantics(pgf, nil, nil)
+
+	lines := strings.Split(src, "\n")
+	got := make(map[string]int)
+	for _, item := range e.items {
+		text := lines[item.line][item.start : item.start+item.len]
+		got[text+" "+string(item.typeStr)]++
+	}
+	want := map[string]int{
+		"for keyword":   5,
+		"range keyword": 1,
+		"if keyword":    1,
+		"<- operator":   4,
+		": operator":    4, // 1:10:2, :5 and :3, but neither {"a": 1} nor a[1:2]
+		"=> operator":   1,
+		"? operator":    1,
+		"! operator":    1,
+		"4r number":     1,
+		"\"a\" string":  1,
+		":= operator":   7,
+		"/ operator":    1,
+		"* operator":    1,
+		"> operator":    2,
+		"0 number":      1,
+		"1 number":      4,
+		"10 number":     1,
+		"2 number":      3,
+		"3 number":      2,
+		"5 number":      1,
+	}
+	for key, n := range want {
+		if got[key] != n {
+			t.Errorf("got %d tokens %q, want %d", got[key], key, n)
+		}
+	}
+}
@@ -28,6 +28,7 @@ func NewServer(session *cache.Session, client protocol.ClientCloser) *Server {
 		gcOptimizationDetails: make(map[source.PackageID]struct{}),
 		watchedGlobPatterns:   make(map[string]struct{}),
 		changedFiles:          make(map[span.URI]struct{}),
+		gopGeneratedGo:        make(map[protocol.DocumentURI]struct{}),
 		session:               session,
 		client:                client,
 		diagnosticsSema:       make(chan struct{}, concurrentAnalyses),
@@ -97,6 +98,12 @@ type Server struct {
 	gcOptimizationDetailsMu sync.Mutex
 	gcOptimizationDetails   map[source.PackageID]struct{}
 
+	// gopGeneratedGo is the set of read-only documents opened by
+	// gopls.gop_show_go and not yet closed. The client is notified when the
+	// Go code they show may have changed.
+	gopGeneratedGoMu sync.Mutex
+	gopGeneratedGo   map[protocol.DocumentURI]struct{}
+
 	// diagnosticsSema limits the concurrency of diagnostics runs, which can be
 	// expensive.
 	diagnosticsSema chan struct{}
@@ -155,6 +162,8 @@ func (s *Server) nonstandardRequest(ctx context.Context, method string, params i
 			return nil, err
 		}
 		return struct{}{}, nil
+	case "gopls/gopGeneratedGo":
+		return s.gopGeneratedGoContent(ctx, params)
 	}
 	return nil, notImplemented(method)
 }
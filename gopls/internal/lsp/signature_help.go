@@ -14,11 +14,18 @@ import (
 )
 
 func (s *Server) signatureHelp(ctx context.Context, params *protocol.SignatureHelpParams) (*protocol.SignatureHelp, error) {
-	snapshot, fh, ok, release, err := s.beginFileRequest(ctx, params.TextDocument.URI, source.Go)
+	snapshot, fh, ok, release, err := s.beginFileRequest(ctx, params.TextDocument.URI, source.UnknownKind)
 	defer release()
 	if !ok {
 		return nil, err
 	}
+	switch snapshot.View().FileKind(fh) {
+	case source.Go:
+	case source.Gop:
+		return s.gopSignatureHelp(ctx, snapshot, fh, params.Position)
+	default:
+		return nil, nil
+	}
 	info, activeParameter, err := source.SignatureHelp(ctx, snapshot, fh, params.Position)
 	if err != nil {
 		event.Error(ctx, "no signature help", err, tag.Position.Of(params.Position))
@@ -29,3 +36,16 @@ func (s *Server) signatureHelp(ctx context.Context, params *protocol.SignatureHe
 		ActiveParameter: uint32(activeParameter),
 	}, nil
 }
+
+func (s *Server) gopSignatureHelp(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, position protocol.Position) (*protocol.SignatureHelp, error) {
+	signatures, activeSignature, activeParameter, err := source.GopSignatureHelp(ctx, snapshot, fh, position)
+	if err != nil {
+		event.Error(ctx, "no signature help", err, tag.Position.Of(position))
+		return nil, nil
+	}
+	return &protocol.SignatureHelp{
+		Signatures:      signatures,
+		ActiveSignature: uint32(activeSignature),
+		ActiveParameter: uint32(activeParameter),
+	}, nil
+}
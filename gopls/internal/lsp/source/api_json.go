@@ -282,6 +282,16 @@ var GeneratedAPIJSON = &APIJSON{
 							Doc:     "find structs that would use less memory if their fields were sorted\n\nThis analyzer find structs that can be rearranged to use less memory, and provides\na suggested edit with the most compact order.\n\nNote that there are two different diagnostics reported. One checks struct size,\nand the other reports \"pointer bytes\" used. Pointer bytes is how many bytes of the\nobject that the garbage collector has to potentially scan for pointers, for example:\n\n\tstruct { uint32; string }\n\nhave 16 pointer bytes because the garbage collector has to scan up through the string's\ninner pointer.\n\n\tstruct { string; *uint32 }\n\nhas 24 pointer bytes because it has to scan further through the *uint32.\n\n\tstruct { string; uint32 }\n\nhas 8 because it can stop immediately after the string pointer.\n\nBe aware that the most compact order is not always the most efficient.\nIn rare cases it may cause two variables each updated by its own goroutine\nto occupy the same CPU cache line, inducing a form of memory contention\nknown as \"false sharing\" that slows down both goroutines.\n",
 							Default: "false",
 						},
+						{
+							Name:    "\"goperrwrap\"",
+							Doc:     "check the use of Go+ error wrapping expressions\n\nThis analyzer reports the uses of expr? in functions whose last result\nis not an error, where there is no error to return:\n\n\tfunc f(s string) int {\n\t\treturn strconv.Atoi(s)?\n\t}\n\nthe uses of expr! in packages other than main, outside of tests: it\npanics if expr fails, which a library should leave to its callers to\ndecide; and the default values of expr?:default that can't be used as the\nvalue of expr:\n\n\tn := strconv.Atoi(s)?:\"0\"\n",
+							Default: "true",
+						},
+						{
+							Name:    "\"goplambda\"",
+							Doc:     "check for Go+ lambdas whose results are ignored\n\nA lambda with an expression body, such as x => x * 2, does nothing but\ncompute its results. This analyzer reports the calls of variables bound\nto such lambdas whose results are ignored:\n\n\tdouble := func(int) int(x => x * 2)\n\tdouble 3 // result of lambda double is ignored\n",
+							Default: "true",
+						},
 						{
 							Name:    "\"httpresponse\"",
 							Doc:     "check for mistakes using HTTP responses\n\nA common mistake when using the net/http package is to defer a function\ncall to close the http.Response Body before checking the error that\ndetermines whether the response is valid:\n\n\tresp, err := http.Head(url)\n\tdefer resp.Body.Close()\n\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n\t// (defer statement belongs here)\n\nThis checker helps uncover latent nil dereference bugs by reporting a\ndiagnostic for such mistakes.",
@@ -557,16 +567,36 @@ var GeneratedAPIJSON = &APIJSON{
 						Doc:     "Enable/disable inlay hints for constant values:\n```go\n\tconst (\n\t\tKindNone   Kind = iota/* = 0*/\n\t\tKindPrint/*  = 1*/\n\t\tKindPrintf/* = 2*/\n\t\tKindErrorf/* = 3*/\n\t)\n```",
 						Default: "false",
 					},
+					{
+						Name:    "\"errWrapTypes\"",
+						Doc:     "Enable/disable inlay hints for the values of Go+ error-wrap expressions:\n```go\n\tn := strconv.Atoi(s)!/* int*/\n```",
+						Default: "false",
+					},
+					{
+						Name:    "\"forPhraseVariableTypes\"",
+						Doc:     "Enable/disable inlay hints for variable types in Go+ comprehensions and for phrases:\n```go\n\t{k/* string*/: v/* int*/ for k, v <- m}\n\tfor x/* int*/ <- c {\n\t\tprintln x\n\t}\n```",
+						Default: "false",
+					},
 					{
 						Name:    "\"functionTypeParameters\"",
 						Doc:     "Enable/disable inlay hints for implicit type parameters on generic functions:\n```go\n\tmyFoo/*[int, string]*/(1, \"hello\")\n```",
 						Default: "false",
 					},
+					{
+						Name:    "\"lambdaTypes\"",
+						Doc:     "Enable/disable inlay hints for parameter and result types of Go+ lambdas:\n```go\n\tapply(x/* int*/ /*int */=> x * 2)\n```",
+						Default: "false",
+					},
 					{
 						Name:    "\"parameterNames\"",
 						Doc:     "Enable/disable inlay hints for parameter names:\n```go\n\tparseInt(/* str: */ \"123\", /* radix: */ 8)\n```",
 						Default: "false",
 					},
+					{
+						Name:    "\"rangeExprSteps\"",
+						Doc:     "Enable/disable inlay hints for the implicit step of Go+ range expressions:\n```go\n\tfor i <- 0:10/*:1*/ {\n\t\tprintln i\n\t}\n```",
+						Default: "false",
+					},
 					{
 						Name:    "\"rangeVariableTypes\"",
 						Doc:     "Enable/disable inlay hints for variable types in range statements:\n```go\n\tfor k/* int*/, v/* string*/ := range []string{} {\n\t\tfmt.Println(k, v)\n\t}\n```",
@@ -594,6 +624,21 @@ var GeneratedAPIJSON = &APIJSON{
 							Doc:     "Runs `go generate` for a given directory.",
 							Default: "true",
 						},
+						{
+							Name:    "\"gop_gen_go\"",
+							Doc:     "Runs `gop go` for the package of a Go+ file: writes the Go code that\nits Go+ files compile to, gop_autogen.go, to the package directory.",
+							Default: "false",
+						},
+						{
+							Name:    "\"gop_mod_tidy\"",
+							Doc:     "Runs `gop mod tidy` for the module of a gop.mod file: updates the\nrequirements of the gop.mod file, regenerates the Go files of the\nmodule's Go+ packages and runs `go mod tidy`.",
+							Default: "true",
+						},
+						{
+							Name:    "\"gop_test\"",
+							Doc:     "Runs `gop test` for a specific set of test or benchmark functions of a\nGo+ package, streaming the output as progress.",
+							Default: "false",
+						},
 						{
 							Name:    "\"regenerate_cgo\"",
 							Doc:     "Regenerates cgo definitions.",
@@ -626,7 +671,7 @@ var GeneratedAPIJSON = &APIJSON{
 						},
 					},
 				},
-				Default:   "{\"gc_details\":false,\"generate\":true,\"regenerate_cgo\":true,\"tidy\":true,\"upgrade_dependency\":true,\"vendor\":true}",
+				Default:   "{\"gc_details\":false,\"generate\":true,\"gop_gen_go\":false,\"gop_mod_tidy\":true,\"gop_test\":false,\"regenerate_cgo\":true,\"tidy\":true,\"upgrade_dependency\":true,\"vendor\":true}",
 				Hierarchy: "ui",
 			},
 			{
@@ -667,6 +712,13 @@ var GeneratedAPIJSON = &APIJSON{
 				Default:   "false",
 				Hierarchy: "formatting",
 			},
+			{
+				Name:      "gopstyle",
+				Type:      "bool",
+				Doc:       "gopstyle indicates if we should rewrite Go+ files in Go+ style when\nformatting them, for example turning `fmt.Println(x)` into\n`println x`.\n",
+				Default:   "false",
+				Hierarchy: "formatting",
+			},
 			{
 				Name:    "verboseOutput",
 				Type:    "bool",
@@ -738,6 +790,30 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "Runs `go get` to fetch a package.",
 			ArgDoc:  "{\n\t// Any document URI within the relevant module.\n\t\"URI\": string,\n\t// The package to go get.\n\t\"Pkg\": string,\n\t\"AddRequire\": bool,\n}",
 		},
+		{
+			Command: "gopls.gop_gen_go",
+			Title:   "Run gop go",
+			Doc:     "Runs `gop go` for the package of a Go+ file: writes the Go code that\nits Go+ files compile to, gop_autogen.go, to the package directory.",
+			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
+		},
+		{
+			Command: "gopls.gop_mod_tidy",
+			Title:   "Run gop mod tidy",
+			Doc:     "Runs `gop mod tidy` for the module of a gop.mod file: updates the\nrequirements of the gop.mod file, regenerates the Go files of the\nmodule's Go+ packages and runs `go mod tidy`.",
+			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
+		},
+		{
+			Command: "gopls.gop_show_go",
+			Title:   "Show generated Go code",
+			Doc:     "Opens the Go code that the Go+ files of a package compile to in a\nread-only document, such as gop-go:///path/to/pkg/gop_autogen.go,\nfor the package of a Go+ file. The test files of the package are\ncompiled to gop_autogen_test.go.\n\nThe client gets the content of the document from the nonstandard\ngopls/gopGeneratedGo request, which takes the URI of the document\nas {\"uri\": string} and returns {\"content\": string}. The content\nreflects the unsaved edits of the Go+ files. gopls sends the\nnonstandard gopls/gopGeneratedGoChanged notification, with the\nURI of the document as {\"uri\": string}, when they change, until\nthe client closes the document with textDocument/didClose.",
+			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
+		},
+		{
+			Command: "gopls.gop_test",
+			Title:   "Run Go+ test(s)",
+			Doc:     "Runs `gop test` for a specific set of test or benchmark functions of a\nGo+ package, streaming the output as progress.",
+			ArgDoc:  "{\n\t// The test file containing the tests to run.\n\t\"URI\": string,\n\t// Specific test names to run, e.g. TestFoo.\n\t\"Tests\": []string,\n\t// Specific benchmarks to run, e.g. BenchmarkFoo.\n\t\"Benchmarks\": []string,\n}",
+		},
 		{
 			Command:   "gopls.list_imports",
 			Title:     "List imports of a file and its package",
@@ -838,6 +914,21 @@ var GeneratedAPIJSON = &APIJSON{
 			Title: "Run go generate",
 			Doc:   "Runs `go generate` for a given directory.",
 		},
+		{
+			Lens:  "gop_gen_go",
+			Title: "Run gop go",
+			Doc:   "Runs `gop go` for the package of a Go+ file: writes the Go code that\nits Go+ files compile to, gop_autogen.go, to the package directory.",
+		},
+		{
+			Lens:  "gop_mod_tidy",
+			Title: "Run gop mod tidy",
+			Doc:   "Runs `gop mod tidy` for the module of a gop.mod file: updates the\nrequirements of the gop.mod file, regenerates the Go files of the\nmodule's Go+ packages and runs `go mod tidy`.",
+		},
+		{
+			Lens:  "gop_test",
+			Title: "Run Go+ test(s)",
+			Doc:   "Runs `gop test` for a specific set of test or benchmark functions of a\nGo+ package, streaming the output as progress.",
+		},
 		{
 			Lens:  "regenerate_cgo",
 			Title: "Regenerate cgo",
@@ -934,6 +1025,16 @@ var GeneratedAPIJSON = &APIJSON{
 			Name: "fieldalignment",
 			Doc:  "find structs that would use less memory if their fields were sorted\n\nThis analyzer find structs that can be rearranged to use less memory, and provides\na suggested edit with the most compact order.\n\nNote that there are two different diagnostics reported. One checks struct size,\nand the other reports \"pointer bytes\" used. Pointer bytes is how many bytes of the\nobject that the garbage collector has to potentially scan for pointers, for example:\n\n\tstruct { uint32; string }\n\nhave 16 pointer bytes because the garbage collector has to scan up through the string's\ninner pointer.\n\n\tstruct { string; *uint32 }\n\nhas 24 pointer bytes because it has to scan further through the *uint32.\n\n\tstruct { string; uint32 }\n\nhas 8 because it can stop immediately after the string pointer.\n\nBe aware that the most compact order is not always the most efficient.\nIn rare cases it may cause two variables each updated by its own goroutine\nto occupy the same CPU cache line, inducing a form of memory contention\nknown as \"false sharing\" that slows down both goroutines.\n",
 		},
+		{
+			Name:    "goperrwrap",
+			Doc:     "check the use of Go+ error wrapping expressions\n\nThis analyzer reports the uses of expr? in functions whose last result\nis not an error, where there is no error to return:\n\n\tfunc f(s string) int {\n\t\treturn strconv.Atoi(s)?\n\t}\n\nthe uses of expr! in packages other than main, outside of tests: it\npanics if expr fails, which a library should leave to its callers to\ndecide; and the default values of expr?:default that can't be used as the\nvalue of expr:\n\n\tn := strconv.Atoi(s)?:\"0\"\n",
+			Default: true,
+		},
+		{
+			Name:    "goplambda",
+			Doc:     "check for Go+ lambdas whose results are ignored\n\nA lambda with an expression body, such as x => x * 2, does nothing but\ncompute its results. This analyzer reports the calls of variables bound\nto such lambdas whose results are ignored:\n\n\tdouble := func(int) int(x => x * 2)\n\tdouble 3 // result of lambda double is ignored\n",
+			Default: true,
+		},
 		{
 			Name:    "httpresponse",
 			Doc:     "check for mistakes using HTTP responses\n\nA common mistake when using the net/http package is to defer a function\ncall to close the http.Response Body before checking the error that\ndetermines whether the response is valid:\n\n\tresp, err := http.Head(url)\n\tdefer resp.Body.Close()\n\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n\t// (defer statement belongs here)\n\nThis checker helps uncover latent nil dereference bugs by reporting a\ndiagnostic for such mistakes.",
@@ -1116,14 +1217,30 @@ var GeneratedAPIJSON = &APIJSON{
 			Name: "constantValues",
 			Doc:  "Enable/disable inlay hints for constant values:\n```go\n\tconst (\n\t\tKindNone   Kind = iota/* = 0*/\n\t\tKindPrint/*  = 1*/\n\t\tKindPrintf/* = 2*/\n\t\tKindErrorf/* = 3*/\n\t)\n```",
 		},
+		{
+			Name: "errWrapTypes",
+			Doc:  "Enable/disable inlay hints for the values of Go+ error-wrap expressions:\n```go\n\tn := strconv.Atoi(s)!/* int*/\n```",
+		},
+		{
+			Name: "forPhraseVariableTypes",
+			Doc:  "Enable/disable inlay hints for variable types in Go+ comprehensions and for phrases:\n```go\n\t{k/* string*/: v/* int*/ for k, v <- m}\n\tfor x/* int*/ <- c {\n\t\tprintln x\n\t}\n```",
+		},
 		{
 			Name: "functionTypeParameters",
 			Doc:  "Enable/disable inlay hints for implicit type parameters on generic functions:\n```go\n\tmyFoo/*[int, string]*/(1, \"hello\")\n```",
 		},
+		{
+			Name: "lambdaTypes",
+			Doc:  "Enable/disable inlay hints for parameter and result types of Go+ lambdas:\n```go\n\tapply(x/* int*/ /*int */=> x * 2)\n```",
+		},
 		{
 			Name: "parameterNames",
 			Doc:  "Enable/disable inlay hints for parameter names:\n```go\n\tparseInt(/* str: */ \"123\", /* radix: */ 8)\n```",
 		},
+		{
+			Name: "rangeExprSteps",
+			Doc:  "Enable/disable inlay hints for the implicit step of Go+ range expressions:\n```go\n\tfor i <- 0:10/*:1*/ {\n\t\tprintln i\n\t}\n```",
+		},
 		{
 			Name: "rangeVariableTypes",
 			Doc:  "Enable/disable inlay hints for variable types in range statements:\n```go\n\tfor k/* int*/, v/* string*/ := range []string{} {\n\t\tfmt.Println(k, v)\n\t}\n```",
@@ -91,7 +91,12 @@ func toProtocolIncomingCalls(ctx context.Context, snapshot Snapshot, refs []*Ref
 			return nil, err
 		}
 
-		callItem, err := enclosingNodeCallItem(snapshot, ref.pkg, ref.URI(), ref.ident.NamePos)
+		var callItem protocol.CallHierarchyItem
+		if ref.Kind == Gop { // a Go+ reference has no Go identifier
+			callItem, err = gopEnclosingNodeCallItem(ctx, snapshot, ref.pkg, ref.URI(), ref.spanRange.Start)
+		} else {
+			callItem, err = enclosingNodeCallItem(snapshot, ref.pkg, ref.URI(), ref.ident.NamePos)
+		}
 		if err != nil {
 			event.Error(ctx, "error getting enclosing node", err, tag.Method.Of(ref.Name))
 			continue
@@ -0,0 +1,107 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"path/filepath"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast/astutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/goplus/mod/gopmod"
+)
+
+// gopEnclosingNodeCallItem is enclosingNodeCallItem for a reference in a Go+
+// file. Lambdas count as function literals, and the statements at the top
+// level of the file belong to the entry point that the compiler puts them
+// in, such as main.
+func gopEnclosingNodeCallItem(ctx context.Context, snapshot Snapshot, pkg Package, uri span.URI, pos token.Pos) (protocol.CallHierarchyItem, error) {
+	pgf, err := pkg.GopFile(uri)
+	if err != nil {
+		return protocol.CallHierarchyItem{}, err
+	}
+
+	var funcDecl *gopast.FuncDecl
+	var funcLit gopast.Node // innermost function literal or lambda
+	var litCount int
+	// Find the enclosing function, if any, and the number of func literals in between.
+	path, _ := astutil.GopPathEnclosingInterval(pgf.File, pos, pos)
+outer:
+	for _, node := range path {
+		switch n := node.(type) {
+		case *gopast.FuncDecl:
+			funcDecl = n
+			break outer
+		case *gopast.FuncLit, *gopast.LambdaExpr, *gopast.LambdaExpr2:
+			litCount++
+			if litCount > 1 {
+				continue
+			}
+			funcLit = n
+		}
+	}
+
+	// A file without a package clause has no name to show.
+	var name string
+	kind := protocol.Package
+	nameStart := token.Pos(pgf.Tok.Base())
+	nameEnd := nameStart
+	if f := pgf.File; f.Name != nil {
+		name = f.Name.Name
+		if f.Name.Pos().IsValid() {
+			nameStart, nameEnd = f.Name.Pos(), f.Name.End()
+		}
+	}
+	switch {
+	case funcDecl != nil && funcDecl == GopShadowEntry(pgf.File):
+		var classes map[string]*gopmod.Class
+		if metas, err := snapshot.MetadataForFile(ctx, uri); err == nil && len(metas) > 0 {
+			classes = metas[0].GopClasses
+		}
+		isProj, isClass := GopClassKind(uri.Filename(), pgf.File, classes)
+		name = GopEntrypoint(pgf.File, isProj, isClass)
+		kind = protocol.Function
+		// Without braces, the body spans the statements.
+		nameStart, nameEnd = funcDecl.Body.Pos(), funcDecl.Body.End()
+	case funcDecl != nil:
+		name = funcDecl.Name.Name
+		kind = protocol.Function
+		nameStart, nameEnd = funcDecl.Name.Pos(), funcDecl.Name.End()
+	}
+
+	switch n := funcLit.(type) {
+	case *gopast.FuncLit:
+		nameStart, nameEnd = n.Type.Func, n.Type.Params.Pos()
+		kind = protocol.Function
+	case *gopast.LambdaExpr:
+		nameStart, nameEnd = n.First, n.Rarrow+token.Pos(len("=>"))
+		kind = protocol.Function
+	case *gopast.LambdaExpr2:
+		nameStart, nameEnd = n.First, n.Rarrow+token.Pos(len("=>"))
+		kind = protocol.Function
+	}
+	rng, err := NewMappedRange(pgf.Tok, pgf.Mapper, nameStart, nameEnd).Range()
+	if err != nil {
+		return protocol.CallHierarchyItem{}, err
+	}
+
+	for i := 0; i < litCount; i++ {
+		name += ".func()"
+	}
+
+	return protocol.CallHierarchyItem{
+		Name:           name,
+		Kind:           kind,
+		Tags:           []protocol.SymbolTag{},
+		Detail:         fmt.Sprintf("%s • %s", pkg.PkgPath(), filepath.Base(uri.Filename())),
+		URI:            protocol.DocumentURI(uri),
+		Range:          rng,
+		SelectionRange: rng,
+	}, nil
+}
@@ -139,7 +139,12 @@ func matchTestFunc(fn *ast.FuncDecl, pkg Package, nameRe *regexp.Regexp, paramID
 	if info == nil {
 		return false
 	}
-	obj := info.ObjectOf(fn.Name)
+	return isTestSignature(info.ObjectOf(fn.Name), paramID)
+}
+
+// isTestSignature reports whether obj is a function that takes only a
+// *testing.T, or a *testing.B, as named by paramID.
+func isTestSignature(obj types.Object, paramID string) bool {
 	if obj == nil {
 		return false
 	}
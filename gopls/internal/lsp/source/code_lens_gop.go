@@ -0,0 +1,163 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"go/types"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/command"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+)
+
+// GopLensFuncs returns the supported lensFuncs for Go+ files.
+func GopLensFuncs() map[command.Command]LensFunc {
+	return map[command.Command]LensFunc{
+		command.GopTest:  runGopTestCodeLens,
+		command.GopGenGo: gopGenGoCodeLens,
+	}
+}
+
+func gopGenGoCodeLens(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]protocol.CodeLens, error) {
+	pgf, err := snapshot.ParseGop(ctx, fh, ParseHeader)
+	if err != nil {
+		return nil, err
+	}
+	// Go+ files may have no package clause.
+	var rng protocol.Range
+	if pgf.File.Package.IsValid() {
+		if rng, err = NewMappedRange(pgf.Tok, pgf.Mapper, pgf.File.Package, pgf.File.Package).Range(); err != nil {
+			return nil, err
+		}
+	}
+	cmd, err := command.NewGopGenGoCommand("run gop go", command.URIArg{URI: protocol.URIFromSpanURI(fh.URI())})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeLens{{Range: rng, Command: cmd}}, nil
+}
+
+func runGopTestCodeLens(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]protocol.CodeLens, error) {
+	codeLens := make([]protocol.CodeLens, 0)
+
+	fns, err := GopTestsAndBenchmarks(ctx, snapshot, fh)
+	if err != nil {
+		return nil, err
+	}
+	puri := protocol.URIFromSpanURI(fh.URI())
+	for _, fn := range fns.Tests {
+		cmd, err := command.NewGopTestCommand("run test", command.RunTestsArgs{URI: puri, Tests: []string{fn.Name}})
+		if err != nil {
+			return nil, err
+		}
+		rng := protocol.Range{Start: fn.Rng.Start, End: fn.Rng.Start}
+		codeLens = append(codeLens, protocol.CodeLens{Range: rng, Command: cmd})
+	}
+
+	for _, fn := range fns.Benchmarks {
+		cmd, err := command.NewGopTestCommand("run benchmark", command.RunTestsArgs{URI: puri, Benchmarks: []string{fn.Name}})
+		if err != nil {
+			return nil, err
+		}
+		rng := protocol.Range{Start: fn.Rng.Start, End: fn.Rng.Start}
+		codeLens = append(codeLens, protocol.CodeLens{Range: rng, Command: cmd})
+	}
+
+	if len(fns.Benchmarks) > 0 {
+		// Add a code lens to the top of the file which runs all benchmarks in
+		// the file. Go+ files may have no package clause.
+		var rng protocol.Range
+		if fns.pkgClause != nil {
+			rng = *fns.pkgClause
+		}
+		var benches []string
+		for _, fn := range fns.Benchmarks {
+			benches = append(benches, fn.Name)
+		}
+		cmd, err := command.NewGopTestCommand("run file benchmarks", command.RunTestsArgs{URI: puri, Benchmarks: benches})
+		if err != nil {
+			return nil, err
+		}
+		codeLens = append(codeLens, protocol.CodeLens{Range: rng, Command: cmd})
+	}
+	return codeLens, nil
+}
+
+type gopTestFns struct {
+	testFns
+	pkgClause *protocol.Range // nil if the file has no package clause
+}
+
+// GopTestsAndBenchmarks returns the tests and benchmarks of a Go+ test file,
+// such as foo_test.gop, by the names that go test knows them by.
+//
+// The test and benchmark methods of the class of a test classfile, such as
+// Kai_test.spx, are run by the functions that the compiler generates for
+// them: the method TestJump of Kai_test is the test TestKai_Jump.
+func GopTestsAndBenchmarks(ctx context.Context, snapshot Snapshot, fh FileHandle) (gopTestFns, error) {
+	var out gopTestFns
+
+	if !cl.IsTestFile(fh.URI().Filename()) {
+		return out, nil
+	}
+	pkg, err := snapshot.PackageForFile(ctx, fh.URI(), TypecheckFull, NarrowestPackage)
+	if err != nil {
+		return out, err
+	}
+	pgf, err := pkg.GopFile(fh.URI())
+	if err != nil {
+		return out, err
+	}
+	info := pkg.GetGopTypesInfo()
+	if info == nil {
+		return out, nil
+	}
+	return gopTestsAndBenchmarks(pgf, info, pkg.GopClass(fh.URI()))
+}
+
+// gopTestsAndBenchmarks returns the tests and benchmarks of the Go+ test
+// file pgf, whose class is class if it is a classfile.
+func gopTestsAndBenchmarks(pgf *ParsedGopFile, info *typesutil.Info, class *types.TypeName) (gopTestFns, error) {
+	var out gopTestFns
+	if pgf.File.Package.IsValid() {
+		rng, err := NewMappedRange(pgf.Tok, pgf.Mapper, pgf.File.Package, pgf.File.Package).Range()
+		if err != nil {
+			return out, err
+		}
+		out.pkgClause = &rng
+	}
+
+	for _, d := range pgf.File.Decls {
+		fn, ok := d.(*gopast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := fn.Name.Name
+		if class != nil {
+			if name, ok = cl.ClassTestName(class.Name(), name); !ok {
+				continue
+			}
+		}
+
+		rng, err := NewMappedRange(pgf.Tok, pgf.Mapper, fn.Pos(), fn.End()).Range()
+		if err != nil {
+			return out, err
+		}
+
+		obj := info.Defs[fn.Name]
+		if testRe.MatchString(name) && isTestSignature(obj, "T") {
+			out.Tests = append(out.Tests, testFn{name, rng})
+		}
+
+		if benchmarkRe.MatchString(name) && isTestSignature(obj, "B") {
+			out.Benchmarks = append(out.Benchmarks, testFn{name, rng})
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/goptest"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+)
+
+func TestGopTestsAndBenchmarks(t *testing.T) {
+	const src = `import "testing"
+
+func TestUpper(t *testing.T) {
+}
+
+func Testing(t *testing.T) {
+}
+
+func TestHelper(s string) {
+}
+
+func BenchmarkUpper(b *testing.B) {
+}
+`
+	p, err := goptest.Compile(t, "main", map[string]string{"/foo/bar_test.gop": src}, &cl.Config{NoFileLine: true, NoAutoGenMain: true})
+	if err != nil {
+		t.Fatal("Compile:", err)
+	}
+	uri := span.URIFromPath("/foo/bar_test.gop")
+	pgf := &ParsedGopFile{
+		URI:    uri,
+		File:   p.Files["/foo/bar_test.gop"],
+		Tok:    p.Tok("/foo/bar_test.gop"),
+		Src:    []byte(src),
+		Mapper: protocol.NewColumnMapper(uri, []byte(src)),
+	}
+
+	fns, err := gopTestsAndBenchmarks(pgf, p.Info, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fns.pkgClause != nil {
+		t.Errorf("got package clause %v, want none", *fns.pkgClause)
+	}
+	if len(fns.Tests) != 1 || fns.Tests[0].Name != "TestUpper" || fns.Tests[0].Rng.Start.Line != 2 {
+		t.Errorf("got tests %v, want TestUpper at line 3", fns.Tests)
+	}
+	if len(fns.Benchmarks) != 1 || fns.Benchmarks[0].Name != "BenchmarkUpper" {
+		t.Errorf("got benchmarks %v, want BenchmarkUpper", fns.Benchmarks)
+	}
+}
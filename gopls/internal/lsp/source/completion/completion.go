@@ -229,6 +229,14 @@ type completer struct {
 	// also includes our package scope and the universal scope at the
 	// end.
 	scopes []*types.Scope
+
+	// gopFile is true if the request comes from a Go+ file, see
+	// GopCompletion.
+	gopFile bool
+
+	// commandStyle is true if function candidates should be completed
+	// as Go+ command-style calls, such as `println "hi"`.
+	commandStyle bool
 }
 
 // funcInfo holds info about a function object.
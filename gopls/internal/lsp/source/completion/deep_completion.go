@@ -148,6 +148,12 @@ func (c *completer) deepSearch(ctx context.Context) {
 				continue
 			}
 
+			// Go+ code refers to operator methods and overloads by other
+			// names, see gopObject.
+			if c.gopFile && isGopHiddenName(obj.Name()) {
+				continue
+			}
+
 			// If we want a type name, don't offer non-type name candidates.
 			// However, do offer package names since they can contain type names,
 			// and do offer any candidate without a type since we aren't sure if it
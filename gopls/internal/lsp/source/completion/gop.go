@@ -0,0 +1,590 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"math"
+	"strings"
+	"time"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast/astutil"
+	goptoken "github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+	"github.com/goplus/gox"
+)
+
+// GopCompletion is the counterpart of Completion for Go+ files.
+//
+// The context of the position is found through the path of the syntax
+// nodes enclosing it, and the candidates through the type information
+// recorded by the compiler: the scopes of functions, lambdas and
+// "for x <- container" phrases, the package scope, and the Go+ builtins,
+// such as println, open and lines. Function candidates at the beginning of
+// a statement are completed as command-style calls, such as `println "hi"`.
+func GopCompletion(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, protoPos protocol.Position, protoContext protocol.CompletionContext) ([]CompletionItem, *Selection, error) {
+	ctx, done := event.Start(ctx, "completion.GopCompletion")
+	defer done()
+
+	startTime := time.Now()
+
+	pkg, pgf, err := source.GetParsedGopFile(ctx, snapshot, fh, source.NarrowestPackage)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting file %s for Completion: %w", fh.URI(), err)
+	}
+	gopTypes, info := pkg.GetGopTypes(), pkg.GetGopTypesInfo()
+	if gopTypes == nil || info == nil {
+		return nil, nil, fmt.Errorf("no type information for %s", fh.URI())
+	}
+	pos, err := pgf.Mapper.Pos(protoPos)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path := gopPath(pgf.File, pos)
+	cur := findGopCursor(pgf, path, pos)
+	if cur.inLiteral {
+		// Skip completion inside comments and literals.
+		return nil, nil, nil
+	}
+
+	ident := cur.ident
+	if ident == nil {
+		ident = &ast.Ident{NamePos: pos}
+	}
+
+	opts := snapshot.View().Options()
+	c := &completer{
+		pkg:      gopPackage{pkg},
+		snapshot: snapshot,
+		qf:       gopQualifier(gopTypes),
+		completionContext: completionContext{
+			triggerCharacter: protoContext.TriggerCharacter,
+			triggerKind:      protoContext.TriggerKind,
+		},
+		fh:       fh,
+		filename: fh.URI().Filename(),
+		tokFile:  pgf.Tok,
+		path:     []ast.Node{ident},
+		pos:      pos,
+		seen:     make(map[types.Object]bool),
+		deepState: deepCompletionState{
+			enabled: opts.DeepCompletion,
+		},
+		opts: &completionOptions{
+			matcher:           opts.Matcher,
+			documentation:     opts.CompletionDocumentation && opts.HoverKind != source.NoDocumentation,
+			fullDocumentation: opts.HoverKind == source.FullDocumentation,
+			placeholders:      opts.UsePlaceholders,
+			budget:            opts.CompletionBudget,
+			snippets:          opts.InsertTextFormat == protocol.SnippetTextFormat,
+		},
+		// default to a matcher that always matches
+		matcher:        prefixMatcher(""),
+		methodSetCache: make(map[methodSetKey]*types.MethodSet),
+		mapper:         pgf.Mapper,
+		startTime:      startTime,
+		gopFile:        true,
+	}
+
+	var cancel context.CancelFunc
+	if c.opts.budget == 0 {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		// timeoutDuration is the completion budget remaining. If less than
+		// 10ms, set to 10ms
+		timeoutDuration := time.Until(c.startTime.Add(c.opts.budget))
+		if timeoutDuration < 10*time.Millisecond {
+			timeoutDuration = 10 * time.Millisecond
+		}
+		ctx, cancel = context.WithTimeout(ctx, timeoutDuration)
+	}
+	defer cancel()
+
+	if cur.ident != nil {
+		c.setSurrounding(cur.ident)
+	}
+
+	if cur.selector != nil {
+		c.gopSelector(info, cur.selector)
+	} else {
+		builtins := pkg.GetGopBuiltins()
+		c.scopes = gopScopes(info, path, &cur, gopTypes, builtins)
+		c.commandStyle = cur.stmtStart
+		c.gopLexical(pgf, info, cur, builtins)
+	}
+
+	// Deep search collected candidates and their members for more candidates.
+	c.deepSearch(ctx)
+
+	c.sortItems()
+	return c.items, c.getSurrounding(), nil
+}
+
+// gopSelector adds the members of x, the operand of a selector expression.
+func (c *completer) gopSelector(info *typesutil.Info, x gopast.Expr) {
+	// Is the operand a package name?
+	if id, ok := x.(*gopast.Ident); ok {
+		if pkgName, ok := info.Uses[id].(*types.PkgName); ok {
+			c.packageMembers(pkgName.Imported(), stdScore, nil, c.gopEnqueue())
+			return
+		}
+	}
+
+	tv, ok := info.Types[x]
+	if !ok || tv.Type == nil {
+		return
+	}
+	c.methodsAndFields(tv.Type, tv.Addressable(), nil, c.gopEnqueue())
+}
+
+// gopLexical adds the objects visible at the position, and the Go+
+// keywords that may begin a statement there.
+func (c *completer) gopLexical(pgf *source.ParsedGopFile, info *typesutil.Info, cur gopCursor, builtins *types.Scope) {
+	// Track seen names to avoid showing completions for shadowed objects.
+	// This works since we look at scopes from innermost to outermost.
+	seen := make(map[string]bool)
+	add := func(obj types.Object, score float64) {
+		if obj == nil || seen[obj.Name()] {
+			return
+		}
+		seen[obj.Name()] = true
+		c.deepState.enqueue(candidate{
+			obj:         obj,
+			score:       score,
+			addressable: isVar(obj),
+		})
+	}
+
+	pkgScope := c.pkg.GetTypes().Scope()
+	for i, scope := range c.scopes {
+		// Rank outer scopes lower than inner.
+		score := stdScore * math.Pow(.99, float64(i))
+		if scope == pkgScope {
+			// The code of a classfile may refer to the members of its class
+			// without this, before the objects of the package scope.
+			if class := c.pkg.GopClass(c.fh.URI()); class != nil {
+				for _, obj := range gopClassMembers(class) {
+					if obj, ok := gopObject(obj); ok {
+						add(obj, score)
+					}
+				}
+			}
+		}
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			switch scope {
+			case types.Universe:
+				if obj == types.Universe.Lookup("comparable") {
+					continue
+				}
+			case builtins:
+				obj = gopBuiltin(obj)
+			default:
+				// Local objects may only be used after their declaration,
+				// except for the variables of a "for x <- container" phrase
+				// of a comprehension, which follows its element.
+				if scope != pkgScope && obj.Pos() > c.pos && !cur.phrases[scope] {
+					continue
+				}
+				obj, _ = gopObject(obj)
+			}
+			add(obj, score)
+		}
+
+		if scope == pkgScope {
+			// The compiler doesn't record the file scope, so add the
+			// imports of the file along with the package scope.
+			for _, spec := range pgf.File.Imports {
+				obj := info.Implicits[spec]
+				if spec.Name != nil {
+					obj = info.Defs[spec.Name]
+				}
+				add(obj, score)
+			}
+		}
+	}
+
+	if cur.stmtStart {
+		c.addGopKeywords(cur)
+	}
+}
+
+// gopClassMembers returns the fields and methods of the class of a
+// classfile that its code may refer to, including those it gets from the
+// framework through embedding, such as the methods of spx.Sprite.
+func gopClassMembers(class *types.TypeName) []types.Object {
+	recv := types.NewPointer(class.Type())
+	accessible := func(obj types.Object) bool {
+		return obj.Exported() || obj.Pkg() == class.Pkg()
+	}
+
+	var members []types.Object
+	seen := make(map[*types.Named]bool)
+	var fields func(t types.Type)
+	fields = func(t types.Type) {
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok {
+			if seen[named] {
+				return
+			}
+			seen[named] = true
+		}
+		s, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			return
+		}
+		for i := 0; i < s.NumFields(); i++ {
+			f := s.Field(i)
+			// Skip the fields shadowed by those of shallower depth.
+			if obj, _, _ := types.LookupFieldOrMethod(recv, true, f.Pkg(), f.Name()); obj == f && accessible(f) {
+				members = append(members, f)
+			}
+			if f.Embedded() {
+				fields(f.Type())
+			}
+		}
+	}
+	fields(class.Type())
+
+	mset := types.NewMethodSet(recv)
+	for i := 0; i < mset.Len(); i++ {
+		if m := mset.At(i).Obj(); accessible(m) {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// addGopKeywords offers the keywords that may begin a statement at the
+// position.
+func (c *completer) addGopKeywords(cur gopCursor) {
+	seen := make(map[string]bool)
+	c.addKeywordItems(seen, stdScore, FOR, IF, SWITCH, SELECT, VAR, CONST, TYPE, GO, DEFER)
+	if cur.inFunc {
+		c.addKeywordItems(seen, stdScore, RETURN, GOTO)
+	} else {
+		// Go+ files may mix declarations with the statements of the main
+		// function.
+		c.addKeywordItems(seen, stdScore, FUNC, IMPORT)
+	}
+	if cur.inLoop {
+		c.addKeywordItems(seen, stdScore, BREAK, CONTINUE)
+	}
+}
+
+// gopEnqueue returns a callback that adds the candidates of
+// packageMembers or methodsAndFields to the deep search queue, under the
+// names by which Go+ code refers to them.
+func (c *completer) gopEnqueue() func(candidate) {
+	seen := make(map[string]bool)
+	return func(cand candidate) {
+		obj, ok := gopObject(cand.obj)
+		if !ok || seen[obj.Name()] {
+			return
+		}
+		seen[obj.Name()] = true
+		cand.obj = obj
+		c.deepState.enqueue(cand)
+	}
+}
+
+// gopObject returns the object under which obj is known to Go+ code, and
+// whether Go+ code may refer to obj at all.
+//
+// The operator methods of a type, such as Gop_Add, are hidden, and the
+// overloads of a function, such as NewRange__0 and NewRange__1, are
+// known by their common name, NewRange.
+func gopObject(obj types.Object) (types.Object, bool) {
+	name := obj.Name()
+	if isGopOperatorName(name) {
+		return nil, false
+	}
+	if base, ok := source.GopOverloadBase(name); ok {
+		fn, ok := obj.(*types.Func)
+		if !ok {
+			return nil, false
+		}
+		return types.NewFunc(fn.Pos(), fn.Pkg(), base, fn.Type().(*types.Signature)), true
+	}
+	return obj, true
+}
+
+// isGopHiddenName reports whether Go+ code can't refer to an object by
+// name, see gopObject.
+func isGopHiddenName(name string) bool {
+	_, overload := source.GopOverloadBase(name)
+	return overload || isGopOperatorName(name)
+}
+
+// isGopOperatorName reports whether name is the name of an operator
+// method or of a variable generated by the Go+ compiler, such as Gop_Add,
+// Gopo_Add or _gop_ret.
+func isGopOperatorName(name string) bool {
+	if strings.HasPrefix(name, "_gop") {
+		return true
+	}
+	if !strings.HasPrefix(name, "Gop") {
+		return false
+	}
+	i := strings.IndexByte(name, '_')
+	return i == 3 || i == 4
+}
+
+// gopBuiltin returns the candidate for obj, an object of the Go+ builtin
+// scope, or nil if obj is hidden or a Go builtin.
+//
+// The lowercase builtins of Go+ are overloaded functions that alias
+// functions of other packages, such as fmt.Println. They are turned into
+// functions with the signature of the aliased function.
+func gopBuiltin(obj types.Object) types.Object {
+	name := obj.Name()
+	if types.Universe.Lookup(name) != nil && name != "print" && name != "println" {
+		// The Go builtins are completed from the universe scope, where
+		// their signatures are known.
+		return nil
+	}
+	if isGopHiddenName(name) {
+		return nil
+	}
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	if gox.IsFunc(tn.Type()) {
+		if sig, ok := gopOverloadSignature(tn.Type()); ok {
+			return types.NewFunc(token.NoPos, nil, name, sig)
+		}
+		return nil
+	}
+	switch tn.Type().(type) {
+	case *types.Named, *types.Basic, *types.Interface:
+		return tn
+	}
+	return nil
+}
+
+// gopOverloadSignature returns the signature of an overloaded function of
+// gox that has a single overload.
+func gopOverloadSignature(t types.Type) (sig *types.Signature, ok bool) {
+	defer func() {
+		// gox panics if there is more than one overload.
+		if recover() != nil {
+			sig, ok = nil, false
+		}
+	}()
+	sig, ok = gox.Default(nil, t).(*types.Signature)
+	return sig, ok
+}
+
+// gopQualifier returns a types.Qualifier that omits the name of the Go+
+// package pkg.
+func gopQualifier(pkg *types.Package) types.Qualifier {
+	return func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	}
+}
+
+// gopPackage is a source.Package whose types are those of its Go+ files.
+type gopPackage struct {
+	source.Package
+}
+
+func (p gopPackage) GetTypes() *types.Package {
+	return p.GetGopTypes()
+}
+
+// A gopCursor describes the context of the position of a completion
+// request in a Go+ file.
+type gopCursor struct {
+	// inLiteral is true if the position is inside a comment or a literal.
+	inLiteral bool
+
+	// ident is the identifier containing the position, if any.
+	ident *ast.Ident
+
+	// selector is the operand of the selector expression whose selector
+	// contains the position, if any.
+	selector gopast.Expr
+
+	// stmtStart is true if the identifier, or the position, begins a
+	// statement.
+	stmtStart bool
+
+	inFunc bool // the position is in a function body
+	inLoop bool // the position is in the body of a for statement
+
+	// phrases are the scopes of the "for x <- container" phrases of the
+	// comprehensions enclosing the position, set by gopScopes.
+	phrases map[*types.Scope]bool
+}
+
+// gopPath returns the nodes of f enclosing the character before pos,
+// innermost first. Unlike those enclosing pos, they include the identifier
+// being typed at the end of a script, where the syntax tree ends.
+func gopPath(f *gopast.File, pos token.Pos) []gopast.Node {
+	path, _ := astutil.GopPathEnclosingInterval(f, pos-1, pos)
+	return path
+}
+
+// findGopCursor describes the context of pos in pgf, whose nodes enclosing
+// pos are path.
+func findGopCursor(pgf *source.ParsedGopFile, path []gopast.Node, pos token.Pos) gopCursor {
+	var cur gopCursor
+	for _, cg := range pgf.File.Comments {
+		for _, c := range cg.List {
+			if c.Pos() < pos && pos <= c.End() {
+				cur.inLiteral = true
+				return cur
+			}
+		}
+	}
+	if len(path) == 0 {
+		return cur
+	}
+
+	switch n := path[0].(type) {
+	case *gopast.BasicLit:
+		// An unterminated string extends to the end of the line.
+		quoted := n.Kind == goptoken.STRING || n.Kind == goptoken.CHAR
+		unterminated := quoted && (len(n.Value) < 2 || n.Value[len(n.Value)-1] != n.Value[0])
+		if n.Pos() < pos && (pos < n.End() || unterminated || !quoted) {
+			cur.inLiteral = true
+			return cur
+		}
+	case *gopast.Ident:
+		// The parser makes up a "_" for a missing selector, as in "strings.".
+		if gopIdentInSource(pgf, n) {
+			cur.ident = &ast.Ident{Name: n.Name, NamePos: n.NamePos}
+		}
+		if len(path) > 1 {
+			if sel, ok := path[1].(*gopast.SelectorExpr); ok && sel.Sel == n {
+				cur.selector = sel.X
+			}
+		}
+	case *gopast.SelectorExpr:
+		if pos > n.X.End() {
+			cur.selector = n.X
+		}
+	}
+
+	start := pos
+	if cur.ident != nil {
+		start = cur.ident.Pos()
+	}
+outer:
+	for i, n := range path {
+		switch n.(type) {
+		case *gopast.BlockStmt, *gopast.CaseClause, *gopast.CommClause, *gopast.File:
+			// The position is between statements.
+			cur.stmtStart = i == 0
+			break outer
+		case gopast.Stmt:
+			cur.stmtStart = n.Pos() == start && cur.selector == nil
+			break outer
+		}
+	}
+
+	for _, n := range path {
+		switch n := n.(type) {
+		case *gopast.FuncDecl:
+			// The shadow entry, which holds the statements at the top level
+			// of the file, has no braces.
+			cur.inFunc = cur.inFunc || inGopBody(n.Body, pos) && n.Body.Lbrace.IsValid()
+		case *gopast.FuncLit:
+			cur.inFunc = cur.inFunc || inGopBody(n.Body, pos)
+		case *gopast.LambdaExpr2:
+			cur.inFunc = cur.inFunc || inGopBody(n.Body, pos)
+		case *gopast.ForStmt:
+			cur.inLoop = cur.inLoop || inGopBody(n.Body, pos)
+		case *gopast.RangeStmt:
+			cur.inLoop = cur.inLoop || inGopBody(n.Body, pos)
+		case *gopast.ForPhraseStmt:
+			cur.inLoop = cur.inLoop || inGopBody(n.Body, pos)
+		}
+	}
+	return cur
+}
+
+// inGopBody reports whether pos is in body, which may lack its closing
+// brace.
+func inGopBody(body *gopast.BlockStmt, pos token.Pos) bool {
+	return body != nil && body.Lbrace < pos
+}
+
+// gopIdentInSource reports whether the identifier id appears in the source
+// of pgf, unlike those that the parser makes up.
+func gopIdentInSource(pgf *source.ParsedGopFile, id *gopast.Ident) bool {
+	start := int(id.Pos()) - pgf.Tok.Base()
+	end := start + len(id.Name)
+	return start >= 0 && end <= len(pgf.Src) && string(pgf.Src[start:end]) == id.Name
+}
+
+// gopScopes returns the scopes enclosing the position of cur, whose nodes
+// are path, innermost first, followed by the package scope, the Go+
+// builtin scope and the universe scope. It sets cur.phrases.
+//
+// The innermost scope is that of the innermost node of path that has one,
+// and the enclosing scopes are its parents. The element of a comprehension
+// precedes its phrases, so their scopes enclose it too.
+func gopScopes(info *typesutil.Info, path []gopast.Node, cur *gopCursor, pkg *types.Package, builtins *types.Scope) []*types.Scope {
+	cur.phrases = make(map[*types.Scope]bool)
+	var innermost *types.Scope
+	for _, n := range path {
+		var scope *types.Scope
+		switch n := n.(type) {
+		case *gopast.FuncDecl:
+			scope = info.Scopes[n.Type]
+		case *gopast.FuncLit:
+			scope = info.Scopes[n.Type]
+		case *gopast.ComprehensionExpr:
+			for _, phrase := range n.Fors {
+				if s := info.Scopes[phrase]; s != nil {
+					cur.phrases[s] = true
+					if scope == nil || scopeDepth(s) > scopeDepth(scope) {
+						scope = s
+					}
+				}
+			}
+		default:
+			scope = info.Scopes[n]
+		}
+		if innermost == nil {
+			innermost = scope
+		}
+	}
+
+	var scopes []*types.Scope
+	for s := innermost; s != nil && s != pkg.Scope() && s != types.Universe; s = s.Parent() {
+		scopes = append(scopes, s)
+	}
+	scopes = append(scopes, pkg.Scope())
+	if builtins != nil {
+		scopes = append(scopes, builtins)
+	}
+	return append(scopes, types.Universe)
+}
+
+// scopeDepth returns the number of parents of s.
+func scopeDepth(s *types.Scope) int {
+	n := 0
+	for s = s.Parent(); s != nil; s = s.Parent() {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,171 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"testing"
+
+	gopparser "github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/source"
+)
+
+func TestFindGopCursor(t *testing.T) {
+	tests := []struct {
+		src       string // "‸" marks the position
+		inLiteral bool
+		ident     string
+		selector  string
+		stmtStart bool
+		inFunc    bool
+		inLoop    bool
+	}{
+		{src: `println "hi‸"`, inLiteral: true},
+		{src: `println "hi‸`, inLiteral: true},
+		{src: "// comm‸ent", inLiteral: true},
+		{src: "x := 1.5‸", inLiteral: true},
+		{src: `println "hi"‸`},
+		{src: "prin‸", ident: "prin", stmtStart: true},
+		{src: "x := 1\nprin‸", ident: "prin", stmtStart: true},
+		{src: "x := 1\n‸", stmtStart: true},
+		{src: "func f() {\n\tprin‸", ident: "prin", stmtStart: true, inFunc: true},
+		{src: "func f() {\n\tprin‸\n}", ident: "prin", stmtStart: true, inFunc: true},
+		{src: "func f() {\n\tfor {\n\t\tbr‸\n\t}\n}", ident: "br", stmtStart: true, inFunc: true, inLoop: true},
+		{src: "for x <- [1, 2] {\n\tbr‸\n}", ident: "br", stmtStart: true, inLoop: true},
+		{src: "run => {\n\tret‸\n}", ident: "ret", stmtStart: true, inFunc: true},
+		{src: "x := prin‸", ident: "prin"},
+		{src: "println x‸", ident: "x"},
+		{src: "strings.To‸", ident: "To", selector: "strings"},
+		{src: "strings.‸", selector: "strings"},
+		{src: "println strings.‸", selector: "strings"},
+		{src: "[x‸ for x <- s]", ident: "x"},
+		{src: "f(x => x‸)", ident: "x"},
+	}
+
+	for _, test := range tests {
+		src := strings.Replace(test.src, "‸", "", 1)
+		off := strings.Index(test.src, "‸")
+
+		fset := token.NewFileSet()
+		f, _ := gopparser.ParseFile(fset, "a.gop", src, gopparser.AllErrors|gopparser.ParseComments)
+		var tok *token.File
+		fset.Iterate(func(f *token.File) bool {
+			tok = f
+			return false
+		})
+		pos := tok.Pos(off)
+		cur := findGopCursor(&source.ParsedGopFile{File: f, Tok: tok, Src: []byte(src)}, gopPath(f, pos), pos)
+
+		var ident, selector string
+		if cur.ident != nil {
+			ident = cur.ident.Name
+		}
+		if cur.selector != nil {
+			selector = src[tok.Offset(cur.selector.Pos()):tok.Offset(cur.selector.End())]
+		}
+		if cur.inLiteral != test.inLiteral || ident != test.ident || selector != test.selector || cur.stmtStart != test.stmtStart ||
+			cur.inFunc != test.inFunc || cur.inLoop != test.inLoop {
+			t.Errorf("findGopCursor(%q) = {inLiteral: %t, ident: %q, selector: %q, stmtStart: %t, inFunc: %t, inLoop: %t}, want {%t, %q, %q, %t, %t, %t}",
+				test.src, cur.inLiteral, ident, selector, cur.stmtStart, cur.inFunc, cur.inLoop,
+				test.inLiteral, test.ident, test.selector, test.stmtStart, test.inFunc, test.inLoop)
+		}
+	}
+}
+
+func TestGopHiddenName(t *testing.T) {
+	tests := []struct {
+		name   string
+		hidden bool
+	}{
+		{"println", false},
+		{"Gop_Add", true},
+		{"Gopo_Add", true},
+		{"Gopher", false},
+		{"_gop_ret", true},
+		{"NewRange__0", true},
+		{"Foo__a", true},
+		{"Foo_0", false},
+		{"__0", false},
+	}
+
+	for _, test := range tests {
+		if got := isGopHiddenName(test.name); got != test.hidden {
+			t.Errorf("isGopHiddenName(%q) = %t, want %t", test.name, got, test.hidden)
+		}
+	}
+}
+
+func TestGopClassMembers(t *testing.T) {
+	// The class of Kai.spx as the compiler declares it: it embeds the
+	// sprite type of the framework and a pointer to the project class.
+	const src = `package main
+
+import "example.com/spx"
+
+type MyGame struct {
+	spx.Game
+	score int
+}
+
+func (p *MyGame) reset() {}
+
+type Kai struct {
+	spx.Sprite
+	*MyGame
+	lives int
+	Name  string // shadows spx.Sprite.Name
+}
+
+func (this *Kai) onStart() {}
+`
+	const framework = `package spx
+
+type Game struct{ hidden int }
+
+func (p *Game) Play(name string) {}
+
+type Sprite struct{ Name string }
+
+func (p *Sprite) Say(msg string) {}
+func (p *Sprite) hide()          {}
+func (p *Sprite) Gop_Add(q *Sprite) *Sprite { return p }
+`
+	fset := token.NewFileSet()
+	check := func(path, src string, imp types.Importer) *types.Package {
+		f, err := parser.ParseFile(fset, path+".go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := (&types.Config{Importer: imp}).Check(path, fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkg
+	}
+	spx := check("example.com/spx", framework, importer.Default())
+	pkg := check("main", src, importerFunc(func(string) (*types.Package, error) { return spx, nil }))
+
+	var got []string
+	for _, obj := range gopClassMembers(pkg.Scope().Lookup("Kai").(*types.TypeName)) {
+		if obj, ok := gopObject(obj); ok {
+			got = append(got, obj.Name())
+		}
+	}
+	sort.Strings(got)
+	want := "Game MyGame Name Play Say Sprite lives onStart reset score"
+	if strings.Join(got, " ") != want {
+		t.Errorf("gopClassMembers(Kai) = %s, want %s", strings.Join(got, " "), want)
+	}
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
@@ -92,8 +92,20 @@ func (c *completer) functionCallSnippet(name string, tparams, params []string, s
 		snip.WriteText("]")
 	}
 
+	if c.commandStyle && len(params) > 0 {
+		// A command-style snippet turns "someFun<>" into "someFunc <*i int*>, <*s string*>".
+		snip.WriteText(" ")
+		c.paramsSnippet(params, snip)
+		return
+	}
+
 	snip.WriteText("(")
+	c.paramsSnippet(params, snip)
+	snip.WriteText(")")
+}
 
+// paramsSnippet writes the snippet for the arguments of a function call.
+func (c *completer) paramsSnippet(params []string, snip *snippet.Builder) {
 	if c.opts.placeholders {
 		// A placeholder snippet turns "someFun<>" into "someFunc(<*i int*>, *s string*)".
 		for i, p := range params {
@@ -110,6 +122,4 @@ func (c *completer) functionCallSnippet(name string, tparams, params []string, s
 			snip.WritePlaceholder(nil)
 		}
 	}
-
-	snip.WriteText(")")
 }
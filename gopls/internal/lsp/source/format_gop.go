@@ -0,0 +1,43 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+
+	gopformat "github.com/Deng-Xian-Sheng/goplus-lsp/gop/format"
+	xformat "github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/format"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+)
+
+// FormatGop formats a Go+ file with gop/format. If the gopstyle setting is
+// enabled, the file is then rewritten in Go+ style, turning calls such as
+// fmt.Println(x) into the command-style call println x.
+func FormatGop(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]protocol.TextEdit, error) {
+	ctx, done := event.Start(ctx, "source.FormatGop")
+	defer done()
+
+	pgf, err := snapshot.ParseGop(ctx, fh, ParseFull)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := fh.URI().Filename()
+	formatted, err := gopformat.Source(pgf.Src, filename)
+	if err != nil {
+		return nil, fmt.Errorf("can't format %q: %v", filename, err)
+	}
+	if snapshot.View().Options().Gopstyle {
+		formatted, err = xformat.GopstyleSource(formatted, filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	edits := snapshot.View().Options().ComputeEdits(string(pgf.Src), string(formatted))
+	return ToProtocolEdits(pgf.Mapper, edits)
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+)
+
+// GopGeneratedGoScheme is the URI scheme of the read-only documents that
+// show the Go code compiled from the Go+ files of a package, such as
+// gop-go:///home/user/hello/gop_autogen.go.
+const GopGeneratedGoScheme = "gop-go"
+
+// GopGeneratedGoURI returns the URI of the read-only document for the Go
+// file filename, such as .../gop_autogen.go, generated by the gop command.
+func GopGeneratedGoURI(filename string) protocol.DocumentURI {
+	return protocol.DocumentURI(GopGeneratedGoScheme + strings.TrimPrefix(string(span.URIFromPath(filename)), "file"))
+}
+
+// GopGeneratedGoFilename returns the name of the Go file for a URI returned
+// by GopGeneratedGoURI.
+func GopGeneratedGoFilename(uri protocol.DocumentURI) (string, error) {
+	rest := strings.TrimPrefix(string(uri), GopGeneratedGoScheme+":")
+	if len(rest) == len(uri) {
+		return "", fmt.Errorf("%s is not a %s URI", uri, GopGeneratedGoScheme)
+	}
+	return span.URIFromURI("file:" + rest).Filename(), nil
+}
+
+// GopGeneratedGoFile returns the name of the Go file that the gop command
+// compiles the Go+ file filename to: gop_autogen_test.go for test files, and
+// gop_autogen.go for the others.
+func GopGeneratedGoFile(filename string) string {
+	name := "gop_autogen.go"
+	if cl.IsTestFile(filename) {
+		name = "gop_autogen_test.go"
+	}
+	return filepath.Join(filepath.Dir(filename), name)
+}
+
+// GopGeneratedGo returns the Go code that the Go+ files of the package in
+// the directory of filename compile to, as the gop command would write it
+// to filename, such as .../gop_autogen.go. The code reflects the unsaved
+// edits of the Go+ files. Unlike the gop command, it leaves out the empty
+// main function that gop adds to main packages without one.
+func GopGeneratedGo(ctx context.Context, snapshot Snapshot, filename string) ([]byte, error) {
+	ctx, done := event.Start(ctx, "source.GopGeneratedGo")
+	defer done()
+
+	dir := filepath.Dir(filename)
+	metas, err := snapshot.AllValidMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metas {
+		if m.ForTest != "" || len(m.GopFiles) == 0 || filepath.Dir(m.GopFiles[0].Filename()) != dir {
+			continue
+		}
+		pkg, err := snapshot.PackageForFile(ctx, m.GopFiles[0], TypecheckFull, NarrowestPackage)
+		if err != nil {
+			return nil, err
+		}
+		files, err := pkg.GopGeneratedGo()
+		if err != nil {
+			return nil, err
+		}
+		src, ok := files[filepath.Base(filename)]
+		if !ok {
+			return nil, fmt.Errorf("no Go code is generated for %s", filename)
+		}
+		return src, nil
+	}
+	return nil, fmt.Errorf("no Go+ package in %s", dir)
+}
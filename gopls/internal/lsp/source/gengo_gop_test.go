@@ -0,0 +1,41 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+)
+
+func TestGopGeneratedGoURI(t *testing.T) {
+	for _, test := range []struct {
+		gopFile, goFile string
+		uri             protocol.DocumentURI
+	}{
+		{"/foo/bar.gop", "/foo/gop_autogen.go", "gop-go:///foo/gop_autogen.go"},
+		{"/foo/Kai.spx", "/foo/gop_autogen.go", "gop-go:///foo/gop_autogen.go"},
+		{"/foo/bar_test.gop", "/foo/gop_autogen_test.go", "gop-go:///foo/gop_autogen_test.go"},
+		{"/foo/Kai_test.spx", "/foo/gop_autogen_test.go", "gop-go:///foo/gop_autogen_test.go"},
+		{"/foo bar/a.gop", "/foo bar/gop_autogen.go", "gop-go:///foo%20bar/gop_autogen.go"},
+	} {
+		goFile := GopGeneratedGoFile(filepath.FromSlash(test.gopFile))
+		if goFile != filepath.FromSlash(test.goFile) {
+			t.Errorf("GopGeneratedGoFile(%s) = %s, want %s", test.gopFile, goFile, test.goFile)
+			continue
+		}
+		uri := GopGeneratedGoURI(goFile)
+		if uri != test.uri {
+			t.Errorf("GopGeneratedGoURI(%s) = %s, want %s", goFile, uri, test.uri)
+		}
+		if filename, err := GopGeneratedGoFilename(uri); err != nil || filename != goFile {
+			t.Errorf("GopGeneratedGoFilename(%s) = %s, %v, want %s", uri, filename, err, goFile)
+		}
+	}
+	if _, err := GopGeneratedGoFilename("file:///foo/gop_autogen.go"); err == nil {
+		t.Error("GopGeneratedGoFilename(file:///foo/gop_autogen.go): no error")
+	}
+}
@@ -249,6 +249,10 @@ func HoverIdentifier(ctx context.Context, i *IdentifierInfo) (*HoverJSON, error)
 	ctx, done := event.Start(ctx, "source.Hover")
 	defer done()
 
+	if i.gopDecls != nil {
+		return hoverGop(ctx, i)
+	}
+
 	hoverCtx, err := FindHoverContext(ctx, i.Snapshot, i.pkg, i.Declaration.obj, i.Declaration.node, i.Declaration.fullDecl)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,141 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"go/types"
+	"strings"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+)
+
+// hoverGop is HoverIdentifier for an identifier of a Go+ file, or a Go
+// identifier declared in a Go+ file.
+//
+// The overloads of a function, such as Add__0 and Add__1, are shown as the
+// user wrote them: one declaration of Add per overload, each preceded by
+// its doc comment.
+func hoverGop(ctx context.Context, i *IdentifierInfo) (*HoverJSON, error) {
+	h := &HoverJSON{}
+	switch len(i.gopDecls) {
+	case 0:
+		return h, nil
+	case 1:
+		obj := i.gopDecls[0]
+		comment := gopDocComment(ctx, i, obj)
+		h.Signature = objectString(gopDisplayObject(obj), i.qf, nil)
+		h.FullDocumentation = comment.Text()
+		h.Synopsis = doc.Synopsis(h.FullDocumentation)
+	default:
+		docs := make([]string, len(i.gopDecls))
+		sigs := make([]string, len(i.gopDecls))
+		for j, obj := range i.gopDecls {
+			docs[j] = gopDocComment(ctx, i, obj).Text()
+			sigs[j] = objectString(gopDisplayObject(obj), i.qf, nil)
+		}
+		h.Signature = formatGopOverloads(docs, sigs)
+	}
+	h.SingleLine = objectString(gopDisplayObject(i.gopDecls[0]), i.qf, nil)
+	h.SymbolName, h.LinkPath, h.LinkAnchor = linkData(i.gopDecls[0], nil)
+	if i.Snapshot.View().IsGoPrivatePath(h.LinkPath) {
+		h.LinkPath = ""
+	}
+	return h, nil
+}
+
+// formatGopOverloads returns the signatures of the overloads of a function,
+// each preceded by its doc comment.
+func formatGopOverloads(docs, sigs []string) string {
+	var b strings.Builder
+	for i, sig := range sigs {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		if docs[i] != "" {
+			for _, line := range strings.Split(strings.TrimSuffix(docs[i], "\n"), "\n") {
+				b.WriteString("//")
+				if line != "" {
+					b.WriteString(" " + line)
+				}
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString(sig)
+	}
+	return b.String()
+}
+
+// gopDisplayObject returns obj as Go+ code refers to it: an overload of a
+// function or method, such as Add__0, is renamed to the common name, Add.
+func gopDisplayObject(obj types.Object) types.Object {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return obj
+	}
+	if base, ok := GopOverloadBase(fn.Name()); ok {
+		return types.NewFunc(fn.Pos(), fn.Pkg(), base, fn.Type().(*types.Signature))
+	}
+	return obj
+}
+
+// gopDocComment returns the doc comment of obj, which may be declared in a
+// Go+ file or in a Go file of i's package or of its dependencies.
+func gopDocComment(ctx context.Context, i *IdentifierInfo, obj types.Object) *ast.CommentGroup {
+	if gobj, pgf, _ := gopDeclaration(i.pkg, obj); gobj != nil {
+		return gopDeclDoc(pgf.File, gobj.Pos())
+	}
+	if !obj.Pos().IsValid() {
+		return nil
+	}
+	declPkg, err := FindPackageFromPos(i.pkg, obj.Pos())
+	if err != nil {
+		return nil
+	}
+	node, _ := FindDeclAndField(declPkg.GetSyntax(), obj.Pos())
+	if node == nil {
+		return nil
+	}
+	hoverCtx, err := FindHoverContext(ctx, i.Snapshot, declPkg, obj, node, nil)
+	if err != nil {
+		return nil
+	}
+	return hoverCtx.Comment
+}
+
+// gopDeclDoc returns the doc comment of the top-level declaration of a Go+
+// file at pos.
+func gopDeclDoc(f *gopast.File, pos token.Pos) *ast.CommentGroup {
+	for _, decl := range f.Decls {
+		if pos < decl.Pos() || pos >= decl.End() {
+			continue
+		}
+		switch decl := decl.(type) {
+		case *gopast.FuncDecl:
+			return decl.Doc
+		case *gopast.GenDecl:
+			for _, spec := range decl.Specs {
+				if pos < spec.Pos() || pos >= spec.End() {
+					continue
+				}
+				switch spec := spec.(type) {
+				case *gopast.TypeSpec:
+					if spec.Doc != nil {
+						return spec.Doc
+					}
+				case *gopast.ValueSpec:
+					if spec.Doc != nil {
+						return spec.Doc
+					}
+				}
+			}
+			return decl.Doc
+		}
+	}
+	return nil
+}
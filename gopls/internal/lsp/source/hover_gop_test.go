@@ -0,0 +1,87 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+)
+
+func TestFormatGopOverloads(t *testing.T) {
+	got := formatGopOverloads(
+		[]string{"Add adds ints.\n\nIt doesn't overflow.\n", ""},
+		[]string{"func Add(a int, b int) int", "func Add(a string, b string) string"},
+	)
+	want := `// Add adds ints.
+//
+// It doesn't overflow.
+func Add(a int, b int) int
+
+func Add(a string, b string) string`
+	if got != want {
+		t.Errorf("formatGopOverloads() = %q, want %q", got, want)
+	}
+}
+
+func TestGopDisplayObject(t *testing.T) {
+	pkg := types.NewPackage("foo", "foo")
+	sig := types.NewSignatureType(nil, nil, nil, nil, nil, false)
+	for _, test := range []struct {
+		name, want string
+	}{
+		{"Add__0", "Add"},
+		{"Add__a", "Add"},
+		{"Add_0", "Add_0"},
+		{"Gop_Add", "Gop_Add"},
+	} {
+		obj := gopDisplayObject(types.NewFunc(token.NoPos, pkg, test.name, sig))
+		if obj.Name() != test.want {
+			t.Errorf("gopDisplayObject(%s).Name() = %s, want %s", test.name, obj.Name(), test.want)
+		}
+	}
+}
+
+func TestGopDeclDoc(t *testing.T) {
+	const src = `package foo
+
+// F does nothing.
+func F() {}
+
+// Types.
+type (
+	// T is a type.
+	T int
+	U int
+)
+
+// V is a variable.
+var V = 1
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.gop", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok := fset.File(f.Pos())
+	for _, test := range []struct {
+		decl, want string
+	}{
+		{"func F", "F does nothing.\n"},
+		{"F()", "F does nothing.\n"},
+		{"T int", "T is a type.\n"},
+		{"U int", "Types.\n"},
+		{"V = 1", "V is a variable.\n"},
+		{"package", ""},
+	} {
+		pos := tok.Pos(strings.Index(src, test.decl))
+		if got := gopDeclDoc(f, pos).Text(); got != test.want {
+			t.Errorf("gopDeclDoc(%q) = %q, want %q", test.decl, got, test.want)
+		}
+	}
+}
@@ -45,6 +45,11 @@ type IdentifierInfo struct {
 	// documentation links.
 	enclosing *types.TypeName
 
+	// For identifiers of Go+ files and Go identifiers declared in Go+
+	// files, gopDecls holds the declared objects, such as the overloads
+	// Add__0 and Add__1 of Add.
+	gopDecls []types.Object
+
 	pkg Package
 	qf  types.Qualifier
 }
@@ -80,6 +85,10 @@ func Identifier(ctx context.Context, snapshot Snapshot, fh FileHandle, position
 	ctx, done := event.Start(ctx, "source.Identifier")
 	defer done()
 
+	if snapshot.View().FileKind(fh) == Gop {
+		return gopIdentifier(ctx, snapshot, fh, position)
+	}
+
 	pkg, err := snapshot.PackageForFile(ctx, fh.URI(), TypecheckFull, NarrowestPackage)
 	if err != nil {
 		return nil, err
@@ -179,6 +188,9 @@ func findIdentifier(ctx context.Context, snapshot Snapshot, pkg Package, pgf *Pa
 			// Preserve the first of these objects and treat it as if it were the declaring object.
 			result.Declaration.obj = objs[0]
 			result.Declaration.typeSwitchImplicit = typ
+		} else if obj := gopUndeclared(pkg, path); obj != nil {
+			// A declaration of a Go+ file, without a gop_autogen.go file.
+			result.Declaration.obj = obj
 		} else {
 			// Probably a type error.
 			return nil, fmt.Errorf("%w for ident %v", errNoObjectFound, result.Name)
@@ -187,27 +199,15 @@ func findIdentifier(ctx context.Context, snapshot Snapshot, pkg Package, pgf *Pa
 
 	// Handle builtins separately.
 	if result.Declaration.obj.Parent() == types.Universe {
-		builtin, err := snapshot.BuiltinFile(ctx)
+		builtin, decl, rng, err := builtinDecl(ctx, snapshot, result.Name)
 		if err != nil {
 			return nil, err
 		}
-		builtinObj := builtin.File.Scope.Lookup(result.Name)
-		if builtinObj == nil {
-			return nil, fmt.Errorf("no builtin object for %s", result.Name)
-		}
-		decl, ok := builtinObj.Decl.(ast.Node)
-		if !ok {
-			return nil, fmt.Errorf("no declaration for %s", result.Name)
-		}
 		result.Declaration.node = decl
 		if typeSpec, ok := decl.(*ast.TypeSpec); ok {
 			// Find the GenDecl (which has the doc comments) for the TypeSpec.
 			result.Declaration.fullDecl = findGenDecl(builtin.File, typeSpec)
 		}
-
-		// The builtin package isn't in the dependency graph, so the usual
-		// utilities won't work here.
-		rng := NewMappedRange(builtin.Tok, builtin.Mapper, decl.Pos(), decl.Pos()+token.Pos(len(result.Name)))
 		result.Declaration.MappedRange = append(result.Declaration.MappedRange, rng)
 		return result, nil
 	}
@@ -263,6 +263,15 @@ func findIdentifier(ctx context.Context, snapshot Snapshot, pkg Package, pgf *Pa
 		}
 	}
 
+	// Declarations of Go+ files are known to the Go type checker through the
+	// generated gop_autogen.go file, if at all.
+	if obj, gopf, declPkg := gopDeclaration(pkg, result.Declaration.obj); obj != nil {
+		result.Declaration.obj = obj
+		result.Declaration.MappedRange = append(result.Declaration.MappedRange, gopObjToMappedRange(gopf, declPkg, obj))
+		result.gopDecls = []types.Object{obj}
+		return result, nil
+	}
+
 	rng, err := objToMappedRange(pkg, result.Declaration.obj)
 	if err != nil {
 		return nil, err
@@ -300,6 +309,27 @@ func findIdentifier(ctx context.Context, snapshot Snapshot, pkg Package, pgf *Pa
 	return result, nil
 }
 
+// builtinDecl returns the declaration of the predeclared object name in the
+// builtin file, and the range of its name.
+func builtinDecl(ctx context.Context, snapshot Snapshot, name string) (*ParsedGoFile, ast.Node, MappedRange, error) {
+	builtin, err := snapshot.BuiltinFile(ctx)
+	if err != nil {
+		return nil, nil, MappedRange{}, err
+	}
+	builtinObj := builtin.File.Scope.Lookup(name)
+	if builtinObj == nil {
+		return nil, nil, MappedRange{}, fmt.Errorf("no builtin object for %s", name)
+	}
+	decl, ok := builtinObj.Decl.(ast.Node)
+	if !ok {
+		return nil, nil, MappedRange{}, fmt.Errorf("no declaration for %s", name)
+	}
+	// The builtin package isn't in the dependency graph, so the usual
+	// utilities won't work here.
+	rng := NewMappedRange(builtin.Tok, builtin.Mapper, decl.Pos(), decl.Pos()+token.Pos(len(name)))
+	return builtin, decl, rng, nil
+}
+
 // findGenDecl determines the parent ast.GenDecl for a given ast.Spec.
 func findGenDecl(f *ast.File, spec ast.Spec) *ast.GenDecl {
 	for _, decl := range f.Decls {
@@ -0,0 +1,323 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast/astutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	goptoken "github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+	"github.com/goplus/gox"
+)
+
+// gopIdentifier is Identifier for a position in a Go+ file.
+//
+// Besides identifiers, it resolves the operators of expressions whose
+// operand has an operator method, such as Gop_Add for +. The overloads of a
+// function or method, such as Add__0 and Add__1 for Add, are all reported
+// as declarations of the common name.
+func gopIdentifier(ctx context.Context, snapshot Snapshot, fh FileHandle, position protocol.Position) (*IdentifierInfo, error) {
+	ctx, done := event.Start(ctx, "source.gopIdentifier")
+	defer done()
+
+	pkg, err := snapshot.PackageForFile(ctx, fh.URI(), TypecheckFull, NarrowestPackage)
+	if err != nil {
+		return nil, err
+	}
+	pgf, err := pkg.GopFile(fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	pos, err := pgf.Mapper.Pos(position)
+	if err != nil {
+		return nil, err
+	}
+	info := pkg.GetGopTypesInfo()
+	if info == nil {
+		return nil, ErrNoIdentFound
+	}
+
+	result := &IdentifierInfo{
+		Snapshot: snapshot,
+		pkg:      pkg,
+		qf:       types.RelativeTo(pkg.GetGopTypes()),
+	}
+	var (
+		start, end token.Pos
+		typ        types.Type
+	)
+	if id, obj := gopObjectAt(pgf.File, info, pos); id != nil {
+		result.Name, start, end = id.Name, id.Pos(), id.End()
+		result.Declaration.obj = obj
+		typ = info.TypeOf(id)
+	} else if opPos, op, method := gopOperatorAt(pgf.File, info, pkg.GetGopTypes(), pos); method != nil {
+		result.Name, start, end = op.String(), opPos, opPos+token.Pos(len(op.String()))
+		result.Declaration.obj = method
+	} else {
+		return nil, ErrNoIdentFound
+	}
+	result.MappedRange = NewMappedRange(pgf.Tok, pgf.Mapper, start, end)
+
+	obj := result.Declaration.obj
+	if obj.Parent() == types.Universe {
+		_, decl, rng, err := builtinDecl(ctx, snapshot, obj.Name())
+		if err != nil {
+			return nil, err
+		}
+		result.Declaration.node = decl
+		result.Declaration.MappedRange = append(result.Declaration.MappedRange, rng)
+		return result, nil
+	}
+
+	decls := gopOverloads(obj)
+	if len(decls) == 0 {
+		decls = []types.Object{obj}
+	}
+	for _, obj := range decls {
+		rng, err := gopDeclRange(pkg, obj)
+		if err != nil {
+			return nil, err
+		}
+		result.Declaration.MappedRange = append(result.Declaration.MappedRange, rng)
+	}
+	result.gopDecls = decls
+
+	if typ == nil {
+		return result, nil
+	}
+	result.Type.Object = typeToObject(typ)
+	if result.Type.Object != nil && !hasErrorType(result.Type.Object) {
+		if result.Type.MappedRange, err = gopDeclRange(pkg, result.Type.Object); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// gopObjectAt returns the identifier of file at pos, or just before it,
+// and the object it defines or denotes.
+func gopObjectAt(file *gopast.File, info *typesutil.Info, pos token.Pos) (*gopast.Ident, types.Object) {
+	// The cursor may be just after the identifier.
+	for _, pos := range []token.Pos{pos, pos - 1} {
+		path, _ := astutil.GopPathEnclosingInterval(file, pos, pos)
+		if len(path) == 0 {
+			continue
+		}
+		id, ok := path[0].(*gopast.Ident)
+		if !ok {
+			continue
+		}
+		if obj := info.Defs[id]; obj != nil {
+			return id, obj
+		}
+		if obj := info.Uses[id]; obj != nil {
+			return id, obj
+		}
+	}
+	return nil, nil
+}
+
+// gopOperatorAt returns the operator of file at pos, and the operator
+// method, such as Gop_Add, that implements it for the type of its operand.
+func gopOperatorAt(file *gopast.File, info *typesutil.Info, pkg *types.Package, pos token.Pos) (token.Pos, goptoken.Token, types.Object) {
+	path, _ := astutil.GopPathEnclosingInterval(file, pos, pos)
+	for _, n := range path {
+		var (
+			opPos token.Pos
+			op    goptoken.Token
+			x     gopast.Expr
+			name  string
+		)
+		switch n := n.(type) {
+		case *gopast.BinaryExpr:
+			opPos, op, x, name = n.OpPos, n.Op, n.X, cl.BinaryGopNames[n.Op.String()]
+		case *gopast.UnaryExpr:
+			opPos, op, x, name = n.OpPos, n.Op, n.X, cl.UnaryGopNames[n.Op.String()]
+		default:
+			continue
+		}
+		if name == "" || pos < opPos || pos > opPos+token.Pos(len(op.String())) {
+			continue
+		}
+		t := info.TypeOf(x)
+		if t == nil {
+			return token.NoPos, 0, nil
+		}
+		method, _, _ := types.LookupFieldOrMethod(t, true, pkg, name)
+		if _, ok := method.(*types.Func); !ok {
+			return token.NoPos, 0, nil
+		}
+		return opPos, op, method
+	}
+	return token.NoPos, 0, nil
+}
+
+// gopOverloads returns the functions or methods that obj, the overloaded
+// function or method of a Go+ package, stands for, such as Add__0 and
+// Add__1 for Add. It returns nil if obj isn't overloaded.
+func gopOverloads(obj types.Object) []types.Object {
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		// The Go+ compiler declares an overloaded function as a type name
+		// without a position.
+		if obj.Pos().IsValid() || obj.Pkg() == nil {
+			return nil
+		}
+		var fns []types.Object
+		scope := obj.Pkg().Scope()
+		for _, name := range scope.Names() {
+			if base, ok := GopOverloadBase(name); ok && base == obj.Name() {
+				fns = append(fns, scope.Lookup(name))
+			}
+		}
+		return fns
+	case *types.Func:
+		if sig, ok := obj.Type().(*types.Signature); ok {
+			if fns, ok := gox.CheckOverloadMethod(sig); ok {
+				return fns
+			}
+		}
+	}
+	return nil
+}
+
+// gopDeclRange returns the range of the name of obj, an object of a Go+ or
+// Go file of pkg or of its dependencies.
+func gopDeclRange(pkg Package, obj types.Object) (MappedRange, error) {
+	if gobj, pgf, declPkg := gopDeclaration(pkg, obj); gobj != nil {
+		return gopObjToMappedRange(pgf, declPkg, gobj), nil
+	}
+	return objToMappedRange(pkg, obj)
+}
+
+// gopDeclaration returns the object declared in a Go+ file that obj stands
+// for, with that file and its package: obj itself if it was declared in a
+// Go+ file, or the object of the Go+ type checker that obj was generated
+// from if it was declared in a gop_autogen.go file. Otherwise it returns
+// nil.
+func gopDeclaration(pkg Package, obj types.Object) (types.Object, *ParsedGopFile, Package) {
+	if !obj.Pos().IsValid() {
+		return nil, nil, nil
+	}
+	tokFile := pkg.FileSet().File(obj.Pos())
+	if tokFile == nil {
+		return nil, nil, nil
+	}
+	filename := tokFile.Name()
+	if !strings.HasSuffix(filename, ".go") {
+		pgf, declPkg, err := findGopFileInDeps(pkg, span.URIFromPath(filename))
+		if err != nil {
+			return nil, nil, nil
+		}
+		return obj, pgf, declPkg
+	}
+	if !IsGopAutogenFile(filename) {
+		return nil, nil, nil
+	}
+	_, declPkg, err := findFileInDeps(pkg, span.URIFromPath(filename))
+	if err != nil || declPkg.GetGopTypes() == nil {
+		return nil, nil, nil
+	}
+
+	scope := declPkg.GetGopTypes().Scope()
+	var gobj types.Object
+	if fn, ok := obj.(*types.Func); ok && fn.Type().(*types.Signature).Recv() != nil {
+		recv := fn.Type().(*types.Signature).Recv().Type()
+		if ptr, ok := recv.(*types.Pointer); ok {
+			recv = ptr.Elem()
+		}
+		named, ok := recv.(*types.Named)
+		if !ok {
+			return nil, nil, nil
+		}
+		tn, ok := scope.Lookup(named.Obj().Name()).(*types.TypeName)
+		if !ok {
+			return nil, nil, nil
+		}
+		gobj, _, _ = types.LookupFieldOrMethod(tn.Type(), true, tn.Pkg(), fn.Name())
+	} else if obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope() {
+		gobj = scope.Lookup(obj.Name())
+	}
+	if gobj == nil || !gobj.Pos().IsValid() {
+		return nil, nil, nil
+	}
+	tokFile = pkg.FileSet().File(gobj.Pos())
+	if tokFile == nil {
+		return nil, nil, nil
+	}
+	pgf, err := declPkg.GopFile(span.URIFromPath(tokFile.Name()))
+	if err != nil {
+		return nil, nil, nil
+	}
+	return gobj, pgf, declPkg
+}
+
+// gopObjToMappedRange returns the range of the name of obj, an object of
+// the Go+ type checker declared in pgf, a Go+ file of declPkg.
+func gopObjToMappedRange(pgf *ParsedGopFile, declPkg Package, obj types.Object) MappedRange {
+	// The Go+ compiler declares functions at the func keyword, so use the
+	// identifier that defines obj if there is one.
+	start, end := obj.Pos(), obj.Pos()+token.Pos(len(obj.Name()))
+	if info := declPkg.GetGopTypesInfo(); info != nil {
+		for id, def := range info.Defs {
+			if def == obj {
+				start, end = id.Pos(), id.End()
+				break
+			}
+		}
+	}
+	return NewMappedRange(pgf.Tok, pgf.Mapper, start, end)
+}
+
+// gopUndeclared returns the object of the Go+ type checker for the
+// identifier path[0] of a Go file, if the Go type checker couldn't resolve
+// it because the package has no gop_autogen.go file for its Go+ files.
+func gopUndeclared(pkg Package, path []ast.Node) types.Object {
+	gopTypes := pkg.GetGopTypes()
+	if gopTypes == nil || len(path) < 2 {
+		return nil
+	}
+	ident, ok := path[0].(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	if sel, ok := path[1].(*ast.SelectorExpr); ok && sel.Sel == ident {
+		return nil
+	}
+	return gopTypes.Scope().Lookup(ident.Name)
+}
+
+// findGopFileInDeps is findFileInDeps for Go+ files.
+func findGopFileInDeps(pkg Package, uri span.URI) (*ParsedGopFile, Package, error) {
+	queue := []Package{pkg}
+	seen := make(map[PackageID]bool)
+
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		seen[pkg.ID()] = true
+
+		if pgf, err := pkg.GopFile(uri); err == nil {
+			return pgf, pkg, nil
+		}
+		for _, dep := range pkg.Imports() {
+			if !seen[dep.ID()] {
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("no Go+ file for %s in package %s", uri, pkg.ID())
+}
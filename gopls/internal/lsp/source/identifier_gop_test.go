@@ -0,0 +1,80 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/goptest"
+	"github.com/goplus/gox"
+)
+
+func TestGopObjectAndOperatorAt(t *testing.T) {
+	const src = `type T struct {
+	v int
+}
+
+func (a T) Gop_Add(b T) T {
+	return T{a.v + b.v}
+}
+
+x := T{1} + T{2}
+println x, 1 + 2
+`
+	p, err := goptest.Compile(t, "main", map[string]string{"/foo/bar.gop": src}, nil)
+	if err != nil {
+		t.Fatal("Compile:", err)
+	}
+	fset, f, info, file := p.Fset, p.Files["/foo/bar.gop"], p.Info, p.Tok("/foo/bar.gop")
+	posOf := func(substr string, skip int) token.Pos {
+		off := strings.Index(src, substr)
+		if off < 0 {
+			t.Fatalf("%q not found", substr)
+		}
+		return file.Pos(off + skip)
+	}
+
+	// Identifiers, with the cursor in and just after them, and at the start
+	// of the script, where the compiler declares a main function.
+	for _, pos := range []token.Pos{posOf("x, 1", 0), posOf("x, 1", 1), posOf("x :=", 0)} {
+		id, obj := gopObjectAt(f, info, pos)
+		if id == nil || id.Name != "x" || obj == nil || obj.Name() != "x" {
+			t.Errorf("gopObjectAt(%v) = %v, %v, want x", fset.Position(pos), id, obj)
+		}
+	}
+
+	// An operator with an operator method, and one without.
+	opPos, op, method := gopOperatorAt(f, info, p.Types, posOf("+ T{2}", 0))
+	if method == nil || method.Name() != "Gop_Add" || op.String() != "+" || opPos != posOf("+ T{2}", 0) {
+		t.Errorf("gopOperatorAt(+ T{2}) = %v, %v, %v, want + and Gop_Add", fset.Position(opPos), op, method)
+	}
+	if _, _, method := gopOperatorAt(f, info, p.Types, posOf("+ 2", 0)); method != nil {
+		t.Errorf("gopOperatorAt(1 + 2) = %v, want nil", method)
+	}
+}
+
+func TestGopOverloads(t *testing.T) {
+	pkg := types.NewPackage("foo", "foo")
+	sig := types.NewSignatureType(nil, nil, nil, nil, nil, false)
+	var fns []types.Object
+	for _, name := range []string{"Add__0", "Add__1", "Addition"} {
+		fn := types.NewFunc(token.NoPos, pkg, name, sig)
+		pkg.Scope().Insert(fn)
+		fns = append(fns, fn)
+	}
+	add := gox.NewOverloadFunc(token.NoPos, pkg, "Add", fns[:2]...)
+	pkg.Scope().Insert(add)
+
+	got := gopOverloads(add)
+	if len(got) != 2 || got[0].Name() != "Add__0" || got[1].Name() != "Add__1" {
+		t.Errorf("gopOverloads(Add) = %v, want Add__0 and Add__1", got)
+	}
+	if got := gopOverloads(fns[2]); got != nil {
+		t.Errorf("gopOverloads(Addition) = %v, want nil", got)
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"go/types"
 	"strings"
 
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/lsppos"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
@@ -25,10 +27,17 @@ const (
 
 type InlayHintFunc func(node ast.Node, tmap *lsppos.TokenMapper, info *types.Info, q *types.Qualifier) []protocol.InlayHint
 
+// GopInlayHintFunc is an InlayHintFunc for the nodes of a Go+ file.
+type GopInlayHintFunc func(node gopast.Node, tmap *lsppos.TokenMapper, info *typesutil.Info, q *types.Qualifier) []protocol.InlayHint
+
 type Hint struct {
 	Name string
 	Doc  string
 	Run  InlayHintFunc
+
+	// GopRun is set for the hints of Go+ files; Run is nil if the hint
+	// doesn't apply to Go files.
+	GopRun GopInlayHintFunc
 }
 
 const (
@@ -39,6 +48,11 @@ const (
 	CompositeLiteralTypes      = "compositeLiteralTypes"
 	CompositeLiteralFieldNames = "compositeLiteralFields"
 	FunctionTypeParameters     = "functionTypeParameters"
+
+	LambdaTypes            = "lambdaTypes"
+	ForPhraseVariableTypes = "forPhraseVariableTypes"
+	ErrWrapTypes           = "errWrapTypes"
+	RangeExprSteps         = "rangeExprSteps"
 )
 
 var AllInlayHints = map[string]*Hint{
@@ -77,12 +91,36 @@ var AllInlayHints = map[string]*Hint{
 		Doc:  "Enable/disable inlay hints for implicit type parameters on generic functions:\n```go\n\tmyFoo/*[int, string]*/(1, \"hello\")\n```",
 		Run:  funcTypeParams,
 	},
+	LambdaTypes: {
+		Name:   LambdaTypes,
+		Doc:    "Enable/disable inlay hints for parameter and result types of Go+ lambdas:\n```go\n\tapply(x/* int*/ /*int */=> x * 2)\n```",
+		GopRun: lambdaTypes,
+	},
+	ForPhraseVariableTypes: {
+		Name:   ForPhraseVariableTypes,
+		Doc:    "Enable/disable inlay hints for variable types in Go+ comprehensions and for phrases:\n```go\n\t{k/* string*/: v/* int*/ for k, v <- m}\n\tfor x/* int*/ <- c {\n\t\tprintln x\n\t}\n```",
+		GopRun: forPhraseVariableTypes,
+	},
+	ErrWrapTypes: {
+		Name:   ErrWrapTypes,
+		Doc:    "Enable/disable inlay hints for the values of Go+ error-wrap expressions:\n```go\n\tn := strconv.Atoi(s)!/* int*/\n```",
+		GopRun: errWrapTypes,
+	},
+	RangeExprSteps: {
+		Name:   RangeExprSteps,
+		Doc:    "Enable/disable inlay hints for the implicit step of Go+ range expressions:\n```go\n\tfor i <- 0:10/*:1*/ {\n\t\tprintln i\n\t}\n```",
+		GopRun: rangeExprSteps,
+	},
 }
 
 func InlayHint(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) ([]protocol.InlayHint, error) {
 	ctx, done := event.Start(ctx, "source.InlayHint")
 	defer done()
 
+	if snapshot.View().FileKind(fh) == Gop {
+		return gopInlayHint(ctx, snapshot, fh, pRng)
+	}
+
 	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
 	if err != nil {
 		return nil, fmt.Errorf("getting file for InlayHint: %w", err)
@@ -95,7 +133,7 @@ func InlayHint(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng proto
 		if !enabled {
 			continue
 		}
-		if h, ok := AllInlayHints[hint]; ok {
+		if h, ok := AllInlayHints[hint]; ok && h.Run != nil {
 			enabledHints = append(enabledHints, h.Run)
 		}
 	}
@@ -0,0 +1,198 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/lsppos"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+)
+
+// gopInlayHint is InlayHint for a Go+ file.
+func gopInlayHint(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) ([]protocol.InlayHint, error) {
+	// Collect a list of the inlay hints that are enabled.
+	var enabledHints []GopInlayHintFunc
+	for hint, enabled := range snapshot.View().Options().InlayHintOptions.Hints {
+		if !enabled {
+			continue
+		}
+		if h, ok := AllInlayHints[hint]; ok && h.GopRun != nil {
+			enabledHints = append(enabledHints, h.GopRun)
+		}
+	}
+	if len(enabledHints) == 0 {
+		return nil, nil
+	}
+
+	pkg, err := snapshot.PackageForFile(ctx, fh.URI(), TypecheckFull, NarrowestPackage)
+	if err != nil {
+		return nil, fmt.Errorf("getting file for InlayHint: %w", err)
+	}
+	pgf, err := pkg.GopFile(fh.URI())
+	if err != nil {
+		return nil, fmt.Errorf("getting file for InlayHint: %w", err)
+	}
+	info := pkg.GetGopTypesInfo()
+	if info == nil {
+		return nil, nil
+	}
+
+	tmap := lsppos.NewTokenMapper(pgf.Src, pgf.Tok)
+	q := gopQualifier(pkg.GetGopTypes())
+
+	// Set the range to the full file if the range is not valid.
+	start, end := token.Pos(pgf.Tok.Base()), token.Pos(pgf.Tok.Base()+pgf.Tok.Size())
+	if pRng.Start.Line < pRng.End.Line || pRng.Start.Character < pRng.End.Character {
+		// Adjust start and end for the specified range.
+		rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+		if err != nil {
+			return nil, err
+		}
+		start, end = rng.Start, rng.End
+	}
+
+	var hints []protocol.InlayHint
+	gopast.Inspect(pgf.File, func(node gopast.Node) bool {
+		// If not in range, we can stop looking.
+		if node == nil || node.End() < start || node.Pos() > end {
+			return false
+		}
+		for _, fn := range enabledHints {
+			hints = append(hints, fn(node, tmap, info, &q)...)
+		}
+		return true
+	})
+	return hints, nil
+}
+
+// gopQualifier returns a types.Qualifier that omits the name of the Go+
+// package pkg.
+func gopQualifier(pkg *types.Package) types.Qualifier {
+	return func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	}
+}
+
+func lambdaTypes(node gopast.Node, tmap *lsppos.TokenMapper, info *typesutil.Info, q *types.Qualifier) []protocol.InlayHint {
+	var lhs []*gopast.Ident
+	var rarrow token.Pos
+	switch n := node.(type) {
+	case *gopast.LambdaExpr:
+		lhs, rarrow = n.Lhs, n.Rarrow
+	case *gopast.LambdaExpr2:
+		lhs, rarrow = n.Lhs, n.Rarrow
+	default:
+		return nil
+	}
+	sig, ok := info.TypeOf(node.(gopast.Expr)).(*types.Signature)
+	if !ok || sig.Params().Len() != len(lhs) {
+		return nil
+	}
+
+	var hints []protocol.InlayHint
+	for i, id := range lhs {
+		if h := gopTypeHint(id.End(), sig.Params().At(i).Type(), tmap, q); h != nil {
+			hints = append(hints, *h)
+		}
+	}
+	var results string
+	switch sig.Results().Len() {
+	case 0:
+		return hints
+	case 1:
+		results = types.TypeString(sig.Results().At(0).Type(), *q)
+	default:
+		results = types.TypeString(sig.Results(), *q)
+	}
+	pos, ok := tmap.Position(rarrow)
+	if !ok {
+		return hints
+	}
+	return append(hints, protocol.InlayHint{
+		Position:     &pos,
+		Label:        buildLabel(results),
+		Kind:         protocol.Type,
+		PaddingRight: true,
+	})
+}
+
+func forPhraseVariableTypes(node gopast.Node, tmap *lsppos.TokenMapper, info *typesutil.Info, q *types.Qualifier) []protocol.InlayHint {
+	// A ForPhraseStmt is reached through its ForPhrase.
+	fp, ok := node.(*gopast.ForPhrase)
+	if !ok {
+		return nil
+	}
+	var hints []protocol.InlayHint
+	for _, id := range []*gopast.Ident{fp.Key, fp.Value} {
+		if id == nil || id.Name == "_" {
+			continue
+		}
+		obj := info.Defs[id]
+		if obj == nil {
+			continue
+		}
+		if h := gopTypeHint(id.End(), obj.Type(), tmap, q); h != nil {
+			hints = append(hints, *h)
+		}
+	}
+	return hints
+}
+
+func errWrapTypes(node gopast.Node, tmap *lsppos.TokenMapper, info *typesutil.Info, q *types.Qualifier) []protocol.InlayHint {
+	expr, ok := node.(*gopast.ErrWrapExpr)
+	if !ok {
+		return nil
+	}
+	// expr! and expr? of a function returning only an error have no value.
+	typ := info.TypeOf(expr)
+	if t, ok := typ.(*types.Tuple); typ == nil || ok && t.Len() == 0 {
+		return nil
+	}
+	if h := gopTypeHint(expr.TokPos+token.Pos(len(expr.Tok.String())), typ, tmap, q); h != nil {
+		return []protocol.InlayHint{*h}
+	}
+	return nil
+}
+
+func rangeExprSteps(node gopast.Node, tmap *lsppos.TokenMapper, _ *typesutil.Info, _ *types.Qualifier) []protocol.InlayHint {
+	expr, ok := node.(*gopast.RangeExpr)
+	if !ok || expr.Expr3 != nil || expr.Last == nil {
+		return nil
+	}
+	end, ok := tmap.Position(expr.Last.End())
+	if !ok {
+		return nil
+	}
+	return []protocol.InlayHint{{
+		Position: &end,
+		Label:    buildLabel(":1"),
+	}}
+}
+
+// gopTypeHint returns a hint of typ at pos, as variableType does.
+func gopTypeHint(pos token.Pos, typ types.Type, tmap *lsppos.TokenMapper, q *types.Qualifier) *protocol.InlayHint {
+	if typ == nil {
+		return nil
+	}
+	end, ok := tmap.Position(pos)
+	if !ok {
+		return nil
+	}
+	return &protocol.InlayHint{
+		Position:    &end,
+		Label:       buildLabel(types.TypeString(typ, *q)),
+		Kind:        protocol.Type,
+		PaddingLeft: true,
+	}
+}
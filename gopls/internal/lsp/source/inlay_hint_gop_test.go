@@ -0,0 +1,68 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/lsppos"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/goptest"
+)
+
+func TestGopInlayHints(t *testing.T) {
+	const src = `import "strconv"
+
+func apply(f func(int) int) int { return f(1) }
+
+func each(f func(int, string) (int, error)) {}
+
+n := strconv.Atoi("1")!
+println apply(x => x * 2), {k: v for k, v <- {"a": n}}
+each((x, s) => {
+	return x, nil
+})
+for i <- 1:10 {
+	println i
+}
+for i <- 1:10:2 {
+	println i
+}
+`
+	p, err := goptest.Compile(t, "main", map[string]string{"/foo/bar.gop": src}, nil)
+	if err != nil {
+		t.Fatal("Compile:", err)
+	}
+	f, info := p.Files["/foo/bar.gop"], p.Info
+	tmap := lsppos.NewTokenMapper([]byte(src), p.Tok("/foo/bar.gop"))
+	q := gopQualifier(p.Types)
+
+	tests := []struct {
+		hint string
+		want []string // line:column label
+	}{
+		{LambdaTypes, []string{"8:16 int", "8:17 int", "9:8 int", "9:11 string", "9:13 (int, error)"}},
+		{ForPhraseVariableTypes, []string{"8:39 string", "8:42 int", "12:6 int", "15:6 int"}},
+		{ErrWrapTypes, []string{"7:24 int"}},
+		{RangeExprSteps, []string{"12:14 :1"}},
+	}
+	for _, test := range tests {
+		run := AllInlayHints[test.hint].GopRun
+		var got []string
+		gopast.Inspect(f, func(node gopast.Node) bool {
+			if node != nil {
+				for _, h := range run(node, tmap, info, &q) {
+					got = append(got, fmt.Sprintf("%d:%d %s", h.Position.Line+1, h.Position.Character+1, h.Label[0].Value))
+				}
+			}
+			return true
+		})
+		if strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("%s: got hints %q, want %q", test.hint, got, test.want)
+		}
+	}
+}
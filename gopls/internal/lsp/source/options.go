@@ -52,6 +52,8 @@ import (
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/embeddirective"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/fillreturns"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/fillstruct"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/goperrwrap"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/goplambda"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/infertypeargs"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/nonewvars"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/noresultvalues"
@@ -110,6 +112,7 @@ func DefaultOptions() *Options {
 					Work: {},
 					Sum:  {},
 					Tmpl: {},
+					Gop:  {},
 				},
 				SupportedCommands: commands,
 			},
@@ -156,6 +159,9 @@ func DefaultOptions() *Options {
 						string(command.GCDetails):         false,
 						string(command.UpgradeDependency): true,
 						string(command.Vendor):            true,
+						string(command.GopModTidy):        true,
+						string(command.GopTest):           false,
+						string(command.GopGenGo):          false,
 						// TODO(hyangah): enable command.RunGovulncheck.
 					},
 				},
@@ -402,6 +408,11 @@ type FormattingOptions struct {
 
 	// Gofumpt indicates if we should run gofumpt formatting.
 	Gofumpt bool
+
+	// Gopstyle indicates if we should rewrite Go+ files in Go+ style when
+	// formatting them, for example turning `fmt.Println(x)` into
+	// `println x`.
+	Gopstyle bool
 }
 
 type DiagnosticOptions struct {
@@ -861,6 +872,12 @@ func (o *Options) enableAllExperimentMaps() {
 	if _, ok := o.Codelenses[string(command.RunGovulncheck)]; !ok {
 		o.Codelenses[string(command.RunGovulncheck)] = true
 	}
+	if _, ok := o.Codelenses[string(command.GopTest)]; !ok {
+		o.Codelenses[string(command.GopTest)] = true
+	}
+	if _, ok := o.Codelenses[string(command.GopGenGo)]; !ok {
+		o.Codelenses[string(command.GopGenGo)] = true
+	}
 	if _, ok := o.Analyses[unusedparams.Analyzer.Name]; !ok {
 		o.Analyses[unusedparams.Analyzer.Name] = true
 	}
@@ -1084,6 +1101,9 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 			}
 		}
 
+	case "gopstyle":
+		result.setBool(&o.Gopstyle)
+
 	case "semanticTokens":
 		result.setBool(&o.SemanticTokens)
 
@@ -1507,6 +1527,10 @@ func defaultAnalyzers() map[string]*Analyzer {
 		embeddirective.Analyzer.Name:   {Analyzer: embeddirective.Analyzer, Enabled: true},
 		timeformat.Analyzer.Name:       {Analyzer: timeformat.Analyzer, Enabled: true},
 
+		// Go+ analyzers:
+		goperrwrap.Analyzer.Name: {Analyzer: goperrwrap.Analyzer, Enabled: true},
+		goplambda.Analyzer.Name:  {Analyzer: goplambda.Analyzer, Enabled: true},
+
 		// gofmt -s suite:
 		simplifycompositelit.Analyzer.Name: {
 			Analyzer:   simplifycompositelit.Analyzer,
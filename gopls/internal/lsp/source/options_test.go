@@ -49,6 +49,11 @@ func TestSetOption(t *testing.T) {
 			check:     func(o Options) bool { return o.Staticcheck == true },
 			wantError: true, // o.StaticcheckSupported is unset
 		},
+		{
+			name:  "gopstyle",
+			value: true,
+			check: func(o Options) bool { return o.Gopstyle },
+		},
 		{
 			name:  "codelenses",
 			value: map[string]interface{}{"generate": true},
@@ -22,10 +22,11 @@ import (
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
 )
 
-// ReferenceInfo holds information about reference to an identifier in Go source.
+// ReferenceInfo holds information about reference to an identifier in Go or Go+ source.
 type ReferenceInfo struct {
 	Name string
 	MappedRange
+	Kind          FileKind // the language of the reference: Go or Gop
 	ident         *ast.Ident
 	obj           types.Object
 	pkg           Package
@@ -50,6 +51,10 @@ func References(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Posit
 	ctx, done := event.Start(ctx, "source.References")
 	defer done()
 
+	if s.View().FileKind(f) == Gop {
+		return gopReferences(ctx, s, f, pp, includeDeclaration)
+	}
+
 	// Find position of the package name declaration
 	pgf, err := s.ParseGo(ctx, f, ParseFull)
 	if err != nil {
@@ -87,6 +92,7 @@ func References(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Posit
 						refs = append(refs, &ReferenceInfo{
 							Name:        packageName,
 							MappedRange: NewMappedRange(f.Tok, f.Mapper, imp.Pos(), imp.End()),
+							Kind:        Go,
 						})
 					}
 				}
@@ -98,6 +104,7 @@ func References(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Posit
 			refs = append(refs, &ReferenceInfo{
 				Name:        packageName,
 				MappedRange: NewMappedRange(f.Tok, f.Mapper, f.File.Name.Pos(), f.File.Name.End()),
+				Kind:        Go,
 			})
 		}
 
@@ -113,13 +120,23 @@ func References(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Posit
 		return nil, err
 	}
 
-	refs, err := references(ctx, s, qualifiedObjs, includeDeclaration, true, false)
+	refs, searched, err := referencesAndPackages(ctx, s, qualifiedObjs, includeDeclaration, true, false)
 	if err != nil {
 		return nil, err
 	}
+	if refs, err = gopAddReferences(qualifiedObjs[0], searched, refs, includeDeclaration); err != nil {
+		return nil, err
+	}
 
+	sortReferences(refs, includeDeclaration)
+	return refs, nil
+}
+
+// sortReferences sorts refs by file and position, leaving the declaration
+// first if includeDeclaration.
+func sortReferences(refs []*ReferenceInfo, includeDeclaration bool) {
 	toSort := refs
-	if includeDeclaration {
+	if includeDeclaration && len(refs) > 0 {
 		toSort = refs[1:]
 	}
 	sort.Slice(toSort, func(i, j int) bool {
@@ -127,9 +144,8 @@ func References(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Posit
 		if cmp := strings.Compare(string(x.URI()), string(y.URI())); cmp != 0 {
 			return cmp < 0
 		}
-		return x.ident.Pos() < y.ident.Pos()
+		return x.spanRange.Start < y.spanRange.Start
 	})
-	return refs, nil
 }
 
 // references is a helper function to avoid recomputing qualifiedObjsAtProtocolPos.
@@ -138,31 +154,42 @@ func References(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Posit
 // Only the definition-related fields of qualifiedObject are used.
 // (Arguably it should accept a smaller data type.)
 func references(ctx context.Context, snapshot Snapshot, qos []qualifiedObject, includeDeclaration, includeInterfaceRefs, includeEmbeddedRefs bool) ([]*ReferenceInfo, error) {
+	references, _, err := referencesAndPackages(ctx, snapshot, qos, includeDeclaration, includeInterfaceRefs, includeEmbeddedRefs)
+	return references, err
+}
+
+// referencesAndPackages is references that also returns the packages
+// searched for the references to qos, so that the Go+ files of the same
+// packages can be searched without computing them again.
+func referencesAndPackages(ctx context.Context, snapshot Snapshot, qos []qualifiedObject, includeDeclaration, includeInterfaceRefs, includeEmbeddedRefs bool) ([]*ReferenceInfo, []Package, error) {
 	var (
 		references []*ReferenceInfo
 		seen       = make(map[positionKey]bool)
+		searched   []Package
+		seenPkgs   = make(map[PackageID]bool)
 	)
 
 	pos := qos[0].obj.Pos()
 	if pos == token.NoPos {
-		return nil, fmt.Errorf("no position for %s", qos[0].obj) // e.g. error.Error
+		return nil, nil, fmt.Errorf("no position for %s", qos[0].obj) // e.g. error.Error
 	}
 	// Inv: qos[0].pkg != nil, since Pos is valid.
 	// Inv: qos[*].pkg != nil, since all qos are logically the same declaration.
 	filename := qos[0].pkg.FileSet().File(pos).Name()
 	pgf, err := qos[0].pkg.File(span.URIFromPath(filename))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	declIdent, err := findIdentifier(ctx, snapshot, qos[0].pkg, pgf, qos[0].obj.Pos())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// Make sure declaration is the first item in the response.
 	if includeDeclaration {
 		references = append(references, &ReferenceInfo{
 			MappedRange:   declIdent.MappedRange,
 			Name:          qos[0].obj.Name(),
+			Kind:          Go,
 			ident:         declIdent.ident,
 			obj:           qos[0].obj,
 			pkg:           declIdent.pkg,
@@ -177,13 +204,17 @@ func references(ctx context.Context, snapshot Snapshot, qos []qualifiedObject, i
 		if qo.obj.Exported() {
 			reverseDeps, err := snapshot.GetReverseDependencies(ctx, qo.pkg.ID())
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			searchPkgs = append(searchPkgs, reverseDeps...)
 		}
 		// Add the package in which the identifier is declared.
 		searchPkgs = append(searchPkgs, qo.pkg)
 		for _, pkg := range searchPkgs {
+			if !seenPkgs[pkg.ID()] {
+				seenPkgs[pkg.ID()] = true
+				searched = append(searched, pkg)
+			}
 			for ident, obj := range pkg.GetTypesInfo().Uses {
 				// For instantiated objects (as in methods or fields on instantiated
 				// types), we may not have pointer-identical objects but still want to
@@ -215,10 +246,11 @@ func references(ctx context.Context, snapshot Snapshot, qos []qualifiedObject, i
 				seen[key] = true
 				rng, err := posToMappedRange(pkg, ident.Pos(), ident.End())
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				references = append(references, &ReferenceInfo{
 					Name:        ident.Name,
+					Kind:        Go,
 					ident:       ident,
 					pkg:         pkg,
 					obj:         obj,
@@ -237,20 +269,20 @@ func references(ctx context.Context, snapshot Snapshot, qos []qualifiedObject, i
 		// we have complete type information already.
 		declRange, err := declIdent.Range()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		fh, err := snapshot.GetFile(ctx, declIdent.URI())
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		interfaceRefs, err := interfaceReferences(ctx, snapshot, fh, declRange.Start)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		references = append(references, interfaceRefs...)
 	}
 
-	return references, nil
+	return references, searched, nil
 }
 
 // equalOrigin reports whether obj1 and obj2 have equivalent origin object.
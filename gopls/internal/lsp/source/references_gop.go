@@ -0,0 +1,598 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	goptoken "github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+	"github.com/goplus/gox"
+)
+
+// A gopObjKey identifies a package-level object, or a method or field of a
+// package-level type, across the Go and Go+ type checkers, which declare
+// distinct objects for the same declaration: the Go+ type checker compiles
+// the Go files of a package again, and the Go type checker sees the
+// declarations of Go+ files in the gop_autogen.go file generated for them.
+type gopObjKey struct {
+	pkgPath  string
+	typeName string // the receiver or struct type of a method or field
+	name     string
+}
+
+// gopObjKeyOf returns the key of obj, or false if obj is local or builtin.
+func gopObjKeyOf(obj types.Object) (gopObjKey, bool) {
+	if obj.Pkg() == nil {
+		return gopObjKey{}, false
+	}
+	key := gopObjKey{pkgPath: obj.Pkg().Path(), name: obj.Name()}
+	switch obj := obj.(type) {
+	case *types.Func:
+		sig := obj.Type().(*types.Signature)
+		if fns, ok := gox.CheckOverloadMethod(sig); ok && len(fns) > 0 {
+			sig = fns[0].Type().(*types.Signature)
+		}
+		if recv := sig.Recv(); recv != nil {
+			named := gopNamed(recv.Type())
+			if named == nil {
+				return gopObjKey{}, false
+			}
+			key.typeName = named.Obj().Name()
+			return key, true
+		}
+	case *types.Var:
+		if obj.IsField() {
+			owner := gopFieldOwner(obj)
+			if owner == nil {
+				return gopObjKey{}, false
+			}
+			key.typeName = owner.Name()
+			return key, true
+		}
+	}
+	return key, isPackageLevel(obj)
+}
+
+// gopNamed returns the named type of t or of the type t points to, or nil.
+func gopNamed(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+// gopFieldOwner returns the package-level struct type that declares the
+// field v, such as the class of a classfile for its fields, or nil.
+func gopFieldOwner(v *types.Var) *types.TypeName {
+	scope := v.Pkg().Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		st, ok := tn.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i) == v {
+				return tn
+			}
+		}
+	}
+	return nil
+}
+
+// gopOverloadFamily returns the overloads of the function or method obj,
+// named like base__N, such as Add__0 and Add__1 for Add__1.
+func gopOverloadFamily(obj types.Object, base string) []types.Object {
+	var fns []types.Object
+	add := func(fn types.Object) {
+		if b, ok := GopOverloadBase(fn.Name()); ok && b == base {
+			fns = append(fns, fn)
+		}
+	}
+	if fn, ok := obj.(*types.Func); ok && fn.Type().(*types.Signature).Recv() != nil {
+		named := gopNamed(fn.Type().(*types.Signature).Recv().Type())
+		if named == nil {
+			return []types.Object{obj}
+		}
+		if iface, ok := named.Underlying().(*types.Interface); ok {
+			for i := 0; i < iface.NumMethods(); i++ {
+				add(iface.Method(i))
+			}
+		} else {
+			for i := 0; i < named.NumMethods(); i++ {
+				add(named.Method(i))
+			}
+		}
+		return fns
+	}
+	scope := obj.Pkg().Scope()
+	for _, name := range scope.Names() {
+		add(scope.Lookup(name))
+	}
+	return fns
+}
+
+// gopLowerFirst returns name with its first letter in lower case, the
+// spelling that Go+ code may use for the exported names of Go packages,
+// such as strings.toUpper for strings.ToUpper.
+func gopLowerFirst(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
+
+// gopObjRefs finds the references to an object in the Go and Go+ files of
+// the workspace.
+type gopObjRefs struct {
+	fset    *token.FileSet
+	key     gopObjKey         // of the object unless it is local; name is unused
+	local   types.Object      // the object if it is local to a Go+ file
+	members []types.Object    // the overloads of a family, or the object
+	names   map[string]string // the names of the objects that are referred to
+}
+
+// newGopObjRefs returns a gopObjRefs for the references to obj, an object
+// of the Go or Go+ type checker.
+//
+// The overloaded name of a family of overloads, such as Add for Add__0 and
+// Add__1, refers to all of them.
+func newGopObjRefs(fset *token.FileSet, obj types.Object) *gopObjRefs {
+	o := &gopObjRefs{
+		fset:    fset,
+		members: []types.Object{obj},
+		names:   map[string]string{obj.Name(): obj.Name()},
+	}
+	key, ok := gopObjKeyOf(obj)
+	if !ok {
+		o.local = obj
+		return o
+	}
+	o.key = key
+	if fns := gopOverloads(obj); fns != nil {
+		o.members = fns
+		for _, fn := range fns {
+			o.names[fn.Name()] = fn.Name()
+		}
+	} else if base, ok := GopOverloadBase(obj.Name()); ok {
+		o.names[base] = base
+	}
+	return o
+}
+
+// matches reports whether obj is one of the objects.
+func (o *gopObjRefs) matches(obj types.Object) bool {
+	if o.local != nil {
+		return obj == o.local
+	}
+	if _, ok := o.names[obj.Name()]; !ok {
+		return false
+	}
+	key, ok := gopObjKeyOf(obj)
+	return ok && key.pkgPath == o.key.pkgPath && key.typeName == o.key.typeName
+}
+
+// exported reports whether the name of an object is exported.
+func (o *gopObjRefs) exported() bool {
+	for name := range o.names {
+		if token.IsExported(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// gopDeclared reports whether obj is declared in a Go+ file, or in the Go
+// file that the Go+ files of its package compile to.
+func (o *gopObjRefs) gopDeclared(obj types.Object) bool {
+	tf := o.fset.File(obj.Pos())
+	if tf == nil {
+		return false
+	}
+	return !strings.HasSuffix(tf.Name(), ".go") || IsGopAutogenFile(tf.Name())
+}
+
+// A gopRef is an identifier of a Go+ file that refers to an object.
+type gopRef struct {
+	id    *gopast.Ident
+	obj   types.Object
+	isDef bool
+}
+
+// gopRefs returns the identifiers of the Go+ files type checked in info
+// that refer to the objects.
+func (o *gopObjRefs) gopRefs(info *typesutil.Info) []gopRef {
+	var refs []gopRef
+	for i, m := range []map[*gopast.Ident]types.Object{info.Defs, info.Uses} {
+		for id, obj := range m {
+			if obj == nil || !id.Pos().IsValid() || !o.matches(obj) {
+				continue
+			}
+			// Skip the Go files, which the Go+ type checker compiles too.
+			if tf := o.fset.File(id.Pos()); tf == nil || strings.HasSuffix(tf.Name(), ".go") {
+				continue
+			}
+			refs = append(refs, gopRef{id: id, obj: obj, isDef: i == 0})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].id.Pos() < refs[j].id.Pos()
+	})
+	return refs
+}
+
+// searchPackages returns the packages whose files may refer to the
+// objects: the packages that declare them, and their reverse dependencies
+// if they are exported. A local object is only referred to in pkg.
+func (o *gopObjRefs) searchPackages(ctx context.Context, s Snapshot, pkg Package) ([]Package, error) {
+	if o.local != nil {
+		return []Package{pkg}, nil
+	}
+	metas, err := s.AllValidMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pkgs []Package
+	seen := make(map[PackageID]bool)
+	add := func(p Package) {
+		if !seen[p.ID()] {
+			seen[p.ID()] = true
+			pkgs = append(pkgs, p)
+		}
+	}
+	for _, m := range metas {
+		if string(m.PkgPath) != o.key.pkgPath {
+			continue
+		}
+		p, err := s.WorkspacePackageByID(ctx, m.ID)
+		if err != nil {
+			continue // not a workspace package
+		}
+		add(p)
+		if !o.exported() {
+			continue
+		}
+		rdeps, err := s.GetReverseDependencies(ctx, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range rdeps {
+			add(p)
+		}
+	}
+	return pkgs, nil
+}
+
+// goRefs returns the references of the Go files of pkg to the objects of
+// Go+ files, leaving out the gop_autogen.go files that declare them.
+func (o *gopObjRefs) goRefs(pkg Package) ([]*ReferenceInfo, error) {
+	var refs []*ReferenceInfo
+	info := pkg.GetTypesInfo()
+	for i, m := range []map[*ast.Ident]types.Object{info.Defs, info.Uses} {
+		for id, obj := range m {
+			if obj == nil || !o.matches(obj) {
+				continue
+			}
+			tf := o.fset.File(id.Pos())
+			if tf == nil || IsGopAutogenFile(tf.Name()) {
+				continue
+			}
+			rng, err := posToMappedRange(pkg, id.Pos(), id.End())
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, &ReferenceInfo{
+				Name:          id.Name,
+				MappedRange:   rng,
+				Kind:          Go,
+				ident:         id,
+				obj:           obj,
+				pkg:           pkg,
+				isDeclaration: i == 0,
+			})
+		}
+	}
+	return refs, nil
+}
+
+// gopRange returns the range of a Go+ file of pkg between start and end.
+func (o *gopObjRefs) gopRange(pkg Package, start, end token.Pos) (MappedRange, error) {
+	pgf, err := pkg.GopFile(span.URIFromPath(o.fset.File(start).Name()))
+	if err != nil {
+		return MappedRange{}, err
+	}
+	return NewMappedRange(pgf.Tok, pgf.Mapper, start, end), nil
+}
+
+// references returns the references of the Go+ files of pkgs to the
+// objects, including the implicit ones of operators and for phrases. If
+// goRefs, the references of their Go files are included too, for the
+// objects of Go+ files.
+func (o *gopObjRefs) references(pkgs []Package, goRefs bool) ([]*ReferenceInfo, error) {
+	var refs []*ReferenceInfo
+	for _, p := range pkgs {
+		if goRefs {
+			more, err := o.goRefs(p)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, more...)
+		}
+		info := p.GetGopTypesInfo()
+		if info == nil {
+			continue
+		}
+		for _, ref := range o.gopRefs(info) {
+			rng, err := o.gopRange(p, ref.id.Pos(), ref.id.End())
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, &ReferenceInfo{
+				Name:          ref.id.Name,
+				MappedRange:   rng,
+				Kind:          Gop,
+				obj:           ref.obj,
+				pkg:           p,
+				isDeclaration: ref.isDef,
+			})
+		}
+		var files []*gopast.File
+		for _, pgf := range p.GopFiles() {
+			files = append(files, pgf.File)
+		}
+		for _, ref := range o.gopImplicitRefs(p.GetGopTypes(), files, info) {
+			rng, err := o.gopRange(p, ref.start, ref.end)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, &ReferenceInfo{
+				Name:        ref.obj.Name(),
+				MappedRange: rng,
+				Kind:        Gop,
+				obj:         ref.obj,
+				pkg:         p,
+			})
+		}
+	}
+	return refs, nil
+}
+
+// A gopImplicitRef is an operator or for phrase of a Go+ file that calls a
+// method, such as + for Gop_Add and <- for Gop_Enum.
+type gopImplicitRef struct {
+	start, end token.Pos
+	obj        types.Object
+}
+
+// gopImplicitRefs returns the operators and for phrases of files, the Go+
+// files of pkg type checked in info, that call the methods.
+func (o *gopObjRefs) gopImplicitRefs(pkg *types.Package, files []*gopast.File, info *typesutil.Info) []gopImplicitRef {
+	if o.key.typeName == "" {
+		return nil
+	}
+	var refs []gopImplicitRef
+	add := func(pos token.Pos, op string, x gopast.Expr, name string) {
+		if _, ok := o.names[name]; !ok || x == nil {
+			return
+		}
+		t := info.TypeOf(x)
+		if t == nil {
+			return
+		}
+		method, _, _ := types.LookupFieldOrMethod(t, true, pkg, name)
+		if method != nil && o.matches(method) {
+			refs = append(refs, gopImplicitRef{pos, pos + token.Pos(len(op)), method})
+		}
+	}
+	for _, f := range files {
+		gopast.Inspect(f, func(n gopast.Node) bool {
+			switch n := n.(type) {
+			case *gopast.BinaryExpr:
+				if name := cl.BinaryGopNames[n.Op.String()]; name != "" {
+					add(n.OpPos, n.Op.String(), n.X, name)
+				}
+			case *gopast.UnaryExpr:
+				if name := cl.UnaryGopNames[n.Op.String()]; name != "" {
+					add(n.OpPos, n.Op.String(), n.X, name)
+				}
+			case *gopast.ForPhrase: // also reached through a ForPhraseStmt
+				add(n.TokPos, goptoken.ARROW.String(), n.X, "Gop_Enum")
+			}
+			return true
+		})
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].start < refs[j].start
+	})
+	return refs
+}
+
+// gopReferences is References for a position in a Go+ file.
+func gopReferences(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Position, includeDeclaration bool) ([]*ReferenceInfo, error) {
+	ctx, done := event.Start(ctx, "source.gopReferences")
+	defer done()
+
+	pkg, err := s.PackageForFile(ctx, f.URI(), TypecheckFull, NarrowestPackage)
+	if err != nil {
+		return nil, err
+	}
+	pgf, err := pkg.GopFile(f.URI())
+	if err != nil {
+		return nil, err
+	}
+	pos, err := pgf.Mapper.Pos(pp)
+	if err != nil {
+		return nil, err
+	}
+	info := pkg.GetGopTypesInfo()
+	if info == nil {
+		return nil, ErrNoIdentFound
+	}
+	var obj types.Object
+	if id, o := gopObjectAt(pgf.File, info, pos); id != nil {
+		obj = o
+	} else if _, _, method := gopOperatorAt(pgf.File, info, pkg.GetGopTypes(), pos); method != nil {
+		obj = method
+	} else {
+		return nil, ErrNoIdentFound
+	}
+	// Don't return references for builtin types.
+	if obj.Pkg() == nil || obj.Parent() == types.Universe {
+		return nil, nil
+	}
+	if builtins := pkg.GetGopBuiltins(); builtins != nil && builtins.Lookup(obj.Name()) == obj {
+		return nil, nil
+	}
+
+	o := newGopObjRefs(pkg.FileSet(), obj)
+	var refs []*ReferenceInfo
+	gopDeclared := false
+	for _, m := range o.members {
+		if !m.Pos().IsValid() {
+			continue
+		}
+		if o.gopDeclared(m) {
+			gopDeclared = true
+			continue
+		}
+		goObj, declPkg, err := gopGoObject(pkg, m)
+		if err != nil {
+			return nil, err
+		}
+		more, err := references(ctx, s, []qualifiedObject{{obj: goObj, pkg: declPkg}}, includeDeclaration && len(refs) == 0, true, false)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, more...)
+	}
+	pkgs, err := o.searchPackages(ctx, s, pkg)
+	if err != nil {
+		return nil, err
+	}
+	more, err := o.references(pkgs, gopDeclared && o.local == nil)
+	if err != nil {
+		return nil, err
+	}
+	refs = gopMergeReferences(refs, more, includeDeclaration)
+	sortReferences(refs, includeDeclaration)
+	return refs, nil
+}
+
+// gopGoObject returns the object of the Go type checker for m, an object
+// declared in a Go file of pkg or of its dependencies, with the package
+// that declares it.
+func gopGoObject(pkg Package, m types.Object) (types.Object, Package, error) {
+	uri := span.URIFromPath(pkg.FileSet().File(m.Pos()).Name())
+	_, declPkg, err := findFileInDeps(pkg, uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, _ := gopObjKeyOf(m)
+	scope := declPkg.GetTypes().Scope()
+	var obj types.Object
+	if key.typeName == "" {
+		obj = scope.Lookup(m.Name())
+	} else if tn, ok := scope.Lookup(key.typeName).(*types.TypeName); ok {
+		obj, _, _ = types.LookupFieldOrMethod(tn.Type(), true, tn.Pkg(), m.Name())
+	}
+	if obj == nil || obj.Pos() != m.Pos() {
+		return nil, nil, fmt.Errorf("no Go declaration for %s", m.Name())
+	}
+	return obj, declPkg, nil
+}
+
+// gopAddReferences adds the references of Go+ files to refs, the references
+// of Go files to the object of qo found in pkgs. Only the Go+ files of pkgs
+// are searched, so a workspace without Go+ files costs nothing.
+func gopAddReferences(qo qualifiedObject, pkgs []Package, refs []*ReferenceInfo, includeDeclaration bool) ([]*ReferenceInfo, error) {
+	if _, ok := gopObjKeyOf(qo.obj); !ok {
+		return refs, nil
+	}
+	var gopPkgs []Package
+	for _, p := range pkgs {
+		if len(p.GopFiles()) > 0 {
+			gopPkgs = append(gopPkgs, p)
+		}
+	}
+	o := newGopObjRefs(qo.pkg.FileSet(), qo.obj)
+	if o.gopDeclared(qo.obj) {
+		// The object is declared in gop_autogen.go, which stands for the Go+
+		// files that declare it.
+		var goRefs []*ReferenceInfo
+		for _, ref := range refs {
+			if !IsGopAutogenFile(ref.URI().Filename()) {
+				goRefs = append(goRefs, ref)
+			}
+		}
+		refs = goRefs
+	}
+	if len(gopPkgs) == 0 {
+		return refs, nil
+	}
+	more, err := o.references(gopPkgs, false)
+	if err != nil {
+		return nil, err
+	}
+	return gopMergeReferences(refs, more, includeDeclaration), nil
+}
+
+// gopMergeReferences adds more to refs, leaving out the references of the
+// same range, such as for a file of several packages. The first reference
+// is the declaration if includeDeclaration; otherwise declarations are left
+// out.
+func gopMergeReferences(refs, more []*ReferenceInfo, includeDeclaration bool) []*ReferenceInfo {
+	type key struct {
+		uri span.URI
+		rng protocol.Range
+	}
+	seen := make(map[key]bool)
+	add := func(ref *ReferenceInfo) bool {
+		rng, err := ref.Range()
+		if err != nil {
+			return false
+		}
+		k := key{ref.URI(), rng}
+		if seen[k] {
+			return false
+		}
+		seen[k] = true
+		return true
+	}
+	var result []*ReferenceInfo
+	for _, ref := range refs {
+		if add(ref) {
+			result = append(result, ref)
+		}
+	}
+	hasDecl := len(result) > 0 && result[0].isDeclaration
+	for _, ref := range more {
+		if ref.isDeclaration && !includeDeclaration || !add(ref) {
+			continue
+		}
+		if ref.isDeclaration && !hasDecl {
+			result = append([]*ReferenceInfo{ref}, result...)
+			hasDecl = true
+			continue
+		}
+		result = append(result, ref)
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/goptest"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+)
+
+func TestGopImplicitRefs(t *testing.T) {
+	const src = `type T struct {
+	v int
+}
+
+func (a T) Gop_Add(b T) T {
+	return T{a.v + b.v}
+}
+
+func (a T) Gop_Enum(c func(v int)) {
+	c(a.v)
+}
+
+x := T{1} + T{2}
+for v <- x {
+	println v + 1
+}
+println [v for v <- x], x.Gop_Add(x)
+`
+	p, err := goptest.Compile(t, "main", map[string]string{"/foo/bar.gop": src}, nil)
+	if err != nil {
+		t.Fatal("Compile:", err)
+	}
+	fset, files, info := p.Fset, p.List, p.Info
+	tn := p.Types.Scope().Lookup("T").(*types.TypeName)
+
+	tests := []struct {
+		method string
+		lines  []int // the lines of the implicit references
+		op     string
+	}{
+		{"Gop_Add", []int{13}, "+"},
+		{"Gop_Enum", []int{14, 17}, "<-"},
+	}
+	for _, test := range tests {
+		method, _, _ := types.LookupFieldOrMethod(tn.Type(), true, p.Types, test.method)
+		o := newGopObjRefs(fset, method)
+		var got []int
+		for _, ref := range o.gopImplicitRefs(p.Types, files, info) {
+			start, end := fset.Position(ref.start), fset.Position(ref.end)
+			if ref.obj != method || src[start.Offset:end.Offset] != test.op {
+				t.Errorf("%s: got reference to %v at %s", test.method, ref.obj, start)
+			}
+			got = append(got, start.Line)
+		}
+		if !equalInts(got, test.lines) {
+			t.Errorf("%s: got implicit references on lines %v, want %v", test.method, got, test.lines)
+		}
+	}
+
+	// The explicit call and the declaration are found among the identifiers.
+	method, _, _ := types.LookupFieldOrMethod(tn.Type(), true, p.Types, "Gop_Add")
+	if refs := newGopObjRefs(fset, method).gopRefs(info); len(refs) != 2 || !refs[0].isDef {
+		t.Errorf("Gop_Add: got %d references, want the declaration and a call", len(refs))
+	}
+}
+
+func TestGopMergeReferences(t *testing.T) {
+	const src = "a\nb\nc\n"
+	uri := span.URIFromPath("/foo/bar.gop")
+	fset := token.NewFileSet()
+	tok := fset.AddFile(uri.Filename(), -1, len(src))
+	tok.SetLinesForContent([]byte(src))
+	m := protocol.NewColumnMapper(uri, []byte(src))
+	ref := func(line int, isDecl bool) *ReferenceInfo {
+		pos := tok.LineStart(line)
+		return &ReferenceInfo{MappedRange: NewMappedRange(tok, m, pos, pos+1), Kind: Gop, isDeclaration: isDecl}
+	}
+	lines := func(refs []*ReferenceInfo) []int {
+		var lines []int
+		for _, ref := range refs {
+			lines = append(lines, tok.Line(ref.spanRange.Start))
+		}
+		return lines
+	}
+
+	got := lines(gopMergeReferences([]*ReferenceInfo{ref(3, false)}, []*ReferenceInfo{ref(2, false), ref(1, true), ref(3, false)}, true))
+	if want := []int{1, 3, 2}; !equalInts(got, want) {
+		t.Errorf("with declaration: got lines %v, want %v", got, want)
+	}
+	got = lines(gopMergeReferences([]*ReferenceInfo{ref(3, false)}, []*ReferenceInfo{ref(2, false), ref(1, true)}, false))
+	if want := []int{3, 2}; !equalInts(got, want) {
+		t.Errorf("without declaration: got lines %v, want %v", got, want)
+	}
+}
+
+func equalInts(x, y []int) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
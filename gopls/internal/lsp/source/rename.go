@@ -53,6 +53,12 @@ func PrepareRename(ctx context.Context, snapshot Snapshot, f FileHandle, pp prot
 	// Find position of the package name declaration.
 	ctx, done := event.Start(ctx, "source.PrepareRename")
 	defer done()
+
+	if snapshot.View().FileKind(f) == Gop {
+		item, err := gopPrepareRename(ctx, snapshot, f, pp)
+		return item, err, err
+	}
+
 	pgf, err := snapshot.ParseGo(ctx, f, ParseFull)
 	if err != nil {
 		return nil, err, err
@@ -171,6 +177,11 @@ func Rename(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Position,
 	ctx, done := event.Start(ctx, "source.Rename")
 	defer done()
 
+	if s.View().FileKind(f) == Gop {
+		result, err := gopRename(ctx, s, f, pp, newName)
+		return result, false, err
+	}
+
 	pgf, err := s.ParseGo(ctx, f, ParseFull)
 	if err != nil {
 		return nil, false, err
@@ -229,7 +240,7 @@ func Rename(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Position,
 	if err != nil {
 		return nil, false, err
 	}
-	result, err := renameObj(ctx, s, newName, qos)
+	result, err := gopRenameObj(ctx, s, newName, qos)
 	if err != nil {
 		return nil, false, err
 	}
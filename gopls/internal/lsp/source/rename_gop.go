@@ -0,0 +1,517 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+)
+
+// gopRenamer renames an object in the Go and Go+ files that refer to it.
+//
+// The overloads of a function or method, such as Add__0 and Add__1, are
+// renamed together, along with the overloaded name Add that Go+ code calls
+// them by.
+type gopRenamer struct {
+	gopObjRefs // names maps the old names to the new ones
+
+	hadConflicts bool
+	errors       string
+}
+
+// newGopRenamer returns a gopRenamer for renaming obj, an object of the Go
+// or Go+ type checker, to newName.
+func newGopRenamer(fset *token.FileSet, obj types.Object, newName string) (*gopRenamer, error) {
+	if err := checkRenamable(obj); err != nil {
+		return nil, err
+	}
+	if !isValidIdentifier(newName) {
+		return nil, fmt.Errorf("invalid identifier to rename: %q", newName)
+	}
+	r := &gopRenamer{gopObjRefs: gopObjRefs{fset: fset, names: make(map[string]string)}}
+	key, ok := gopObjKeyOf(obj)
+	if !ok {
+		if obj.Name() == newName {
+			return nil, fmt.Errorf("old and new names are the same: %s", newName)
+		}
+		r.local = obj
+		r.members = []types.Object{obj}
+		r.names[obj.Name()] = newName
+		return r, nil
+	}
+	r.key = key
+
+	base, index := obj.Name(), ""
+	r.members = gopOverloads(obj)
+	if r.members == nil {
+		if b, ok := GopOverloadBase(obj.Name()); ok {
+			base, index = b, obj.Name()[len(b):]
+			r.members = gopOverloadFamily(obj, base)
+		}
+	}
+	if r.members == nil {
+		if obj.Name() == newName {
+			return nil, fmt.Errorf("old and new names are the same: %s", newName)
+		}
+		r.members = []types.Object{obj}
+		r.names[obj.Name()] = newName
+		return r, nil
+	}
+
+	// An overload is renamed by the new name of the family, with or without
+	// its index, such as Sum or Sum__1 for Add__1.
+	newBase := strings.TrimSuffix(newName, index)
+	if _, ok := GopOverloadBase(newBase); ok {
+		return nil, fmt.Errorf("can't rename %s to %s: the overloads of %s are renamed together, by a name without index", obj.Name(), newName, base)
+	}
+	if newBase == base {
+		return nil, fmt.Errorf("old and new names are the same: %s", newBase)
+	}
+	r.names[base] = newBase
+	for _, m := range r.members {
+		r.names[m.Name()] = newBase + m.Name()[len(base):]
+	}
+	return r, nil
+}
+
+// errorf reports an error (e.g. conflict) and prevents file modification.
+func (r *gopRenamer) errorf(pos token.Pos, format string, args ...interface{}) {
+	r.hadConflicts = true
+	if pos.IsValid() {
+		r.errors += fmt.Sprintf("%s: ", r.fset.Position(pos))
+	}
+	r.errors += fmt.Sprintf(format, args...) + "\n"
+}
+
+// newText returns the new spelling of an identifier spelled as spelled,
+// which refers to the renamed object name, keeping the lower case spelling
+// of exported names in Go+ code.
+func (r *gopRenamer) newText(spelled, name string) string {
+	to := r.names[name]
+	if spelled != name && spelled == gopLowerFirst(name) {
+		return gopLowerFirst(to)
+	}
+	return to
+}
+
+// checkGop performs safety checks of the renaming of refs, the references
+// of the Go+ files of pkg: the new names must not conflict with other
+// declarations of their scope, shadow references to other objects, or be
+// shadowed by the declarations of inner scopes, such as the parameters of
+// lambdas and the variables of comprehensions.
+func (r *gopRenamer) checkGop(pkg *types.Package, files []*gopast.File, info *typesutil.Info, refs []gopRef) {
+	if len(refs) == 0 {
+		return
+	}
+	samePkg := r.local != nil || pkg.Path() == r.key.pkgPath
+	if !samePkg {
+		for _, ref := range refs {
+			if token.IsExported(ref.obj.Name()) && !token.IsExported(r.names[ref.obj.Name()]) {
+				r.errorf(ref.id.Pos(), "renaming %q to %q would make it unexported", ref.obj.Name(), r.names[ref.obj.Name()])
+				r.errorf(ref.id.Pos(), "\tbreaking references from packages such as %q", pkg.Path())
+				return
+			}
+		}
+		return
+	}
+
+	if r.key.typeName != "" {
+		r.checkGopMember(pkg)
+		return
+	}
+
+	// The objects declared in the lexical scopes of pkg.
+	var froms []types.Object
+	if r.local != nil {
+		froms = append(froms, r.local)
+	} else {
+		for name := range r.names {
+			if obj := pkg.Scope().Lookup(name); obj != nil && r.matches(obj) {
+				froms = append(froms, obj)
+			}
+		}
+		sort.Slice(froms, func(i, j int) bool {
+			return froms[i].Name() < froms[j].Name()
+		})
+	}
+	scopes := newGopScopes(r.fset, files, info, pkg.Scope())
+	for _, from := range froms {
+		b := from.Parent()
+		if b == nil {
+			continue
+		}
+		to := r.names[from.Name()]
+
+		// Check for same-block conflict.
+		if prev := b.Lookup(to); prev != nil && !r.matches(prev) {
+			r.errorf(from.Pos(), "renaming this %s %q to %q", objectKind(from), from.Name(), to)
+			r.errorf(prev.Pos(), "\tconflicts with %s in same block", objectKind(prev))
+			return
+		}
+
+		// Check for sub-block conflict: is there an intervening definition
+		// of the new name between b and some reference to from?
+		for _, ref := range refs {
+			if ref.isDef || ref.obj != from {
+				continue
+			}
+			block := scopes.at(ref.id.Pos())
+			if toBlock, prev := block.LookupParent(r.newText(ref.id.Name, ref.obj.Name()), token.NoPos); prev != nil && !r.matches(prev) && deeper(toBlock, b) {
+				r.errorf(from.Pos(), "renaming this %s %q to %q", objectKind(from), from.Name(), to)
+				r.errorf(ref.id.Pos(), "\twould cause this reference to become shadowed")
+				r.errorf(prev.Pos(), "\tby this intervening %s definition", objectKind(prev))
+				return
+			}
+		}
+
+		// Check for super-block conflict: is the new name, declared in a
+		// superblock of b, referenced from within b?
+		for id, obj := range info.Uses {
+			if obj == nil || id.Name != to || r.matches(obj) || !id.Pos().IsValid() {
+				continue
+			}
+			if tf := r.fset.File(id.Pos()); tf == nil || strings.HasSuffix(tf.Name(), ".go") {
+				continue
+			}
+			block := scopes.at(id.Pos())
+			toBlock, prev := block.LookupParent(to, token.NoPos)
+			if prev != obj || !gopEnclosedBy(block, b) || !deeper(b, toBlock) {
+				continue
+			}
+			r.errorf(from.Pos(), "renaming this %s %q to %q", objectKind(from), from.Name(), to)
+			r.errorf(id.Pos(), "\twould shadow this reference")
+			r.errorf(obj.Pos(), "\tto the %s declared here", objectKind(obj))
+			return
+		}
+	}
+}
+
+// checkGopMember checks that the renamed methods or fields, declared in
+// pkg, don't conflict with the other fields and methods of their type.
+func (r *gopRenamer) checkGopMember(pkg *types.Package) {
+	tn, ok := pkg.Scope().Lookup(r.key.typeName).(*types.TypeName)
+	if !ok {
+		return
+	}
+	var olds []string
+	for old := range r.names {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	for _, old := range olds {
+		from, _, _ := types.LookupFieldOrMethod(tn.Type(), true, pkg, old)
+		if from == nil {
+			continue
+		}
+		to := r.names[old]
+		if prev, _, _ := types.LookupFieldOrMethod(tn.Type(), true, pkg, to); prev != nil && !r.matches(prev) {
+			r.errorf(from.Pos(), "renaming this %s %q to %q", objectKind(from), from.Name(), to)
+			r.errorf(prev.Pos(), "\twould conflict with this %s", objectKind(prev))
+			return
+		}
+	}
+}
+
+// gopEnclosedBy reports whether the scope x is b or is nested in b.
+func gopEnclosedBy(x, b *types.Scope) bool {
+	for ; x != nil; x = x.Parent() {
+		if x == b {
+			return true
+		}
+	}
+	return false
+}
+
+// gopScopes finds the innermost scope at a position of the Go+ files of a
+// package.
+//
+// The scopes that the Go+ type checker records have no position, so they
+// are located by the range of their syntax nodes. The element of a
+// comprehension is in the scope of its last for phrase, even though it
+// comes before it, and the statements of a script are in the scope of the
+// main function, which has no syntax.
+type gopScopes struct {
+	fset   *token.FileSet
+	pkg    *types.Scope
+	ranges []gopScopeRange
+	noPos  []*types.Scope // of the nodes without a position
+}
+
+type gopScopeRange struct {
+	start, end token.Pos
+	scope      *types.Scope
+}
+
+func newGopScopes(fset *token.FileSet, files []*gopast.File, info *typesutil.Info, pkg *types.Scope) *gopScopes {
+	s := &gopScopes{fset: fset, pkg: pkg}
+	for n, scope := range info.Scopes {
+		if !n.Pos().IsValid() {
+			s.noPos = append(s.noPos, scope)
+			continue
+		}
+		s.ranges = append(s.ranges, gopScopeRange{n.Pos(), n.End(), scope})
+	}
+	for _, f := range files {
+		gopast.Inspect(f, func(n gopast.Node) bool {
+			if e, ok := n.(*gopast.ComprehensionExpr); ok && e.Elt != nil && len(e.Fors) > 0 {
+				if scope := info.Scopes[e.Fors[len(e.Fors)-1]]; scope != nil {
+					s.ranges = append(s.ranges, gopScopeRange{e.Elt.Pos(), e.Elt.End(), scope})
+				}
+			}
+			return true
+		})
+	}
+	return s
+}
+
+// at returns the innermost scope at pos.
+func (s *gopScopes) at(pos token.Pos) *types.Scope {
+	var inner *gopScopeRange
+	for i, r := range s.ranges {
+		if r.start <= pos && pos < r.end && (inner == nil || r.end-r.start < inner.end-inner.start) {
+			inner = &s.ranges[i]
+		}
+	}
+	if inner != nil {
+		return inner.scope
+	}
+	// Outside of functions, pos is in a statement of a script if the main
+	// function declares an object of the file.
+	tf := s.fset.File(pos)
+	for _, scope := range s.noPos {
+		for _, name := range scope.Names() {
+			if obj := scope.Lookup(name); obj.Pos().IsValid() && s.fset.File(obj.Pos()) == tf {
+				return scope
+			}
+		}
+	}
+	return s.pkg
+}
+
+// gopRename is Rename for a position in a Go+ file.
+func gopRename(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Position, newName string) (map[span.URI][]protocol.TextEdit, error) {
+	ctx, done := event.Start(ctx, "source.gopRename")
+	defer done()
+
+	pkg, _, obj, err := gopRenameTarget(ctx, s, f, pp)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newGopRenamer(pkg.FileSet(), obj, newName)
+	if err != nil {
+		return nil, err
+	}
+	return r.rename(ctx, s, pkg, nil)
+}
+
+// gopPrepareRename is PrepareRename for a position in a Go+ file.
+func gopPrepareRename(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Position) (*PrepareItem, error) {
+	ctx, done := event.Start(ctx, "source.gopPrepareRename")
+	defer done()
+
+	pkg, id, obj, err := gopRenameTarget(ctx, s, f, pp)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRenamable(obj); err != nil {
+		return nil, err
+	}
+	pgf, err := pkg.GopFile(f.URI())
+	if err != nil {
+		return nil, err
+	}
+	rng, err := NewMappedRange(pgf.Tok, pgf.Mapper, id.Pos(), id.End()).Range()
+	if err != nil {
+		return nil, err
+	}
+	// The placeholder is the declared name, even if the identifier spells
+	// it in lower case.
+	return &PrepareItem{Range: rng, Text: obj.Name()}, nil
+}
+
+// gopRenameTarget returns the identifier at pp of a Go+ file, with its
+// package and the object it refers to.
+func gopRenameTarget(ctx context.Context, s Snapshot, f FileHandle, pp protocol.Position) (Package, *gopast.Ident, types.Object, error) {
+	pkg, err := s.PackageForFile(ctx, f.URI(), TypecheckFull, NarrowestPackage)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pgf, err := pkg.GopFile(f.URI())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pos, err := pgf.Mapper.Pos(pp)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	info := pkg.GetGopTypesInfo()
+	if info == nil {
+		return nil, nil, nil, ErrNoIdentFound
+	}
+	id, obj := gopObjectAt(pgf.File, info, pos)
+	if id == nil {
+		return nil, nil, nil, ErrNoIdentFound
+	}
+	if obj.Pkg() == nil || obj.Parent() == types.Universe {
+		return nil, nil, nil, errBuiltin
+	}
+	if builtins := pkg.GetGopBuiltins(); builtins != nil && builtins.Lookup(obj.Name()) == obj {
+		return nil, nil, nil, errBuiltin
+	}
+	if _, ok := obj.(*types.PkgName); ok {
+		return nil, nil, nil, errors.New("can't rename imported package names in Go+ files")
+	}
+	return pkg, id, obj, nil
+}
+
+// gopRenameObj is renameObj for the objects that Go+ code may refer to:
+// package-level objects and the methods and fields of package-level types.
+func gopRenameObj(ctx context.Context, s Snapshot, newName string, qos []qualifiedObject) (map[span.URI][]protocol.TextEdit, error) {
+	obj := qos[0].obj
+	if _, ok := gopObjKeyOf(obj); !ok {
+		return renameObj(ctx, s, newName, qos)
+	}
+	gop, err := hasGopFiles(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if !gop {
+		return renameObj(ctx, s, newName, qos)
+	}
+	r, err := newGopRenamer(qos[0].pkg.FileSet(), obj, newName)
+	if err != nil {
+		return nil, err
+	}
+	return r.rename(ctx, s, qos[0].pkg, qos)
+}
+
+// rename returns the edits of the Go and Go+ files for the renaming.
+// The Go declarations are renamed by renameObj, which reuses qos for the
+// object of qos[0] if not nil; pkg is the package of a local object.
+func (r *gopRenamer) rename(ctx context.Context, s Snapshot, pkg Package, qos []qualifiedObject) (map[span.URI][]protocol.TextEdit, error) {
+	result := make(map[span.URI][]protocol.TextEdit)
+	seen := make(map[gopEditKey]bool)
+	gopDeclared := false
+	for _, m := range r.members {
+		if !m.Pos().IsValid() {
+			continue
+		}
+		if r.gopDeclared(m) {
+			gopDeclared = true
+			continue
+		}
+		mqos := qos
+		if len(qos) == 0 || qos[0].obj != m {
+			posn := r.fset.Position(m.Pos())
+			var err error
+			mqos, err = qualifiedObjsAtLocation(ctx, s, positionKey{span.URIFromPath(posn.Filename), posn.Offset}, map[positionKey]bool{})
+			if err != nil {
+				return nil, err
+			}
+		}
+		edits, err := renameObj(ctx, s, r.names[m.Name()], mqos)
+		if err != nil {
+			return nil, err
+		}
+		for uri, edits := range edits {
+			for _, edit := range edits {
+				gopAddEdit(result, seen, uri, edit)
+			}
+		}
+	}
+
+	pkgs, err := r.searchPackages(ctx, s, pkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 && (gopDeclared || qos == nil) {
+		return nil, fmt.Errorf("can't rename %s: package %s is not in the workspace", r.members[0].Name(), r.key.pkgPath)
+	}
+	for _, p := range pkgs {
+		// The Go declarations of Go+ files are renamed in the Go+ files, and
+		// referred to by Go files through gop_autogen.go.
+		if gopDeclared && r.local == nil {
+			goRefs, err := r.goRefs(p)
+			if err != nil {
+				return nil, err
+			}
+			for _, ref := range goRefs {
+				rng, err := ref.Range()
+				if err != nil {
+					return nil, err
+				}
+				gopAddEdit(result, seen, ref.URI(), protocol.TextEdit{Range: rng, NewText: r.newText(ref.Name, ref.obj.Name())})
+			}
+		}
+		info := p.GetGopTypesInfo()
+		if info == nil {
+			continue
+		}
+		refs := r.gopRefs(info)
+		var files []*gopast.File
+		for _, pgf := range p.GopFiles() {
+			files = append(files, pgf.File)
+		}
+		r.checkGop(p.GetGopTypes(), files, info, refs)
+		if r.hadConflicts {
+			return nil, errors.New(r.errors)
+		}
+		for _, ref := range refs {
+			mr, err := r.gopRange(p, ref.id.Pos(), ref.id.End())
+			if err != nil {
+				return nil, err
+			}
+			rng, err := mr.Range()
+			if err != nil {
+				return nil, err
+			}
+			gopAddEdit(result, seen, mr.URI(), protocol.TextEdit{Range: rng, NewText: r.newText(ref.id.Name, ref.obj.Name())})
+		}
+	}
+	return result, nil
+}
+
+// gopEditKey identifies the range of an edit.
+type gopEditKey struct {
+	uri span.URI
+	rng protocol.Range
+}
+
+// gopAddEdit adds edit of the file uri to result, unless seen records an
+// edit of the same range, such as for a file of several packages.
+func gopAddEdit(result map[span.URI][]protocol.TextEdit, seen map[gopEditKey]bool, uri span.URI, edit protocol.TextEdit) {
+	key := gopEditKey{uri, edit.Range}
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+	result[uri] = append(result[uri], edit)
+}
+
+// hasGopFiles reports whether a package of the workspace has Go+ files,
+// which only the packages of the workspace modules have.
+func hasGopFiles(ctx context.Context, s Snapshot) (bool, error) {
+	metas, err := s.AllValidMetadata(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range metas {
+		if len(m.GopFiles) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/goptest"
+)
+
+func TestNewGopRenamer(t *testing.T) {
+	pkg := types.NewPackage("example.com/foo", "foo")
+	sig := types.NewSignatureType(nil, nil, nil, nil, nil, false)
+	for _, name := range []string{"Add__0", "Add__1", "Addr"} {
+		pkg.Scope().Insert(types.NewFunc(token.NoPos, pkg, name, sig))
+	}
+	fset := token.NewFileSet()
+
+	r, err := newGopRenamer(fset, pkg.Scope().Lookup("Add__1"), "Sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"Add": "Sum", "Add__0": "Sum__0", "Add__1": "Sum__1"}
+	if len(r.names) != len(want) {
+		t.Errorf("got names %v, want %v", r.names, want)
+	}
+	for old, new := range want {
+		if r.names[old] != new {
+			t.Errorf("got names %v, want %v", r.names, want)
+			break
+		}
+	}
+	if got := r.newText("add", "Add"); got != "sum" {
+		t.Errorf("newText(add) = %q, want sum", got)
+	}
+	if got := r.newText("Add__0", "Add__0"); got != "Sum__0" {
+		t.Errorf("newText(Add__0) = %q, want Sum__0", got)
+	}
+	if !r.matches(pkg.Scope().Lookup("Add__0")) || r.matches(pkg.Scope().Lookup("Addr")) {
+		t.Errorf("matches: got the wrong objects")
+	}
+
+	if _, err := newGopRenamer(fset, pkg.Scope().Lookup("Add__0"), "Sum__0"); err != nil {
+		t.Errorf("renaming Add__0 to Sum__0: %v", err)
+	}
+	for _, newName := range []string{"Sum__1", "Add", "Add__0"} {
+		if _, err := newGopRenamer(fset, pkg.Scope().Lookup("Add__0"), newName); err == nil {
+			t.Errorf("renaming Add__0 to %s: got no error", newName)
+		}
+	}
+}
+
+func TestGopRenameCheck(t *testing.T) {
+	const src = `import "strings"
+
+func double(x int) int { return x * 2 }
+
+func half(x int) int { return x / 2 }
+
+f := func(g func(int) int) int { return g(1) }
+println f(a => double(a)), [double(v) for v <- [1, 2, 3]], strings.toUpper("a")
+`
+	p, err := goptest.Compile(t, "main", map[string]string{"/foo/bar.gop": src}, nil)
+	if err != nil {
+		t.Fatal("Compile:", err)
+	}
+	fset, files, info := p.Fset, p.List, p.Info
+	objectOf := func(name string) types.Object {
+		for _, m := range []map[*gopast.Ident]types.Object{info.Defs, info.Uses} {
+			for id, obj := range m {
+				if id.Name == name {
+					return obj
+				}
+			}
+		}
+		t.Fatalf("no object for %s", name)
+		return nil
+	}
+
+	tests := []struct {
+		from, to string
+		refs     []string // new spellings of the references
+		conflict string   // substring of the error, if any
+	}{
+		{"double", "triple", []string{"triple", "triple", "triple"}, ""},
+		{"double", "a", nil, "would cause this reference to become shadowed"}, // lambda parameter
+		{"double", "v", nil, "would cause this reference to become shadowed"}, // comprehension variable
+		{"double", "f", nil, "would cause this reference to become shadowed"}, // variable of the script
+		{"a", "x", []string{"x", "x"}, ""},
+		{"a", "double", nil, "would shadow this reference"},
+		{"v", "double", nil, "would shadow this reference"},
+		{"f", "double", nil, "would shadow this reference"},
+		{"double", "half", nil, "conflicts with func in same block"},
+		{"toUpper", "ToUpperCase", []string{"toUpperCase"}, ""},
+		{"toUpper", "upper", nil, "would make it unexported"},
+	}
+	for _, test := range tests {
+		r, err := newGopRenamer(fset, objectOf(test.from), test.to)
+		if err != nil {
+			t.Errorf("renaming %s to %s: %v", test.from, test.to, err)
+			continue
+		}
+		refs := r.gopRefs(info)
+		r.checkGop(p.Types, files, info, refs)
+		if test.conflict != "" {
+			if !r.hadConflicts || !strings.Contains(r.errors, test.conflict) {
+				t.Errorf("renaming %s to %s: got errors %q, want %q", test.from, test.to, r.errors, test.conflict)
+			}
+			continue
+		}
+		if r.hadConflicts {
+			t.Errorf("renaming %s to %s: unexpected errors %q", test.from, test.to, r.errors)
+			continue
+		}
+		var got []string
+		for _, ref := range refs {
+			got = append(got, r.newText(ref.id.Name, ref.obj.Name()))
+		}
+		if strings.Join(got, " ") != strings.Join(test.refs, " ") {
+			t.Errorf("renaming %s to %s: got references %v, want %v", test.from, test.to, got, test.refs)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast/astutil"
+	goptoken "github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/event"
+	"github.com/goplus/gox"
+)
+
+// GopSignatureHelp is SignatureHelp for a Go+ file.
+//
+// Besides parenthesized calls, it supports command-style calls such as
+// `println "a", x`, including a function name followed only by spaces.
+// An overloaded function has a signature per overload, such as Add__0 and
+// Add__1 for Add; activeSignature is the first overload that accepts the
+// types of the arguments typed so far.
+func GopSignatureHelp(ctx context.Context, snapshot Snapshot, fh FileHandle, position protocol.Position) (signatures []protocol.SignatureInformation, activeSignature, activeParam int, err error) {
+	ctx, done := event.Start(ctx, "source.GopSignatureHelp")
+	defer done()
+
+	pkg, err := snapshot.PackageForFile(ctx, fh.URI(), TypecheckFull, NarrowestPackage)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("getting file for SignatureHelp: %w", err)
+	}
+	pgf, err := pkg.GopFile(fh.URI())
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("getting file for SignatureHelp: %w", err)
+	}
+	pos, err := pgf.Mapper.Pos(position)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	info := pkg.GetGopTypesInfo()
+	if info == nil {
+		return nil, 0, 0, fmt.Errorf("no type information for %s", fh.URI())
+	}
+
+	// Find a call expression surrounding the query position.
+	callExpr, err := gopCallAt(pgf, pos)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if callExpr == nil || callExpr.Fun == nil {
+		return nil, 0, 0, fmt.Errorf("cannot find an enclosing function")
+	}
+
+	name, fns, sigs := gopCallSignatures(info, callExpr.Fun)
+
+	// Handle builtin functions separately.
+	if len(fns) == 1 {
+		if obj, ok := fns[0].(*types.Builtin); ok {
+			sig, err := NewBuiltinSignature(ctx, snapshot, obj.Name())
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			paramInfo := make([]protocol.ParameterInformation, 0, len(sig.params))
+			for _, p := range sig.params {
+				paramInfo = append(paramInfo, protocol.ParameterInformation{Label: p})
+			}
+			return []protocol.SignatureInformation{{
+				Label:         sig.name + sig.Format(),
+				Documentation: sig.doc,
+				Parameters:    paramInfo,
+			}}, 0, gopActiveParameter(callExpr, len(sig.params), sig.variadic, pos), nil
+		}
+	}
+	if len(sigs) == 0 {
+		return nil, 0, 0, fmt.Errorf("cannot find signature for Fun %[1]T (%[1]v)", callExpr.Fun)
+	}
+
+	qf := gopQualifier(pkg.GetGopTypes())
+	i := &IdentifierInfo{Snapshot: snapshot, pkg: pkg}
+	for j, sig := range sigs {
+		var comment *ast.CommentGroup
+		if fns[j] != nil {
+			comment = gopDocComment(ctx, i, fns[j])
+		}
+		s := NewSignature(ctx, snapshot, pkg, sig, comment, qf)
+		paramInfo := make([]protocol.ParameterInformation, 0, len(s.params))
+		for _, p := range s.params {
+			paramInfo = append(paramInfo, protocol.ParameterInformation{Label: p})
+		}
+		signatures = append(signatures, protocol.SignatureInformation{
+			Label:         name + s.Format(),
+			Documentation: s.doc,
+			Parameters:    paramInfo,
+		})
+	}
+	activeSignature = gopActiveOverload(info, sigs, callExpr.Args)
+	sig := sigs[activeSignature]
+	return signatures, activeSignature, gopActiveParameter(callExpr, sig.Params().Len(), sig.Variadic(), pos), nil
+}
+
+// gopCallAt returns the call expression whose arguments surround pos.
+func gopCallAt(pgf *ParsedGopFile, pos token.Pos) (*gopast.CallExpr, error) {
+	path, _ := astutil.GopPathEnclosingInterval(pgf.File, pos, pos)
+	if path == nil {
+		return nil, fmt.Errorf("cannot find node enclosing position")
+	}
+	for _, node := range path {
+		switch node := node.(type) {
+		case *gopast.CallExpr:
+			if node.IsCommand() {
+				if pos > node.Fun.End() && pos <= node.NoParenEnd {
+					return node, nil
+				}
+			} else if pos >= node.Lparen && pos <= node.Rparen {
+				return node, nil
+			}
+		case *gopast.FuncLit, *gopast.FuncType, *gopast.LambdaExpr, *gopast.LambdaExpr2:
+			// The user is within an anonymous function,
+			// which may be the parameter to the *gopast.CallExpr.
+			// Don't show signature help in this case.
+			return nil, fmt.Errorf("no signature help within a function declaration")
+		case *gopast.BasicLit:
+			if node.Kind == goptoken.STRING {
+				return nil, fmt.Errorf("no signature help within a string literal")
+			}
+		}
+	}
+	return gopCommandBefore(pgf, pos), nil
+}
+
+// gopCommandBefore returns the command-style call of the expression
+// statement that ends before pos, with only spaces in between. The
+// statement is either a command-style call, whose last argument is then
+// active, or a function name, which the parser reads as a statement of its
+// own and which is returned as a call without arguments.
+func gopCommandBefore(pgf *ParsedGopFile, pos token.Pos) *gopast.CallExpr {
+	var call *gopast.CallExpr
+	gopast.Inspect(pgf.File, func(n gopast.Node) bool {
+		if call != nil {
+			return false
+		}
+		stmt, ok := n.(*gopast.ExprStmt)
+		if !ok {
+			return true
+		}
+		if !stmt.End().IsValid() || stmt.End() > pos {
+			return true
+		}
+		start, end := pgf.Tok.Offset(stmt.End()), pgf.Tok.Offset(pos)
+		if end > len(pgf.Src) || len(bytes.Trim(pgf.Src[start:end], " \t")) != 0 {
+			return true
+		}
+		switch x := stmt.X.(type) {
+		case *gopast.CallExpr:
+			if x.IsCommand() {
+				call = x
+			}
+		default:
+			if gopIsCommandName(x) {
+				call = &gopast.CallExpr{Fun: x, NoParenEnd: x.End()}
+			}
+		}
+		return false
+	})
+	return call
+}
+
+// gopIsCommandName reports whether x may be the function of a
+// command-style call.
+func gopIsCommandName(x gopast.Expr) bool {
+	switch x := x.(type) {
+	case *gopast.Ident:
+		return true
+	case *gopast.SelectorExpr:
+		return gopIsCommandName(x.X)
+	}
+	return false
+}
+
+// gopCallSignatures returns the name of the function that fun denotes, as
+// spelled in the call, and its signatures: one per overload if the
+// function is overloaded. Each signature has its function in fns, or nil
+// for a function value.
+func gopCallSignatures(info *typesutil.Info, fun gopast.Expr) (name string, fns []types.Object, sigs []*types.Signature) {
+	var obj types.Object
+	switch fun := fun.(type) {
+	case *gopast.Ident:
+		name, obj = fun.Name, info.ObjectOf(fun)
+	case *gopast.SelectorExpr:
+		name, obj = fun.Sel.Name, info.ObjectOf(fun.Sel)
+	default:
+		name = "func"
+	}
+	if obj, ok := obj.(*types.Builtin); ok {
+		return name, []types.Object{obj}, nil
+	}
+
+	t := info.TypeOf(fun)
+	if t == nil && obj != nil {
+		t = obj.Type()
+	}
+	overloads := gopOverloadFuncs(t)
+	if fn, ok := obj.(*types.Func); ok && overloads == nil {
+		overloads, _ = gox.CheckOverloadMethod(fn.Type().(*types.Signature))
+	}
+	if overloads != nil {
+		for _, fn := range overloads {
+			if sig, ok := fn.Type().(*types.Signature); ok {
+				fns = append(fns, fn)
+				sigs = append(sigs, sig)
+			}
+		}
+		return name, fns, sigs
+	}
+
+	// The types of gox, such as that of an overloaded function, panic in
+	// Underlying.
+	var sig *types.Signature
+	switch t := t.(type) {
+	case *types.Signature:
+		sig = t
+	case *types.Named:
+		sig, _ = t.Underlying().(*types.Signature)
+	}
+	if sig == nil {
+		return name, nil, nil
+	}
+	if _, ok := obj.(*types.Func); !ok {
+		obj = nil
+	}
+	return name, []types.Object{obj}, []*types.Signature{sig}
+}
+
+// gopOverloadFuncs returns the functions that t, the type of an overloaded
+// function of gox such as println, stands for, or nil if t isn't such a
+// type.
+func gopOverloadFuncs(t types.Type) []types.Object {
+	if t == nil {
+		return nil
+	}
+	// gox exposes the overloads only through the receiver of a method.
+	recv := types.NewParam(token.NoPos, nil, "", t)
+	fns, _ := gox.CheckOverloadMethod(types.NewSignature(recv, nil, nil, false))
+	return fns
+}
+
+// gopActiveOverload returns the index of the first of sigs that accepts
+// the types of args. Arguments without a type yet are accepted by any
+// signature.
+func gopActiveOverload(info *typesutil.Info, sigs []*types.Signature, args []gopast.Expr) int {
+Overloads:
+	for i, sig := range sigs {
+		params := sig.Params()
+		if !sig.Variadic() && len(args) > params.Len() {
+			continue
+		}
+		for j, arg := range args {
+			tv, ok := info.Types[arg]
+			if !ok || tv.Type == types.Typ[types.Invalid] {
+				continue
+			}
+			var param types.Type
+			if sig.Variadic() && j >= params.Len()-1 {
+				param = params.At(params.Len() - 1).Type().(*types.Slice).Elem()
+			} else {
+				param = params.At(j).Type()
+			}
+			if !gopAssignable(tv, param) {
+				continue Overloads
+			}
+		}
+		return i
+	}
+	return 0
+}
+
+// gopAssignable reports whether an argument of type and value tv is
+// assignable to a parameter of type param. Unlike types.AssignableTo, it
+// rejects an untyped constant that isn't representable by param, such as
+// 1.5 for an int.
+func gopAssignable(tv types.TypeAndValue, param types.Type) bool {
+	if b, ok := param.Underlying().(*types.Basic); ok && tv.Value != nil && b.Info()&types.IsInteger != 0 {
+		if constant.ToInt(tv.Value).Kind() != constant.Int {
+			return false
+		}
+	}
+	return types.AssignableTo(tv.Type, param)
+}
+
+// gopActiveParameter is activeParameter for a Go+ call, whose arguments
+// are not parenthesized if it is command-style.
+func gopActiveParameter(callExpr *gopast.CallExpr, numParams int, variadic bool, pos token.Pos) (activeParam int) {
+	if len(callExpr.Args) == 0 {
+		return 0
+	}
+	// First, check if the position is even in the range of the arguments.
+	start, end := callExpr.Lparen, callExpr.Rparen
+	if callExpr.IsCommand() {
+		start, end = callExpr.Fun.End(), callExpr.NoParenEnd
+	}
+	if !(start <= pos && pos <= end) {
+		return 0
+	}
+	for _, expr := range callExpr.Args {
+		if start == token.NoPos {
+			start = expr.Pos()
+		}
+		end = expr.End()
+		if start <= pos && pos <= end {
+			break
+		}
+		// Don't advance the active parameter for the last parameter of a variadic function.
+		if !variadic || activeParam < numParams-1 {
+			activeParam++
+		}
+		start = expr.Pos() + 1 // to account for commas
+	}
+	return activeParam
+}
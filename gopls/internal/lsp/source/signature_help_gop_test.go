@@ -0,0 +1,74 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/goptest"
+)
+
+func TestGopSignatureHelp(t *testing.T) {
+	const src = `import "github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl/internal/spx"
+
+x := 1
+println "a", x
+println spx.rand(1), spx.rand(1.5)
+spx.rand 2.5
+` + "println \n"
+	p, err := goptest.Compile(t, "main", map[string]string{"/foo/bar.gop": src}, nil)
+	if err != nil {
+		t.Fatal("Compile:", err)
+	}
+	pgf := &ParsedGopFile{File: p.Files["/foo/bar.gop"], Tok: p.Tok("/foo/bar.gop"), Src: []byte(src)}
+
+	tests := []struct {
+		before          string // the source before the position
+		name            string
+		sigs            int
+		activeSignature int
+		activeParam     int
+	}{
+		{`println "a`, "", 0, 0, 0}, // within a string literal
+		{`println "a", `, "println", 1, 0, 0},
+		{`println "a", x`, "println", 1, 0, 0}, // variadic
+		{`println spx.rand(`, "rand", 2, 0, 0},
+		{`spx.rand(1.`, "rand", 2, 1, 0},
+		{`spx.rand 2`, "rand", 2, 1, 0},
+		{"x\nprintln ", "println", 1, 0, 0},
+		{"2.5\nprintln ", "println", 1, 0, 0}, // a command without arguments
+		{"2.5", "rand", 2, 1, 0},
+	}
+	for _, test := range tests {
+		off := strings.Index(src, test.before)
+		if off < 0 {
+			t.Fatalf("%q not found", test.before)
+		}
+		pos := pgf.Tok.Pos(off + len(test.before))
+		call, err := gopCallAt(pgf, pos)
+		if test.name == "" {
+			if err == nil && call != nil {
+				t.Errorf("%q: got a call, want none", test.before)
+			}
+			continue
+		}
+		if err != nil || call == nil {
+			t.Errorf("%q: got no call (%v)", test.before, err)
+			continue
+		}
+		name, fns, sigs := gopCallSignatures(p.Info, call.Fun)
+		if name != test.name || len(sigs) != test.sigs || len(fns) != len(sigs) {
+			t.Errorf("%q: got %s with %d signatures, want %s with %d", test.before, name, len(sigs), test.name, test.sigs)
+			continue
+		}
+		activeSignature := gopActiveOverload(p.Info, sigs, call.Args)
+		sig := sigs[activeSignature]
+		activeParam := gopActiveParameter(call, sig.Params().Len(), sig.Variadic(), pos)
+		if activeSignature != test.activeSignature || activeParam != test.activeParam {
+			t.Errorf("%q: got signature %d, parameter %d, want %d, %d", test.before, activeSignature, activeParam, test.activeSignature, test.activeParam)
+		}
+	}
+}
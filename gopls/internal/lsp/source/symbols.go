@@ -20,6 +20,10 @@ func DocumentSymbols(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]p
 	ctx, done := event.Start(ctx, "source.DocumentSymbols")
 	defer done()
 
+	if snapshot.View().FileKind(fh) == Gop {
+		return gopDocumentSymbols(ctx, snapshot, fh)
+	}
+
 	content, err := fh.Read()
 	if err != nil {
 		return nil, err
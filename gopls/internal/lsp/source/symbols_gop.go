@@ -0,0 +1,395 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/token"
+
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	gopformat "github.com/Deng-Xian-Sheng/goplus-lsp/gop/format"
+	goptoken "github.com/Deng-Xian-Sheng/goplus-lsp/gop/token"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/lsppos"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	"github.com/goplus/mod/gopmod"
+)
+
+// gopDocumentSymbols is DocumentSymbols for a Go+ file.
+//
+// The statements of a script appear as a function named after the entry
+// point that the compiler puts them in, such as main. In a classfile, the
+// class appears as a Class whose children are its fields, its methods and
+// its entry point, such as Main.
+func gopDocumentSymbols(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]protocol.DocumentSymbol, error) {
+	pgf, err := snapshot.ParseGop(ctx, fh, ParseFull)
+	if err != nil {
+		return nil, fmt.Errorf("getting file for DocumentSymbols: %w", err)
+	}
+	var classes map[string]*gopmod.Class
+	if metas, err := snapshot.MetadataForFile(ctx, fh.URI()); err == nil && len(metas) > 0 {
+		classes = metas[0].GopClasses
+	}
+	isProj, isClass := GopClassKind(pgf.URI.Filename(), pgf.File, classes)
+
+	m := lsppos.NewTokenMapper(pgf.Src, pgf.Tok)
+	entry := GopShadowEntry(pgf.File)
+	var fields *gopast.GenDecl
+	if isClass {
+		fields = GopClassFields(pgf.File)
+	}
+
+	// As in DocumentSymbols, declarations with errors are skipped.
+	var symbols, members []protocol.DocumentSymbol
+	var start, end token.Pos // the extent of the members of the class
+	addMember := func(s protocol.DocumentSymbol, node gopast.Node) {
+		members = append(members, s)
+		if !start.IsValid() || node.Pos() < start {
+			start = node.Pos()
+		}
+		if node.End() > end {
+			end = node.End()
+		}
+	}
+	for _, decl := range pgf.File.Decls {
+		switch decl := decl.(type) {
+		case *gopast.FuncDecl:
+			if decl.Name.Name == "_" {
+				continue
+			}
+			if decl == entry {
+				es, err := gopEntrySymbol(m, entry, GopEntrypoint(pgf.File, isProj, isClass))
+				if err != nil {
+					continue
+				}
+				if isClass {
+					es.Kind = protocol.Method
+					addMember(es, entry.Body)
+				} else {
+					symbols = append(symbols, es)
+				}
+				continue
+			}
+			fs, err := gopFuncSymbol(m, decl)
+			if err != nil {
+				continue
+			}
+			if isClass {
+				// All functions of a classfile are methods of its class.
+				fs.Kind = protocol.Method
+				addMember(fs, decl)
+				continue
+			}
+			// If function is a method, prepend the type of the method.
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				fs.Name = fmt.Sprintf("(%s).%s", GopExprString(decl.Recv.List[0].Type), fs.Name)
+			}
+			symbols = append(symbols, fs)
+		case *gopast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *gopast.TypeSpec:
+					if spec.Name.Name == "_" {
+						continue
+					}
+					ts, err := gopTypeSymbol(m, spec)
+					if err == nil {
+						symbols = append(symbols, ts)
+					}
+				case *gopast.ValueSpec:
+					for _, name := range spec.Names {
+						if name.Name == "_" {
+							continue
+						}
+						vs, err := gopVarSymbol(m, spec, name, decl.Tok == goptoken.CONST)
+						if err != nil {
+							continue
+						}
+						if decl == fields {
+							vs.Kind = protocol.Field
+							addMember(vs, spec)
+						} else {
+							symbols = append(symbols, vs)
+						}
+					}
+				}
+			}
+		}
+	}
+	if !isClass {
+		return symbols, nil
+	}
+
+	class := protocol.DocumentSymbol{
+		Name:     cl.ClassNameOf(pgf.URI.Filename(), isProj),
+		Kind:     protocol.Class,
+		Children: members,
+	}
+	if !start.IsValid() {
+		start = token.Pos(pgf.Tok.Base())
+		end = start
+	}
+	if class.Range, err = m.Range(start, end); err != nil {
+		return symbols, nil
+	}
+	if class.SelectionRange, err = m.Range(start, start); err != nil {
+		return symbols, nil
+	}
+	return append([]protocol.DocumentSymbol{class}, symbols...), nil
+}
+
+// GopShadowEntry returns the function that the parser declares for the
+// statements at the top level of f, or nil if f has none. The compiler
+// renames it after the entry point of f; see GopEntrypoint.
+func GopShadowEntry(f *gopast.File) *gopast.FuncDecl {
+	if !f.NoEntrypoint {
+		return nil
+	}
+	for _, decl := range f.Decls {
+		// Unlike a declared function, the shadow entry has no braces.
+		if fn, ok := decl.(*gopast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" && fn.Body != nil && !fn.Body.Lbrace.IsValid() {
+			return fn
+		}
+	}
+	return nil
+}
+
+// GopEntrypoint returns the name of the entry point of f, as cl does:
+// MainEntry for a project classfile, Main for a classfile, and main or init
+// for a script, depending on its package.
+func GopEntrypoint(f *gopast.File, isProj, isClass bool) string {
+	switch {
+	case isProj:
+		return "MainEntry"
+	case isClass:
+		return "Main"
+	case f.Name != nil && f.Name.Name != "main":
+		return "init"
+	}
+	return "main"
+}
+
+// GopClassFields returns the declaration of the fields of the class of a
+// classfile f: its first var declaration, if only imports and constants
+// precede it.
+func GopClassFields(f *gopast.File) *gopast.GenDecl {
+	for _, decl := range f.Decls {
+		g, ok := decl.(*gopast.GenDecl)
+		if !ok {
+			return nil
+		}
+		switch g.Tok {
+		case goptoken.IMPORT, goptoken.CONST:
+			continue
+		case goptoken.VAR:
+			return g
+		}
+		return nil
+	}
+	return nil
+}
+
+// GopExprString returns the Go+ source of x, as types.ExprString does for
+// a Go expression.
+func GopExprString(x gopast.Expr) string {
+	var buf bytes.Buffer
+	// Without the file of x, the printer ignores its positions and thus its
+	// line breaks.
+	if err := gopformat.Node(&buf, token.NewFileSet(), x); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// gopEntrySymbol returns the symbol of the shadow entry of a file, named
+// name, which spans the statements at the top level of the file.
+func gopEntrySymbol(m *lsppos.TokenMapper, entry *gopast.FuncDecl, name string) (protocol.DocumentSymbol, error) {
+	if len(entry.Body.List) == 0 {
+		return protocol.DocumentSymbol{}, fmt.Errorf("no statements")
+	}
+	s := protocol.DocumentSymbol{
+		Name:   name,
+		Kind:   protocol.Function,
+		Detail: "func()",
+	}
+	var err error
+	s.Range, err = m.NodeRange(entry.Body) // without braces, it spans the statements
+	if err != nil {
+		return protocol.DocumentSymbol{}, err
+	}
+	s.SelectionRange = protocol.Range{Start: s.Range.Start, End: s.Range.Start}
+	return s, nil
+}
+
+func gopFuncSymbol(m *lsppos.TokenMapper, decl *gopast.FuncDecl) (protocol.DocumentSymbol, error) {
+	s := protocol.DocumentSymbol{
+		Name: decl.Name.Name,
+		Kind: protocol.Function,
+	}
+	if decl.Recv != nil {
+		s.Kind = protocol.Method
+	}
+	var err error
+	s.Range, err = m.Range(decl.Pos(), decl.End())
+	if err != nil {
+		return protocol.DocumentSymbol{}, err
+	}
+	s.SelectionRange, err = m.Range(decl.Name.Pos(), decl.Name.End())
+	if err != nil {
+		return protocol.DocumentSymbol{}, err
+	}
+	s.Detail = GopExprString(decl.Type)
+	return s, nil
+}
+
+func gopTypeSymbol(m *lsppos.TokenMapper, spec *gopast.TypeSpec) (protocol.DocumentSymbol, error) {
+	s := protocol.DocumentSymbol{
+		Name: spec.Name.Name,
+	}
+	var err error
+	s.Range, err = m.NodeRange(spec)
+	if err != nil {
+		return protocol.DocumentSymbol{}, err
+	}
+	s.SelectionRange, err = m.NodeRange(spec.Name)
+	if err != nil {
+		return protocol.DocumentSymbol{}, err
+	}
+	s.Kind, s.Detail, s.Children = gopTypeDetails(m, spec.Type)
+	return s, nil
+}
+
+func gopTypeDetails(m *lsppos.TokenMapper, typExpr gopast.Expr) (kind protocol.SymbolKind, detail string, children []protocol.DocumentSymbol) {
+	switch typExpr := typExpr.(type) {
+	case *gopast.StructType:
+		kind = protocol.Struct
+		children = gopFieldListSymbols(m, typExpr.Fields, protocol.Field)
+		if len(children) > 0 {
+			detail = "struct{...}"
+		} else {
+			detail = "struct{}"
+		}
+
+	case *gopast.InterfaceType:
+		kind = protocol.Interface
+		children = gopFieldListSymbols(m, typExpr.Methods, protocol.Method)
+		if len(children) > 0 {
+			detail = "interface{...}"
+		} else {
+			detail = "interface{}"
+		}
+
+	case *gopast.FuncType:
+		kind = protocol.Function
+		detail = GopExprString(typExpr)
+
+	default:
+		kind = protocol.Class // catch-all, for cases where we don't know the kind syntactically
+		detail = GopExprString(typExpr)
+	}
+	return
+}
+
+func gopFieldListSymbols(m *lsppos.TokenMapper, fields *gopast.FieldList, fieldKind protocol.SymbolKind) []protocol.DocumentSymbol {
+	if fields == nil {
+		return nil
+	}
+
+	var symbols []protocol.DocumentSymbol
+	for _, field := range fields.List {
+		detail, children := "", []protocol.DocumentSymbol(nil)
+		if field.Type != nil {
+			_, detail, children = gopTypeDetails(m, field.Type)
+		}
+		if len(field.Names) == 0 { // embedded interface or struct field
+			child := protocol.DocumentSymbol{
+				Name:     detail,
+				Kind:     protocol.Field, // consider all embeddings to be fields
+				Children: children,
+			}
+
+			// If the field is a valid embedding, promote the type name to field
+			// name.
+			var selection gopast.Node = field.Type
+			if id := gopEmbeddedIdent(field.Type); id != nil {
+				child.Name = id.Name
+				child.Detail = detail
+				selection = id
+			}
+
+			if rng, err := m.NodeRange(field.Type); err == nil {
+				child.Range = rng
+			}
+			if rng, err := m.NodeRange(selection); err == nil {
+				child.SelectionRange = rng
+			}
+
+			symbols = append(symbols, child)
+		} else {
+			for _, name := range field.Names {
+				child := protocol.DocumentSymbol{
+					Name:     name.Name,
+					Kind:     fieldKind,
+					Detail:   detail,
+					Children: children,
+				}
+
+				if rng, err := m.NodeRange(field); err == nil {
+					child.Range = rng
+				}
+				if rng, err := m.NodeRange(name); err == nil {
+					child.SelectionRange = rng
+				}
+
+				symbols = append(symbols, child)
+			}
+		}
+	}
+	return symbols
+}
+
+// gopEmbeddedIdent is embeddedIdent for a Go+ type expression.
+func gopEmbeddedIdent(x gopast.Expr) *gopast.Ident {
+	if star, ok := x.(*gopast.StarExpr); ok {
+		x = star.X
+	}
+	if ix, ok := x.(*gopast.IndexExpr); ok { // check for instantiated receivers
+		x = ix.X
+	}
+	switch x := x.(type) {
+	case *gopast.Ident:
+		return x
+	case *gopast.SelectorExpr:
+		if _, ok := x.X.(*gopast.Ident); ok {
+			return x.Sel
+		}
+	}
+	return nil
+}
+
+func gopVarSymbol(m *lsppos.TokenMapper, spec *gopast.ValueSpec, name *gopast.Ident, isConst bool) (protocol.DocumentSymbol, error) {
+	s := protocol.DocumentSymbol{
+		Name: name.Name,
+		Kind: protocol.Variable,
+	}
+	if isConst {
+		s.Kind = protocol.Constant
+	}
+	var err error
+	s.Range, err = m.NodeRange(spec)
+	if err != nil {
+		return protocol.DocumentSymbol{}, err
+	}
+	s.SelectionRange, err = m.NodeRange(name)
+	if err != nil {
+		return protocol.DocumentSymbol{}, err
+	}
+	if spec.Type != nil { // type may be missing from the syntax
+		_, s.Detail, s.Children = gopTypeDetails(m, spec.Type)
+	}
+	return s, nil
+}
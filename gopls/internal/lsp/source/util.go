@@ -17,10 +17,14 @@ import (
 	"strconv"
 	"strings"
 
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/gopenv"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/bug"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/typeparams"
+	modenv "github.com/goplus/mod/env"
+	"github.com/goplus/mod/gopmod"
 )
 
 // MappedRange provides mapped protocol.Range for a span.Range, accounting for
@@ -106,6 +110,49 @@ func GetParsedFile(ctx context.Context, snapshot Snapshot, fh FileHandle, pkgPol
 	return pkg, pgh, err
 }
 
+// GetParsedGopFile is like GetParsedFile, but for Go+ files.
+func GetParsedGopFile(ctx context.Context, snapshot Snapshot, fh FileHandle, pkgPolicy PackageFilter) (Package, *ParsedGopFile, error) {
+	pkg, err := snapshot.PackageForFile(ctx, fh.URI(), TypecheckWorkspace, pkgPolicy)
+	if err != nil {
+		return nil, nil, err
+	}
+	pgf, err := pkg.GopFile(fh.URI())
+	return pkg, pgf, err
+}
+
+// IsGopAutogenFile reports whether filename is one of the Go files that the
+// gop command generates for the Go+ files of a package: gop_autogen.go,
+// gop_autogen_test.go and gop_autogen2_test.go.
+func IsGopAutogenFile(filename string) bool {
+	return strings.HasPrefix(filepath.Base(filename), "gop_autogen")
+}
+
+// GopClassKind reports whether the Go+ file f named filename is compiled
+// as a project classfile or a classfile. The classfiles registered by the
+// gop.mod of the package of f, if any, take precedence over the default
+// ones recorded in f.
+func GopClassKind(filename string, f *gopast.File, classes map[string]*gopmod.Class) (isProj, isClass bool) {
+	if classes == nil {
+		return f.IsProj, f.IsClass
+	}
+	ext := filepath.Ext(filename)
+	class, ok := classes[ext]
+	return ok && ext == class.ProjExt, ok
+}
+
+// GopOverloadBase returns the common name of an overloaded function whose
+// name ends with "__" and the index of the overload, such as NewRange__0.
+func GopOverloadBase(name string) (string, bool) {
+	n := len(name)
+	if n < 4 || name[n-3:n-1] != "__" {
+		return "", false
+	}
+	if c := name[n-1]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'z') {
+		return "", false
+	}
+	return name[:n-3], true
+}
+
 func IsGenerated(ctx context.Context, snapshot Snapshot, uri span.URI) bool {
 	fh, err := snapshot.GetFile(ctx, uri)
 	if err != nil {
@@ -216,11 +263,42 @@ func FileKindForLang(langID string) FileKind {
 		return Tmpl
 	case "go.work":
 		return Work
+	case "gop", "goplus":
+		return Gop
+	case "gop.mod":
+		return GopMod
 	default:
 		return UnknownKind
 	}
 }
 
+// GopExtensions are the file extensions of Go+ source files: .gop files and
+// the classfile extensions that gop recognizes without a gop.mod.
+var GopExtensions = []string{".gop", ".gmx", ".spx"}
+
+// IsGopExt reports whether ext, including its leading dot, is the extension
+// of a Go+ source file.
+func IsGopExt(ext string) bool {
+	for _, gopExt := range GopExtensions {
+		if ext == gopExt {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadGopEnv returns the environment of the gop toolchain, which locates
+// the Go+ builtin packages. Unlike gopenv.Get, it reports an error rather
+// than panicking if GOPROOT can't be found.
+func LoadGopEnv() (gop *modenv.Gop, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			gop, err = nil, fmt.Errorf("loading the Go+ environment: %s", strings.TrimSpace(fmt.Sprint(e)))
+		}
+	}()
+	return gopenv.Get(), nil
+}
+
 func (k FileKind) String() string {
 	switch k {
 	case Go:
@@ -233,6 +311,10 @@ func (k FileKind) String() string {
 		return "tmpl"
 	case Work:
 		return "go.work"
+	case Gop:
+		return "gop"
+	case GopMod:
+		return "gop.mod"
 	default:
 		return fmt.Sprintf("unk%d", k)
 	}
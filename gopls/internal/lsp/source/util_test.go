@@ -81,3 +81,22 @@ func scanFile(fset *token.FileSet, name string, content []byte) *token.File {
 	}
 	return f
 }
+
+func TestIsGopExt(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want bool
+	}{
+		{".gop", true},
+		{".gmx", true},
+		{".spx", true},
+		{".go", false},
+		{"gop", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsGopExt(test.ext); got != test.want {
+			t.Errorf("IsGopExt(%q) = %v, want %v", test.ext, got, test.want)
+		}
+	}
+}
@@ -19,6 +19,8 @@ import (
 
 	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/go/packages"
+	gopast "github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/govulncheck"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/span"
@@ -27,6 +29,8 @@ import (
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/gocommand"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/imports"
 	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/packagesinternal"
+	"github.com/goplus/mod/gopmod"
+	gopmodfile "github.com/goplus/mod/modfile"
 	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/module"
 )
@@ -106,6 +110,11 @@ type Snapshot interface {
 	// If the file is not available, returns nil and an error.
 	ParseGo(ctx context.Context, fh FileHandle, mode ParseMode) (*ParsedGoFile, error)
 
+	// ParseGop returns the parsed Go+ AST for the file.
+	// Only ParseHeader and ParseFull modes are supported.
+	// If the file is not available, returns nil and an error.
+	ParseGop(ctx context.Context, fh FileHandle, mode ParseMode) (*ParsedGopFile, error)
+
 	// DiagnosePackage returns basic diagnostics, including list, parse, and type errors
 	// for pkg, grouped by file.
 	DiagnosePackage(ctx context.Context, pkg Package) (map[span.URI][]*Diagnostic, error)
@@ -140,6 +149,12 @@ type Snapshot interface {
 	// ParseMod is used to parse go.mod files.
 	ParseMod(ctx context.Context, fh FileHandle) (*ParsedModule, error)
 
+	// GopModFiles are the gop.mod files next to the snapshot's go.mod files.
+	GopModFiles(ctx context.Context) []span.URI
+
+	// ParseGopMod is used to parse gop.mod files.
+	ParseGopMod(ctx context.Context, fh FileHandle) (*ParsedGopModule, error)
+
 	// ModWhy returns the results of `go mod why` for the module specified by
 	// the given go.mod file.
 	ModWhy(ctx context.Context, fh FileHandle) (map[string]string, error)
@@ -350,6 +365,17 @@ type ParsedGoFile struct {
 	ParseErr scanner.ErrorList
 }
 
+// A ParsedGopFile contains the results of parsing a Go+ file.
+type ParsedGopFile struct {
+	URI      span.URI
+	Mode     ParseMode
+	File     *gopast.File
+	Tok      *token.File
+	Src      []byte
+	Mapper   *protocol.ColumnMapper
+	ParseErr scanner.ErrorList
+}
+
 // A ParsedModule contains the results of parsing a go.mod file.
 type ParsedModule struct {
 	URI         span.URI
@@ -358,6 +384,14 @@ type ParsedModule struct {
 	ParseErrors []*Diagnostic
 }
 
+// A ParsedGopModule contains the results of parsing a gop.mod file.
+type ParsedGopModule struct {
+	URI         span.URI
+	File        *gopmodfile.File
+	Mapper      *protocol.ColumnMapper
+	ParseErrors []*Diagnostic
+}
+
 // A ParsedWorkFile contains the results of parsing a go.work file.
 type ParsedWorkFile struct {
 	URI         span.URI
@@ -381,7 +415,9 @@ type Metadata struct {
 	Name            PackageName
 	GoFiles         []span.URI
 	CompiledGoFiles []span.URI
-	ForTest         PackagePath // package path under test, or ""
+	GopFiles        []span.URI               // Go+ files in the package directory, unknown to go list
+	GopClasses      map[string]*gopmod.Class // classfiles registered by gop.mod, by extension
+	ForTest         PackagePath              // package path under test, or ""
 	TypesSizes      types.Sizes
 	Errors          []packages.Error
 	DepsByImpPath   map[ImportPath]PackageID  // may contain dups; empty ID => missing
@@ -609,7 +645,7 @@ func (id FileIdentity) String() string {
 }
 
 // FileKind describes the kind of the file in question.
-// It can be one of Go,mod, Sum, or Tmpl.
+// It can be one of Go, Mod, Sum, Tmpl, Work, Gop or GopMod.
 type FileKind int
 
 const (
@@ -626,6 +662,10 @@ const (
 	Tmpl
 	// Work is a go.work file.
 	Work
+	// Gop is a Go+ source file, either a .gop file or a classfile.
+	Gop
+	// GopMod is a gop.mod file.
+	GopMod
 )
 
 // Analyzer represents a go/analysis analyzer with some boolean properties
@@ -693,12 +733,19 @@ type Package interface {
 	ParseMode() ParseMode
 	CompiledGoFiles() []*ParsedGoFile // (borrowed)
 	File(uri span.URI) (*ParsedGoFile, error)
+	GopFiles() []*ParsedGopFile // (borrowed)
+	GopFile(uri span.URI) (*ParsedGopFile, error)
 	GetSyntax() []*ast.File // (borrowed)
 	HasListOrParseErrors() bool
 
 	// Results of type checking:
 	GetTypes() *types.Package
 	GetTypesInfo() *types.Info
+	GetGopTypes() *types.Package                // nil unless the Go+ files were type-checked
+	GetGopTypesInfo() *typesutil.Info           // nil unless the Go+ files were type-checked
+	GetGopBuiltins() *types.Scope               // nil unless the Go+ files were type-checked
+	GopClass(uri span.URI) *types.TypeName      // nil unless the Go+ file is a classfile
+	GopGeneratedGo() (map[string][]byte, error) // the Go code compiled from the Go+ files, by Go file name
 	DirectDep(path PackagePath) (Package, error)
 	ResolveImportPath(path ImportPath) (Package, error)
 	Imports() []Package // new slice of all direct dependencies, unordered
@@ -751,12 +798,14 @@ const (
 	ListError                DiagnosticSource = "go list"
 	ParseError               DiagnosticSource = "syntax"
 	TypeError                DiagnosticSource = "compiler"
+	GopError                 DiagnosticSource = "gop"
 	ModTidyError             DiagnosticSource = "go mod tidy"
 	OptimizationDetailsError DiagnosticSource = "optimizer details"
 	UpgradeNotification      DiagnosticSource = "upgrade available"
 	Vulncheck                DiagnosticSource = "govulncheck"
 	TemplateError            DiagnosticSource = "template"
 	WorkFileError            DiagnosticSource = "go.work file"
+	GopModFileError          DiagnosticSource = "gop.mod file"
 )
 
 func AnalyzerErrorKind(name string) DiagnosticSource {
@@ -0,0 +1,147 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goptest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/go/analysis"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/analysis/gopsyntax"
+)
+
+// RunAnalyzer is analysistest.Run for an analyzer of Go+ files, which
+// requires gopsyntax.Analyzer only. For each package path, it compiles the
+// .gop and .spx files of dir/src/path (the latter as plain Go+ files, since
+// there is no classfile framework here), runs a on them despite compilation
+// errors, and checks its diagnostics against the '// want "regexp"'
+// comments of the files.
+func RunAnalyzer(t *testing.T, dir string, a *analysis.Analyzer, pkgs ...string) {
+	t.Helper()
+	for _, pkgPath := range pkgs {
+		pkgDir := filepath.Join(dir, "src", filepath.FromSlash(pkgPath))
+		entries, err := ioutil.ReadDir(pkgDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := make(map[string]string)
+		for _, entry := range entries {
+			if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".gop" || ext == ".spx") {
+				src, err := ioutil.ReadFile(filepath.Join(pkgDir, entry.Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				files[filepath.Join(pkgDir, entry.Name())] = string(src)
+			}
+		}
+		if len(files) == 0 {
+			t.Fatalf("no Go+ files in %s", pkgDir)
+		}
+
+		p, _ := Compile(t, pkgPath, files, nil)
+		var diags []analysis.Diagnostic
+		pass := &analysis.Pass{
+			Analyzer: a,
+			Fset:     p.Fset,
+			ResultOf: map[*analysis.Analyzer]interface{}{
+				gopsyntax.Analyzer: &gopsyntax.Package{Fset: p.Fset, Files: p.List, Types: p.Types, TypesInfo: p.Info},
+			},
+			Report: func(d analysis.Diagnostic) { diags = append(diags, d) },
+		}
+		if _, err := a.Run(pass); err != nil {
+			t.Errorf("%s: %v", pkgPath, err)
+			continue
+		}
+		checkDiagnostics(t, p, diags)
+	}
+}
+
+// checkDiagnostics reports the diagnostics that no '// want' comment of
+// the files of p expects, and the expectations that none matches.
+func checkDiagnostics(t *testing.T, p *Package, diags []analysis.Diagnostic) {
+	type key struct {
+		file string
+		line int
+	}
+	want := make(map[key][]*regexp.Regexp)
+	for _, f := range p.List {
+		for _, cgroup := range f.Comments {
+			for _, c := range cgroup.List {
+				text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+				rest := strings.TrimPrefix(text, "want")
+				if rest == text {
+					continue
+				}
+				posn := p.Fset.Position(c.Pos())
+				rxs, err := parseWant(rest)
+				if err != nil {
+					t.Errorf("%s: in 'want' comment: %v", posn, err)
+					continue
+				}
+				k := key{posn.Filename, posn.Line}
+				want[k] = append(want[k], rxs...)
+			}
+		}
+	}
+
+	for _, d := range diags {
+		posn := p.Fset.Position(d.Pos)
+		k := key{posn.Filename, posn.Line}
+		matched := false
+		for i, rx := range want[k] {
+			if rx.MatchString(d.Message) {
+				want[k] = append(want[k][:i], want[k][i+1:]...)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			t.Errorf("%s: unexpected diagnostic: %s", posn, d.Message)
+		}
+	}
+	var unmatched []key // sorted for determinism
+	for k, rxs := range want {
+		if len(rxs) > 0 {
+			unmatched = append(unmatched, k)
+		}
+	}
+	sort.Slice(unmatched, func(i, j int) bool {
+		if unmatched[i].file != unmatched[j].file {
+			return unmatched[i].file < unmatched[j].file
+		}
+		return unmatched[i].line < unmatched[j].line
+	})
+	for _, k := range unmatched {
+		for _, rx := range want[k] {
+			t.Errorf("%s:%d: no diagnostic was reported matching %#q", k.file, k.line, rx)
+		}
+	}
+}
+
+// parseWant parses the string literals of a '// want' comment as regular
+// expressions.
+func parseWant(text string) ([]*regexp.Regexp, error) {
+	var rxs []*regexp.Regexp
+	for text = strings.TrimSpace(text); text != ""; text = strings.TrimSpace(text) {
+		lit, err := strconv.QuotedPrefix(text)
+		if err != nil {
+			return nil, fmt.Errorf("want a string literal, got %q", text)
+		}
+		text = text[len(lit):]
+		pattern, _ := strconv.Unquote(lit)
+		rx, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		rxs = append(rxs, rx)
+	}
+	return rxs, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package goptest provides the fixture of the tests of the Go+ support:
+// compiling Go+ files against the Go+ tree of this repository.
+package goptest
+
+import (
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/ast"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/cl"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/parser"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gop/x/typesutil"
+	"github.com/goplus/gox"
+	"github.com/goplus/mod/env"
+)
+
+// Root returns the root of the Go+ tree of this repository.
+func Root() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "../../../../../gop-1.1.3")
+}
+
+// NewImporter returns an importer of the packages of the Go+ tree and of
+// the standard library.
+func NewImporter(fset *token.FileSet) *gop.Importer {
+	return gop.NewImporter(nil, &env.Gop{Root: Root(), Version: "1.0"}, fset)
+}
+
+// A Package is a compiled Go+ package.
+type Package struct {
+	Fset  *token.FileSet
+	Files map[string]*ast.File // by file name
+	List  []*ast.File          // sorted by file name
+	Out   *gox.Package         // nil if the compilation failed early
+	Types *types.Package       // the types of Out
+	Info  *typesutil.Info
+}
+
+// Tok returns the token.File of the named file. (A Go+ file may have no
+// package clause, and then no position of its own.)
+func (p *Package) Tok(filename string) *token.File {
+	var tok *token.File
+	p.Fset.Iterate(func(f *token.File) bool {
+		if f.Name() == filename {
+			tok = f
+		}
+		return tok == nil
+	})
+	return tok
+}
+
+// Compile parses the Go+ files of a package, given by name and content,
+// and compiles them as pkgPath with conf, a nil conf standing for
+// &cl.Config{NoFileLine: true}. It fills the Fset, Importer and Recorder
+// of conf if they are unset.
+//
+// Parse errors are fatal. Compile returns the compilation error along
+// with the package, whose Out and Types are set if the compiler got that far.
+func Compile(t testing.TB, pkgPath string, files map[string]string, conf *cl.Config) (*Package, error) {
+	t.Helper()
+	if conf == nil {
+		conf = &cl.Config{NoFileLine: true}
+	}
+	if conf.Fset == nil {
+		conf.Fset = token.NewFileSet()
+	}
+	if conf.Importer == nil {
+		conf.Importer = NewImporter(conf.Fset)
+	}
+	p := &Package{Fset: conf.Fset, Files: make(map[string]*ast.File), Info: typesutil.NewInfo()}
+	if conf.Recorder == nil {
+		conf.Recorder = typesutil.NewRecorder(p.Info)
+	}
+
+	var filenames []string
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	name := "main"
+	for _, filename := range filenames {
+		f, err := parser.ParseFile(p.Fset, filename, files[filename], parser.ParseComments)
+		if err != nil {
+			t.Fatalf("ParseFile(%s): %v", filename, err)
+		}
+		if f.Name != nil {
+			name = f.Name.Name
+		}
+		p.Files[filename] = f
+		p.List = append(p.List, f)
+	}
+
+	out, err := cl.NewPackage(pkgPath, &ast.Package{Name: name, Files: p.Files}, conf)
+	if out != nil {
+		p.Out, p.Types = out, out.Types
+	}
+	return p, err
+}
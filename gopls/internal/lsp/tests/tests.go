@@ -276,6 +276,7 @@ func DefaultOptions(o *source.Options) {
 		source.Sum:  {},
 		source.Work: {},
 		source.Tmpl: {},
+		source.Gop:  {},
 	}
 	o.UserOptions.Codelenses[string(command.Test)] = true
 	o.HoverKind = source.SynopsisDocumentation
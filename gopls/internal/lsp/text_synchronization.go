@@ -195,6 +195,7 @@ func (s *Server) didSave(ctx context.Context, params *protocol.DidSaveTextDocume
 func (s *Server) didClose(ctx context.Context, params *protocol.DidCloseTextDocumentParams) error {
 	uri := params.TextDocument.URI.SpanURI()
 	if !uri.IsFile() {
+		s.gopGeneratedGoClosed(params.TextDocument.URI)
 		return nil
 	}
 	return s.didModifyFiles(ctx, []source.FileModification{
@@ -318,6 +319,8 @@ func (s *Server) processModifications(ctx context.Context, modifications []sourc
 		}
 	}
 
+	s.gopGeneratedGoChanged(ctx, snapshots)
+
 	go func() {
 		s.diagnoseSnapshots(snapshots, onDisk)
 		release()
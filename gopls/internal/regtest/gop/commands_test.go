@@ -0,0 +1,110 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gop
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/command"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	. "github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/regtest"
+)
+
+const greet = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.gop --
+func greet(name string) {
+	println "hello", name
+}
+
+greet "world"
+`
+
+// TestGopGenGo checks that the code lens of gopls.gop_gen_go writes the
+// Go code of the Go+ files.
+func TestGopGenGo(t *testing.T) {
+	WithOptions(
+		Settings{
+			"codelenses": map[string]bool{
+				"gop_gen_go": true,
+			},
+		},
+	).Run(t, greet, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gop")
+		env.ExecuteCodeLensCommand("main.gop", command.GopGenGo, nil)
+		env.Await(env.DoneWithChangeWatchedFiles())
+		if got := env.ReadWorkspaceFile("gop_autogen.go"); !strings.Contains(got, "func greet(name string)") {
+			t.Errorf("gop_autogen.go: got\n%s\nwant a declaration of greet", got)
+		}
+	})
+}
+
+// TestGopShowGo checks that gopls.gop_show_go opens the Go code of a
+// package that compiles, and reports the errors of one that doesn't.
+func TestGopShowGo(t *testing.T) {
+	Run(t, greet, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gop")
+		cmd, err := command.NewGopShowGoCommand("", command.URIArg{URI: env.Sandbox.Workdir.URI("main.gop")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		params := &protocol.ExecuteCommandParams{Command: cmd.Command, Arguments: cmd.Arguments}
+		env.ExecuteCommand(params, nil)
+
+		env.RegexpReplace("main.gop", `greet "world"`, `greet 1`)
+		env.Await(env.DoneWithChange())
+		if _, err := env.Editor.ExecuteCommand(env.Ctx, params); err == nil {
+			t.Error("gopls.gop_show_go: got no error for a package that doesn't compile")
+		}
+	})
+}
+
+// TestGopModDiagnostics checks that the register directives of gop.mod
+// are checked against the requirements of the module.
+func TestGopModDiagnostics(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- gop.mod --
+gop 1.1
+
+register example.com/game
+-- main.gop --
+println "hello"
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("gop.mod")
+		env.Await(env.DiagnosticAtRegexpWithMessage("gop.mod", "register", "is not required by this module"))
+	})
+}
+
+// TestGopModHover checks the hover of the classfile directive of gop.mod.
+func TestGopModHover(t *testing.T) {
+	const files = `
+-- go.mod --
+module example.com/game
+
+go 1.18
+-- gop.mod --
+gop 1.1
+
+classfile .gamex .sprite example.com/game/engine
+-- engine/engine.go --
+package engine
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("gop.mod")
+		content, _ := env.Hover("gop.mod", env.RegexpSearch("gop.mod", "classfile"))
+		if content == nil || !strings.Contains(content.Value, "Project files: `*.gamex`") {
+			t.Errorf("Hover(classfile): got %v, want the project files", content)
+		}
+	})
+}
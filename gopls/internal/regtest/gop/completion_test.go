@@ -0,0 +1,61 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gop
+
+import (
+	"testing"
+
+	. "github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/regtest"
+)
+
+// TestGopCompletion checks that the completion candidates of a Go+ file
+// include the objects of the enclosing scopes, those of the top level of the
+// file and of the phrases of comprehensions among them, and the members of
+// the operand of a selector.
+func TestGopCompletion(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.gop --
+import "strings"
+
+func shout(msg string) string {
+	loud := strings.ToUpper(msg)
+	return loud
+}
+
+count := 3
+println [x*count for x <- [1, 2]]
+println strings.ToLower("A"), shout("a")
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gop")
+		for _, test := range []struct {
+			re   string // the position is that of the empty group
+			want []string
+		}{
+			{`return lo()ud`, []string{"loud"}},
+			{`x\*co()unt`, []string{"count"}},
+			{`\[x()\*`, []string{"x"}},
+			{`strings\.To()Lower`, []string{"ToLower", "ToUpper"}},
+			{`println s()trings`, []string{"shout", "strings"}},
+		} {
+			list := env.Completion("main.gop", env.RegexpSearch("main.gop", test.re))
+			got := make(map[string]bool)
+			if list != nil {
+				for _, item := range list.Items {
+					got[item.Label] = true
+				}
+			}
+			for _, label := range test.want {
+				if !got[label] {
+					t.Errorf("Completion(%s): no %s among %v", test.re, label, got)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gop
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/regtest"
+)
+
+// TestGopParseErrorsDontGateAnalysis checks that the parse errors of the
+// Go+ files of a package are reported without preventing the analysis of
+// its Go files.
+func TestGopParseErrorsDontGateAnalysis(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.go --
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Printf("%d", "x")
+}
+-- hello.gop --
+x :=
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		env.OpenFile("hello.gop")
+		env.Await(
+			OnceMet(
+				env.DoneWithOpen(),
+				DiagnosticAt("hello.gop", 1, 0),
+				env.DiagnosticAtRegexpWithMessage("main.go", "fmt.Printf", "wrong type"),
+			),
+		)
+	})
+}
+
+// TestGopCompileErrors checks that the errors of the Go+ compiler are
+// reported on the Go+ files, and cleared once fixed.
+func TestGopCompileErrors(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.gop --
+x := 1
+println y
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gop")
+		env.Await(env.DiagnosticAtRegexpWithMessage("main.gop", "y", "undefined"))
+		env.RegexpReplace("main.gop", "println y", "println x")
+		env.Await(
+			OnceMet(
+				env.DoneWithChange(),
+				EmptyDiagnostics("main.gop"),
+			),
+		)
+	})
+}
+
+// TestGopImportsWorkspacePackage checks that the Go+ files of a package of
+// Go+ files only see the workspace packages they import, edits included.
+func TestGopImportsWorkspacePackage(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- lib/lib.go --
+package lib
+
+func Hello() {}
+-- main.gop --
+import "mod.com/lib"
+
+lib.Hello()
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gop")
+		env.Await(
+			OnceMet(
+				env.DoneWithOpen(),
+				EmptyDiagnostics("main.gop"),
+			),
+		)
+		env.OpenFile("lib/lib.go")
+		env.RegexpReplace("lib/lib.go", "Hello", "Goodbye")
+		env.Await(env.DiagnosticAtRegexpWithMessage("main.gop", "lib.Hello", "Hello"))
+	})
+}
+
+// TestGoImportsGopPackage checks that the Go files importing a package see
+// the declarations of its Go+ files, without the Go code generated for them.
+func TestGoImportsGopPackage(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- lib/greeting.go --
+package lib
+
+type greeting string
+-- lib/lib.gop --
+package lib
+
+func Hello() greeting {
+	return "hello"
+}
+-- main.go --
+package main
+
+import "mod.com/lib"
+
+func main() {
+	println(string(lib.Hello()))
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		env.Await(
+			OnceMet(
+				env.DoneWithOpen(),
+				EmptyDiagnostics("main.go"),
+			),
+		)
+		env.OpenFile("lib/lib.gop")
+		env.RegexpReplace("lib/lib.gop", "Hello", "Goodbye")
+		env.Await(env.DiagnosticAtRegexpWithMessage("main.go", "Hello", "lib.Hello"))
+	})
+}
+
+// TestGopRegisteredClassfile checks that the files of a classfile that the
+// gop.mod of the module registers are handled as Go+ files.
+func TestGopRegisteredClassfile(t *testing.T) {
+	const files = `
+-- go.mod --
+module example.com/game
+
+go 1.18
+-- gop.mod --
+module example.com/game
+
+gop 1.1
+
+classfile .gamex .sprite example.com/game/engine
+-- engine/engine.go --
+package engine
+
+type Game struct{}
+
+func (g *Game) Main() {}
+
+type Sprite struct{}
+-- main.gamex --
+// greet greets name.
+func greet(name string) {
+	println "hello", name
+}
+
+greet "world"
+println y
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gamex")
+		env.Await(env.DiagnosticAtRegexpWithMessage("main.gamex", "y", "undefined"))
+		content, _ := env.Hover("main.gamex", env.RegexpSearch("main.gamex", `greet "world"`))
+		if content == nil || !strings.Contains(content.Value, "greet greets name.") {
+			t.Errorf("Hover(greet): got %v, want its documentation", content)
+		}
+	})
+}
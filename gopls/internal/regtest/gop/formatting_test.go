@@ -0,0 +1,89 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gop
+
+import (
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/tests/compare"
+
+	. "github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/regtest"
+)
+
+const unformatted = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.gop --
+import "fmt"
+
+func  greet(name string)  {
+fmt.Println("hello", name)
+}
+
+greet "world"
+`
+
+// TestGopFormatting checks that Go+ files are formatted as by gop fmt, and
+// rewritten in Go+ style with the gopstyle option.
+func TestGopFormatting(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		gopstyle bool
+		want     string
+	}{
+		{"gofmt", false, `import "fmt"
+
+func greet(name string) {
+	fmt.Println("hello", name)
+}
+
+greet "world"
+`},
+		{"gopstyle", true, `func greet(name string) {
+	println "hello", name
+}
+
+greet "world"
+`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			WithOptions(
+				Settings{"gopstyle": test.gopstyle},
+			).Run(t, unformatted, func(t *testing.T, env *Env) {
+				env.OpenFile("main.gop")
+				env.FormatBuffer("main.gop")
+				if got := env.Editor.BufferText("main.gop"); got != test.want {
+					t.Errorf("unexpected formatting result:\n%s", compare.Text(test.want, got))
+				}
+			})
+		})
+	}
+}
+
+// TestGopFormattingParseErrors checks that a Go+ file with parse errors is
+// left as is.
+func TestGopFormattingParseErrors(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.gop --
+func  greet(name string {
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gop")
+		want := env.Editor.BufferText("main.gop")
+		if err := env.Editor.FormatBuffer(env.Ctx, "main.gop"); err == nil {
+			t.Error("FormatBuffer: got no error")
+		}
+		if got := env.Editor.BufferText("main.gop"); got != want {
+			t.Errorf("unexpected formatting result:\n%s", compare.Text(want, got))
+		}
+	})
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gop
+
+import (
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/hooks"
+	. "github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/regtest"
+	"github.com/Deng-Xian-Sheng/goplus-lsp/internal/bug"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+)
+
+func TestMain(m *testing.M) {
+	bug.PanicOnBugs = true
+	Main(m, hooks.Options)
+}
+
+// TestGopOnlyModule checks that the Go+ files of a module without Go files,
+// which go list doesn't report, belong to a package.
+func TestGopOnlyModule(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.gop --
+x := 1
+println x
+-- sub/sub.gop --
+package sub
+
+func Hello() {}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gop")
+		env.Await(env.DoneWithOpen())
+		checkSymbols(t, env, "main", "main.gop")
+		checkSymbols(t, env, "Hello", "sub/sub.gop")
+	})
+}
+
+// TestGopOnlyDir checks that a directory of Go+ files in a module of Go
+// files belongs to a package, whether it is loaded with the workspace or
+// when its first file is opened.
+func TestGopOnlyDir(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.go --
+package main
+
+func main() {}
+-- a/a.gop --
+package a
+
+func Hello() {}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		checkSymbols(t, env, "Hello", "a/a.gop")
+		env.CreateBuffer("b/b.gop", "package b\n\nfunc Goodbye() {}\n")
+		env.SaveBuffer("b/b.gop")
+		env.Await(env.DoneWithSave())
+		checkSymbols(t, env, "Goodbye", "b/b.gop")
+	})
+}
+
+// TestOpenGopFileNoReload checks that opening a Go+ file of a loaded
+// package doesn't reload the workspace.
+func TestOpenGopFileNoReload(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.go --
+package main
+
+func main() {}
+-- hello.gop --
+func hello() {}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("hello.gop")
+		env.Await(
+			OnceMet(
+				env.DoneWithOpen(),
+				LogMatching(protocol.Info, `packages\.Load #\d+\n`, 1, false),
+			),
+		)
+	})
+}
+
+// checkSymbols checks that the workspace symbols matching query are in the
+// file name.
+func checkSymbols(t *testing.T, env *Env, query, name string) {
+	t.Helper()
+	syms := env.Symbol(query)
+	if len(syms) == 0 {
+		t.Fatalf("Symbol(%q): no symbols", query)
+	}
+	for _, sym := range syms {
+		if sym.Location.Path != name {
+			t.Errorf("Symbol(%q): got symbol %s in %s, want in %s", query, sym.Name, sym.Location.Path, name)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gop
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/protocol"
+	. "github.com/Deng-Xian-Sheng/goplus-lsp/gopls/internal/lsp/regtest"
+)
+
+const navigation = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- lib/lib.go --
+package lib
+
+// Hello says hello.
+func Hello() {}
+-- main.gop --
+import "mod.com/lib"
+
+// greet greets name.
+func greet(name string) {
+	println "hello", name
+}
+
+greet "world"
+lib.Hello()
+`
+
+// TestGopHover checks that hovering in a Go+ file describes the Go+ and
+// the Go declarations it refers to.
+func TestGopHover(t *testing.T) {
+	Run(t, navigation, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gop")
+		for _, test := range []struct {
+			re, want string
+		}{
+			{`greet "world"`, "greet greets name."},
+			{`Hello\(\)`, "Hello says hello."},
+		} {
+			content, _ := env.Hover("main.gop", env.RegexpSearch("main.gop", test.re))
+			if content == nil || !strings.Contains(content.Value, test.want) {
+				t.Errorf("Hover(%s): got %v, want %q", test.re, content, test.want)
+			}
+		}
+	})
+}
+
+// TestGopDefinition checks that the definitions of the identifiers of a Go+
+// file are found, in Go+ and in Go files.
+func TestGopDefinition(t *testing.T) {
+	Run(t, navigation, func(t *testing.T, env *Env) {
+		env.OpenFile("main.gop")
+		for _, test := range []struct {
+			re, file, def string
+		}{
+			{`greet "world"`, "main.gop", `func (greet)`},
+			{`Hello\(\)`, "lib/lib.go", `func (Hello)`},
+		} {
+			name, pos := env.GoToDefinition("main.gop", env.RegexpSearch("main.gop", test.re))
+			if name != test.file {
+				t.Errorf("GoToDefinition(%s): got file %s, want %s", test.re, name, test.file)
+				continue
+			}
+			if want := env.RegexpSearch(name, test.def); pos != want {
+				t.Errorf("GoToDefinition(%s): got position %v, want %v", test.re, pos, want)
+			}
+		}
+	})
+}
+
+// TestGopIncomingCalls checks that the calls of a Go function from a Go+
+// file are found, in declared functions, in lambdas and at the top level.
+func TestGopIncomingCalls(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- lib/lib.go --
+package lib
+
+func Hello() {}
+-- main.gop --
+import "mod.com/lib"
+
+func greet() {
+	lib.Hello()
+}
+
+func run(fn func()) {
+	fn()
+}
+
+run => {
+	lib.Hello()
+}
+lib.Hello()
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("lib/lib.go")
+		var params protocol.CallHierarchyPrepareParams
+		params.TextDocument.URI = env.Sandbox.Workdir.URI("lib/lib.go")
+		params.Position = env.RegexpSearch("lib/lib.go", "Hello").ToProtocolPosition()
+		items, err := env.Editor.Server.PrepareCallHierarchy(env.Ctx, &params)
+		if err != nil || len(items) != 1 {
+			t.Fatalf("PrepareCallHierarchy: got %v, %v, want an item", items, err)
+		}
+		calls, err := env.Editor.Server.IncomingCalls(env.Ctx, &protocol.CallHierarchyIncomingCallsParams{Item: items[0]})
+		if err != nil {
+			t.Fatal("IncomingCalls:", err)
+		}
+		var got []string
+		for _, call := range calls {
+			got = append(got, call.From.Name)
+			if call.From.URI != env.Sandbox.Workdir.URI("main.gop") {
+				t.Errorf("IncomingCalls: got call from %s in %s, want in main.gop", call.From.Name, call.From.URI)
+			}
+		}
+		sort.Strings(got)
+		if want := []string{"greet", "main", "main.func()"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("IncomingCalls: got calls from %v, want from %v", got, want)
+		}
+	})
+}